@@ -11,7 +11,9 @@ import (
 
 	"github.com/Forceres/tg-bot-movieclub-go/internal/app"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/transport/telegram"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/transport/web"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/datepicker"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/fsm"
@@ -53,6 +55,7 @@ var allowedUpdates = []string{
 	AllowedUpdateChannelPost,
 	AllowedUpdateEditedChannelPost,
 	AllowedUpdateCallbackQuery,
+	AllowedUpdateInlineQuery,
 	AllowedUpdatePoll,
 	AllowedUpdatePollAnswer,
 }
@@ -103,11 +106,38 @@ func startLongPolling(ctx context.Context, opts []bot.Option, cfg *config.Config
 	}
 	datepicker.ScheduleDatepicker(b, services.ScheduleDatepicker)
 	datepicker.SessionDatepicker(b, services.SessionDatepicker)
+	datepicker.ExceptionDatepicker(b, services.ExceptionDatepicker)
 	app.RegisterHandlers(b, handlers, services, cfg)
+	if services.CalendarService.Enabled() {
+		go startCalendarServer(ctx, b, cfg, services)
+	}
 	b.Start(ctx)
 	return nil
 }
 
+// startCalendarServer serves the calendar feed when there is no webhook server to mount it on.
+func startCalendarServer(ctx context.Context, b *bot.Bot, cfg *config.Config, services *app.Services) {
+	mux := http.NewServeMux()
+	mux.Handle(service.CALENDAR_PATH, web.NewCalendarHandler(services.CalendarService, b, cfg.Telegram.GroupID))
+	server := &http.Server{
+		Addr:    cfg.Calendar.ListenAddress,
+		Handler: mux,
+	}
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down calendar server...")
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error during server shutdown: %v", err)
+		}
+	}()
+	err := server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		log.Printf("Failed to start calendar server: %v", err)
+	}
+}
+
 func startWebhook(ctx context.Context, opts []bot.Option, cfg *config.Config, handlers *app.Handlers, services *app.Services) error {
 	opts = append(opts, bot.WithWebhookSecretToken(cfg.Telegram.WebhookSecretToken))
 	b, err := bot.New(cfg.Telegram.BotToken, opts...)
@@ -130,6 +160,7 @@ func startWebhook(ctx context.Context, opts []bot.Option, cfg *config.Config, ha
 	// }()
 	datepicker.ScheduleDatepicker(b, services.ScheduleDatepicker)
 	datepicker.SessionDatepicker(b, services.SessionDatepicker)
+	datepicker.ExceptionDatepicker(b, services.ExceptionDatepicker)
 	app.RegisterHandlers(b, handlers, services, cfg)
 	ok, err := b.SetWebhook(ctx, &bot.SetWebhookParams{
 		URL:                cfg.DomainAddress + "/webhook",
@@ -152,6 +183,10 @@ func startWebhook(ctx context.Context, opts []bot.Option, cfg *config.Config, ha
 
 	mux.Handle("/webhook", b.WebhookHandler())
 
+	if services.CalendarService.Enabled() {
+		mux.Handle(service.CALENDAR_PATH, web.NewCalendarHandler(services.CalendarService, b, cfg.Telegram.GroupID))
+	}
+
 	server := &http.Server{
 		Addr:    ":2000",
 		Handler: mux,
@@ -42,7 +42,7 @@ func main() {
 		log.Fatalf("Failed to create bot: %v", err)
 	}
 
-	app.RegisterTaskProcessors(services, b, mux)
+	app.RegisterTaskProcessors(cfg, services, b, mux)
 
 	log.Println("Starting Telegram Movie Club Worker...")
 	if err := srv.Run(mux); err != nil {
@@ -23,8 +23,14 @@ import (
 
 const (
 	statePrepareVotingType       fsm.StateID = "prepare_voting_type"
+	statePrepareVotingMethod     fsm.StateID = "prepare_voting_method"
 	statePrepareVotingTitle      fsm.StateID = "prepare_voting_title"
 	statePrepareVotingDuration   fsm.StateID = "prepare_voting_duration"
+	statePrepareVotingQuorum     fsm.StateID = "prepare_voting_quorum"
+	statePrepareQuorumPolicy     fsm.StateID = "prepare_quorum_policy"
+	statePrepareHideTally        fsm.StateID = "prepare_hide_tally"
+	statePrepareBallotMode       fsm.StateID = "prepare_ballot_mode"
+	statePrepareVotingSession    fsm.StateID = "prepare_voting_session"
 	statePrepareMovies           fsm.StateID = "prepare_movies"
 	stateStartVoting             fsm.StateID = "start_voting"
 	statePrepareCancelIDs        fsm.StateID = "prepare_cancel_ids"
@@ -39,6 +45,14 @@ const (
 	stateDescription             fsm.StateID = "description"
 	stateSaveDescription         fsm.StateID = "save_description"
 	statePrepareMovieSuggestions fsm.StateID = "prepare_movie_suggestions"
+	stateVenueAddress            fsm.StateID = "venue_address"
+	stateVenueRoom               fsm.StateID = "venue_room"
+	stateVenueNotes              fsm.StateID = "venue_notes"
+	stateSaveVenue               fsm.StateID = "save_venue"
+	stateScheduleWeekdays        fsm.StateID = "schedule_weekdays"
+	stateScheduleInterval        fsm.StateID = "schedule_interval"
+	stateSkipDate                fsm.StateID = "skip_date"
+	stateToggleException         fsm.StateID = "toggle_exception"
 )
 
 func PollAnswerMatchFunc() bot.MatchFunc {
@@ -47,15 +61,27 @@ func PollAnswerMatchFunc() bot.MatchFunc {
 	}
 }
 
+func InlineQueryMatchFunc() bot.MatchFunc {
+	return func(update *models.Update) bool {
+		return update != nil && update.InlineQuery != nil
+	}
+}
+
 type Handlers struct {
 	HelpHandler                     bot.HandlerFunc
 	CurrentMoviesHandler            bot.HandlerFunc
 	AlreadyWatchedMoviesHandler     bot.HandlerFunc
 	VotingHandler                   bot.HandlerFunc
 	PollAnswerHandler               bot.HandlerFunc
+	RankedBallotHandler             bot.HandlerFunc
+	SecretBallotHandler             bot.HandlerFunc
+	SecretBallotCallbackHandler     bot.HandlerFunc
 	SuggestMovieHandler             bot.HandlerFunc
 	CancelHandler                   bot.HandlerFunc
 	CancelVotingHandler             bot.HandlerFunc
+	CloseVotingHandler              bot.HandlerFunc
+	ExtendVotingHandler             bot.HandlerFunc
+	ReopenVotingHandler             bot.HandlerFunc
 	RegisterUserHandler             bot.HandlerFunc
 	UpdateChatMemberHandler         bot.HandlerFunc
 	ScheduleHandler                 bot.HandlerFunc
@@ -66,6 +92,24 @@ type Handlers struct {
 	AddMovieToSessionHandler        bot.HandlerFunc
 	CustomSessionDescriptionHandler bot.HandlerFunc
 	SuggestionsHandler              bot.HandlerFunc
+	ResultsHandler                  bot.HandlerFunc
+	TopHandler                      bot.HandlerFunc
+	RemindersHandler                bot.HandlerFunc
+	SessionsHandler                 bot.HandlerFunc
+	RsvpHandler                     bot.HandlerFunc
+	RsvpCallbackHandler             bot.HandlerFunc
+	HistoryHandler                  bot.HandlerFunc
+	HistoryCallbackHandler          bot.HandlerFunc
+	OrderHandler                    bot.HandlerFunc
+	OrderCallbackHandler            bot.HandlerFunc
+	RatingWindowHandler             bot.HandlerFunc
+	RestoreSessionHandler           bot.HandlerFunc
+	VenueHandler                    bot.HandlerFunc
+	CalendarHandler                 bot.HandlerFunc
+	SkipHandler                     bot.HandlerFunc
+	GrantSuggestionsHandler         bot.HandlerFunc
+	UnsuggestHandler                bot.HandlerFunc
+	InlineSearchHandler             bot.HandlerFunc
 }
 
 type Middlewares struct {
@@ -76,18 +120,22 @@ type Middlewares struct {
 }
 
 type Services struct {
-	UserService        service.IUserService
-	MovieService       service.IMovieService
-	KinopoiskService   service.IKinopoiskService
-	VotingService      service.IVotingService
-	PollService        service.IPollService
-	VoteService        service.IVoteService
-	ScheduleService    service.IScheduleService
-	SessionService     service.ISessionService
-	AsynqClient        *asynq.Client
-	AsynqInspector     *asynq.Inspector
-	ScheduleDatepicker *datepicker.Datepicker
-	SessionDatepicker  *datepicker.Datepicker
+	UserService         service.IUserService
+	MovieService        service.IMovieService
+	KinopoiskService    service.IKinopoiskService
+	VotingService       service.IVotingService
+	PollService         service.IPollService
+	VoteService         service.IVoteService
+	ScheduleService     service.IScheduleService
+	SessionService      service.ISessionService
+	AttendanceService   service.IAttendanceService
+	CalendarService     service.ICalendarService
+	SuggestionService   service.ISuggestionService
+	AsynqClient         *asynq.Client
+	AsynqInspector      *asynq.Inspector
+	ScheduleDatepicker  *datepicker.Datepicker
+	SessionDatepicker   *datepicker.Datepicker
+	ExceptionDatepicker *datepicker.Datepicker
 }
 
 func LoadApp(cfg *config.Config, f *fsm.FSM) (*Handlers, *Middlewares, *Services) {
@@ -100,26 +148,47 @@ func LoadApp(cfg *config.Config, f *fsm.FSM) (*Handlers, *Middlewares, *Services
 
 	sessionDatepicker := datepicker.NewDatepicker(f)
 	scheduleDatepicker := datepicker.NewDatepicker(f)
+	exceptionDatepicker := datepicker.NewDatepicker(f)
 
 	services.SessionDatepicker = sessionDatepicker
 	services.ScheduleDatepicker = scheduleDatepicker
+	services.ExceptionDatepicker = exceptionDatepicker
 
 	currentMoviesHandler := telegram.NewCurrentMoviesHandler(services.MovieService)
 	alreadyWatchedMoviesHandler := telegram.NewAlreadyWatchedMoviesHandler(services.MovieService, telegraph)
-	votingHandler := telegram.NewVotingHandler(services.MovieService, services.VotingService, services.PollService, services.VoteService, f, services.AsynqClient)
-	suggestMovieHandler := telegram.NewSuggestMovieHandler(services.MovieService, services.KinopoiskService)
+	votingHandler := telegram.NewVotingHandler(services.MovieService, services.VotingService, services.PollService, services.VoteService, services.SessionService, f, services.AsynqClient, &cfg.Voting)
+	suggestMovieHandler := telegram.NewSuggestMovieHandler(services.MovieService, services.KinopoiskService, services.SuggestionService)
 	cancelHandler := telegram.NewCancelHandler(f)
 	cancelVotingHandler := telegram.NewCancelVotingHandler(f, services.VotingService, services.AsynqInspector)
+	closeVotingHandler := telegram.NewCloseVotingHandler(services.VotingService, services.AsynqInspector)
+	extendVotingHandler := telegram.NewExtendVotingHandler(services.VotingService, services.AsynqInspector, services.AsynqClient, &cfg.Voting)
+	reopenVotingHandler := telegram.NewReopenVotingHandler(services.VotingService, services.AsynqInspector, services.AsynqClient, &cfg.Voting, &cfg.Session)
 	registerUserHandler := telegram.NewRegisterUserHandler(services.UserService)
 	updateChatMemberHandler := telegram.NewUpdateChatMemberHandler(services.UserService)
 	pollAnswerHandler := telegram.NewPollAnswerHandler(services.PollService, services.VoteService)
-	scheduleHandler := telegram.NewScheduleHandler(services.ScheduleService, f, services.ScheduleDatepicker, services.SessionDatepicker)
-	cancelSessionHandler := telegram.NewCancelSessionHandler(services.SessionService, services.VotingService, services.AsynqInspector)
-	rescheduleSessionHandler := telegram.NewResheduleSessionHandler(f, services.SessionService, services.AsynqInspector, services.AsynqClient)
-	removeMovieFromSessionHandler := telegram.NewRemoveMovieFromSessionHandler(services.SessionService, services.AsynqInspector, f)
+	rankedBallotHandler := telegram.NewRankedBallotHandler(services.PollService, services.VoteService, services.VotingService)
+	secretBallotHandler := telegram.NewSecretBallotHandler(services.PollService, services.VoteService, services.VotingService)
+	scheduleHandler := telegram.NewScheduleHandler(services.ScheduleService, f, services.ScheduleDatepicker, services.SessionDatepicker, services.ExceptionDatepicker)
+	cancelSessionHandler := telegram.NewCancelSessionHandler(services.SessionService, services.VotingService, services.AsynqInspector, &cfg.Session)
+	rescheduleSessionHandler := telegram.NewResheduleSessionHandler(f, services.SessionService, services.MovieService, services.AsynqInspector, services.AsynqClient, &cfg.Session)
+	removeMovieFromSessionHandler := telegram.NewRemoveMovieFromSessionHandler(services.SessionService, services.MovieService, services.AsynqInspector, services.AsynqClient, f)
 	customSessionDescriptionHandler := telegram.NewCustomSessionDescriptionHandler(services.SessionService, f)
-	addMovieToSessionHandler := telegram.NewAddMovieToSessionHandler(services.MovieService, services.KinopoiskService, services.SessionService, services.PollService, services.AsynqClient, services.AsynqInspector)
+	addMovieToSessionHandler := telegram.NewAddMovieToSessionHandler(services.MovieService, services.KinopoiskService, services.SessionService, services.PollService, services.AttendanceService, services.AsynqClient, services.AsynqInspector, &cfg.Session)
 	suggestionsHandler := telegram.NewSuggestionsHandler(services.MovieService, f)
+	resultsHandler := telegram.NewResultsHandler(services.VotingService, services.VoteService, services.PollService)
+	topHandler := telegram.NewTopHandler(services.MovieService)
+	remindersHandler := telegram.NewRemindersHandler(services.UserService)
+	sessionsHandler := telegram.NewSessionsHandler(services.SessionService)
+	rsvpHandler := telegram.NewRsvpHandler(services.SessionService, services.AttendanceService)
+	historyHandler := telegram.NewHistoryHandler(services.SessionService)
+	ratingWindowHandler := telegram.NewRatingWindowHandler(services.SessionService, &cfg.Voting)
+	venueHandler := telegram.NewVenueHandler(f, services.SessionService, services.ScheduleService, services.AttendanceService)
+	restoreSessionHandler := telegram.NewRestoreSessionHandler(services.SessionService, services.VotingService, services.MovieService, services.AsynqInspector, services.AsynqClient, &cfg.Session, &cfg.Voting)
+	orderHandler := telegram.NewOrderHandler(services.SessionService, services.MovieService, services.AsynqInspector, services.AsynqClient)
+	inlineSearchHandler := telegram.NewInlineSearchHandler(services.MovieService)
+	unsuggestHandler := telegram.NewUnsuggestHandler(services.MovieService, services.UserService)
+	grantSuggestionsHandler := telegram.NewGrantSuggestionsHandler(services.SuggestionService, services.UserService)
+	calendarHandler := telegram.NewCalendarHandler(services.CalendarService, cfg.DomainAddress)
 
 	handlers := &Handlers{
 		HelpHandler:                     telegram.HelpHandler,
@@ -127,9 +196,15 @@ func LoadApp(cfg *config.Config, f *fsm.FSM) (*Handlers, *Middlewares, *Services
 		AlreadyWatchedMoviesHandler:     alreadyWatchedMoviesHandler.Handle,
 		VotingHandler:                   votingHandler.Handle,
 		PollAnswerHandler:               pollAnswerHandler.Handle,
+		RankedBallotHandler:             rankedBallotHandler.Handle,
+		SecretBallotHandler:             secretBallotHandler.Handle,
+		SecretBallotCallbackHandler:     secretBallotHandler.HandleCallback,
 		SuggestMovieHandler:             suggestMovieHandler.Handle,
 		CancelHandler:                   cancelHandler.Handle,
 		CancelVotingHandler:             cancelVotingHandler.Handle,
+		CloseVotingHandler:              closeVotingHandler.Handle,
+		ExtendVotingHandler:             extendVotingHandler.Handle,
+		ReopenVotingHandler:             reopenVotingHandler.Handle,
 		RegisterUserHandler:             registerUserHandler.Handle,
 		UpdateChatMemberHandler:         updateChatMemberHandler.Handle,
 		ScheduleHandler:                 scheduleHandler.Handle,
@@ -140,12 +215,36 @@ func LoadApp(cfg *config.Config, f *fsm.FSM) (*Handlers, *Middlewares, *Services
 		AddMovieToSessionHandler:        addMovieToSessionHandler.Handle,
 		CustomSessionDescriptionHandler: customSessionDescriptionHandler.Handle,
 		SuggestionsHandler:              suggestionsHandler.Handle,
+		ResultsHandler:                  resultsHandler.Handle,
+		TopHandler:                      topHandler.Handle,
+		RemindersHandler:                remindersHandler.Handle,
+		SessionsHandler:                 sessionsHandler.Handle,
+		RsvpHandler:                     rsvpHandler.Handle,
+		RsvpCallbackHandler:             rsvpHandler.HandleCallback,
+		HistoryHandler:                  historyHandler.Handle,
+		HistoryCallbackHandler:          historyHandler.HandleCallback,
+		OrderHandler:                    orderHandler.Handle,
+		OrderCallbackHandler:            orderHandler.HandleCallback,
+		RatingWindowHandler:             ratingWindowHandler.Handle,
+		RestoreSessionHandler:           restoreSessionHandler.Handle,
+		VenueHandler:                    venueHandler.Handle,
+		CalendarHandler:                 calendarHandler.Handle,
+		SkipHandler:                     scheduleHandler.HandleSkip,
+		GrantSuggestionsHandler:         grantSuggestionsHandler.Handle,
+		UnsuggestHandler:                unsuggestHandler.Handle,
+		InlineSearchHandler:             inlineSearchHandler.Handle,
 	}
 
 	f.AddCallbacks(map[fsm.StateID]fsm.Callback{
 		statePrepareVotingType:       votingHandler.PrepareVotingType,
+		statePrepareVotingMethod:     votingHandler.PrepareVotingMethod,
 		statePrepareVotingTitle:      votingHandler.PrepareVotingTitle,
 		statePrepareVotingDuration:   votingHandler.PrepareVotingDuration,
+		statePrepareVotingQuorum:     votingHandler.PrepareVotingQuorum,
+		statePrepareQuorumPolicy:     votingHandler.PrepareQuorumPolicy,
+		statePrepareHideTally:        votingHandler.PrepareHideTally,
+		statePrepareBallotMode:       votingHandler.PrepareBallotMode,
+		statePrepareVotingSession:    votingHandler.PrepareVotingSession,
 		statePrepareMovies:           votingHandler.PrepareMovies,
 		stateStartVoting:             votingHandler.StartVoting,
 		stateCancel:                  cancelVotingHandler.Cancel,
@@ -153,13 +252,21 @@ func LoadApp(cfg *config.Config, f *fsm.FSM) (*Handlers, *Middlewares, *Services
 		stateDate:                    scheduleHandler.PrepareDate,
 		stateTime:                    scheduleHandler.PrepareTime,
 		stateLocation:                scheduleHandler.PrepareLocation,
+		stateScheduleWeekdays:        scheduleHandler.PrepareWeekdays,
+		stateScheduleInterval:        scheduleHandler.PrepareInterval,
 		stateSaveSchedule:            scheduleHandler.SaveSchedule,
+		stateSkipDate:                scheduleHandler.PrepareSkipDate,
+		stateToggleException:         scheduleHandler.ToggleException,
 		stateRescheduleSession:       rescheduleSessionHandler.RescheduleSession,
 		statePrepareMoviesToDelete:   removeMovieFromSessionHandler.PrepareMoviesToDelete,
 		stateRemove:                  removeMovieFromSessionHandler.Remove,
 		stateDescription:             customSessionDescriptionHandler.HandleDescriptionInput,
 		stateSaveDescription:         customSessionDescriptionHandler.SaveDescription,
 		statePrepareMovieSuggestions: suggestionsHandler.PrepareMovies,
+		stateVenueAddress:            venueHandler.PrepareAddress,
+		stateVenueRoom:               venueHandler.PrepareRoom,
+		stateVenueNotes:              venueHandler.PrepareNotes,
+		stateSaveVenue:               venueHandler.SaveVenue,
 	})
 
 	middlewares := &Middlewares{
@@ -194,56 +301,81 @@ func LoadServices(cfg *config.Config) *Services {
 	voteRepo := repository.NewVoteRepository(db)
 	roleRepo := repository.NewRoleRepository(db)
 	userRepo := repository.NewUserRepository(db)
+	ratingSummaryRepo := repository.NewRatingSummaryRepository(db)
+	bracketRepo := repository.NewBracketRepository(db)
+	attendanceRepo := repository.NewAttendanceRepository(db)
+	suggestionGrantRepo := repository.NewSuggestionGrantRepository(db)
 
-	movieService := service.NewMovieService(movieRepo, sessionRepo)
+	movieService := service.NewMovieService(movieRepo, sessionRepo, cfg.Session.Break)
 
 	pollService := service.NewPollService(pollRepo)
 
 	scheduleService := service.NewScheduleService(scheduleRepo)
 
-	sessionService := service.NewSessionService(sessionRepo, movieRepo, votingRepo, scheduleService)
+	sessionService := service.NewSessionService(sessionRepo, movieRepo, votingRepo, ratingSummaryRepo, scheduleService)
 
-	votingService := service.NewVotingService(votingRepo, scheduleService, sessionRepo, movieRepo, pollRepo)
+	votingService := service.NewVotingService(votingRepo, scheduleService, sessionRepo, movieRepo, pollRepo, voteRepo, userRepo, ratingSummaryRepo, bracketRepo)
 
 	voteService := service.NewVoteService(voteRepo)
 
 	userService := service.NewUserService(userRepo, roleRepo)
 
+	attendanceService := service.NewAttendanceService(attendanceRepo, sessionRepo)
+
+	suggestionService := service.NewSuggestionService(movieRepo, suggestionGrantRepo, &cfg.Suggestion)
+
+	calendarService := service.NewCalendarService(sessionRepo, cfg.Calendar.Secret, cfg.Session.Break)
+
 	kinopoiskClient := &http.Client{}
 	kinopoiskAPI := kinopoisk.NewKinopoiskAPI(&cfg.Kinopoisk, kinopoiskClient)
 	kinopoiskService := service.NewKinopoiskService(kinopoiskAPI)
 
 	services := &Services{
-		UserService:      userService,
-		MovieService:     movieService,
-		KinopoiskService: kinopoiskService,
-		VotingService:    votingService,
-		PollService:      pollService,
-		VoteService:      voteService,
-		ScheduleService:  scheduleService,
-		SessionService:   sessionService,
-		AsynqClient:      client,
-		AsynqInspector:   inspector,
+		UserService:       userService,
+		MovieService:      movieService,
+		KinopoiskService:  kinopoiskService,
+		VotingService:     votingService,
+		PollService:       pollService,
+		VoteService:       voteService,
+		ScheduleService:   scheduleService,
+		SessionService:    sessionService,
+		AttendanceService: attendanceService,
+		CalendarService:   calendarService,
+		SuggestionService: suggestionService,
+		AsynqClient:       client,
+		AsynqInspector:    inspector,
 	}
 
 	return services
 }
 
-func RegisterTaskProcessors(services *Services, b *bot.Bot, mux *asynq.ServeMux) {
-	closeRatingVotingProcessor := tasks.NewCloseRatingVotingTaskProcessor(b, services.VotingService, services.VoteService, services.MovieService)
-	closeSelectionVotingProcessor := tasks.NewCloseSelectionVotingTaskProcessor(b, services.VotingService, services.VoteService, services.MovieService, services.AsynqInspector, services.AsynqClient)
-	openRatingVotingProcessor := tasks.NewOpenRatingVotingTaskProcessor(b, services.VotingService, services.MovieService, services.AsynqClient)
+func RegisterTaskProcessors(cfg *config.Config, services *Services, b *bot.Bot, mux *asynq.ServeMux) {
+	closeRatingVotingProcessor := tasks.NewCloseRatingVotingTaskProcessor(b, services.VotingService, services.VoteService, services.MovieService, services.AsynqClient, &cfg.Voting)
+	closeSelectionVotingProcessor := tasks.NewCloseSelectionVotingTaskProcessor(b, services.VotingService, services.VoteService, services.MovieService, services.PollService, services.AsynqInspector, services.AsynqClient, &cfg.Voting, &cfg.Session)
+	openRatingVotingProcessor := tasks.NewOpenRatingVotingTaskProcessor(b, services.VotingService, services.MovieService, services.SessionService, services.AsynqClient, &cfg.Voting)
 	finishSessionProcessor := tasks.NewFinishSessionTaskProcessor(services.SessionService)
+	remindSessionProcessor := tasks.NewRemindSessionTaskProcessor(b, services.SessionService, services.MovieService)
+	remindVotingProcessor := tasks.NewRemindVotingTaskProcessor(b, services.VotingService, services.UserService)
 	mux.HandleFunc(tasks.CloseRatingVotingTaskType, closeRatingVotingProcessor.Process)
 	mux.HandleFunc(tasks.CloseSelectionVotingTaskType, closeSelectionVotingProcessor.Process)
 	mux.HandleFunc(tasks.OpenRatingVotingTaskType, openRatingVotingProcessor.Process)
 	mux.HandleFunc(tasks.FinishSessionTaskType, finishSessionProcessor.Process)
+	mux.HandleFunc(tasks.RemindSessionTaskType, remindSessionProcessor.Process)
+	mux.HandleFunc(tasks.RemindVotingTaskType, remindVotingProcessor.Process)
 }
 
 func RegisterHandlers(b *bot.Bot, handlers *Handlers, services *Services, cfg *config.Config) {
 	b.RegisterHandlerMatchFunc(PollAnswerMatchFunc(), handlers.PollAnswerHandler)
+	b.RegisterHandlerMatchFunc(InlineQueryMatchFunc(), handlers.InlineSearchHandler)
+	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, service.RANKED_BALLOT_PREFIX, bot.MatchTypePrefix, handlers.RankedBallotHandler)
+	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, service.SECRET_BALLOT_PREFIX, bot.MatchTypePrefix, handlers.SecretBallotCallbackHandler)
+	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, service.RSVP_PREFIX, bot.MatchTypePrefix, handlers.RsvpCallbackHandler)
+	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, telegram.HISTORY_PREFIX, bot.MatchTypePrefix, handlers.HistoryCallbackHandler)
+	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, telegram.ORDER_PREFIX, bot.MatchTypePrefix, handlers.OrderCallbackHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService))
+	// Deep links from secret ballot announcements, must be registered before /start
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/start "+service.SECRET_BALLOT_START_PREFIX, bot.MatchTypePrefix, handlers.SecretBallotHandler)
 	b.RegisterHandlerMatchFunc(telegram.UpdateChatMemberMatchFunc(cfg.Telegram.GroupID), handlers.UpdateChatMemberHandler)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "#предлагаю", bot.MatchTypePrefix, handlers.SuggestMovieHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, telegram.SUGGEST_HASHTAG, bot.MatchTypePrefix, handlers.SuggestMovieHandler)
 	b.RegisterHandler(bot.HandlerTypeMessageText, "#расписание", bot.MatchTypeExact, handlers.ScheduleHandler, middleware.Delete)
 	b.RegisterHandler(bot.HandlerTypeMessageText, "#перенос", bot.MatchTypeExact, handlers.RescheduleSessionHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
 	b.RegisterHandler(bot.HandlerTypeMessageText, "#предложка", bot.MatchTypeExact, handlers.SuggestionsHandler, middleware.Delete)
@@ -253,6 +385,9 @@ func RegisterHandlers(b *bot.Bot, handlers *Handlers, services *Services, cfg *c
 	registerCommandHandler(b, "voting", handlers.VotingHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
 	registerCommandHandler(b, "cancel", handlers.CancelHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
 	registerCommandHandler(b, "cancel_voting", handlers.CancelVotingHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
+	registerCommandHandler(b, "close_voting", handlers.CloseVotingHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
+	registerCommandHandler(b, "extend_voting", handlers.ExtendVotingHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
+	registerCommandHandler(b, "reopen_voting", handlers.ReopenVotingHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
 	registerCommandHandler(b, "cancel_session", handlers.CancelSessionHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
 	registerCommandHandler(b, "register", handlers.RegisterUserHandler, middleware.Delete)
 	registerCommandHandler(b, "schedule", handlers.RescheduleHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
@@ -260,6 +395,20 @@ func RegisterHandlers(b *bot.Bot, handlers *Handlers, services *Services, cfg *c
 	registerCommandHandler(b, "start", handlers.RegisterUserHandler, middleware.Delete)
 	registerCommandHandler(b, "rm", handlers.RemoveMovieFromSessionHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
 	registerCommandHandler(b, "custom", handlers.CustomSessionDescriptionHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
+	registerCommandHandler(b, "results", handlers.ResultsHandler, middleware.Delete)
+	registerCommandHandler(b, "top", handlers.TopHandler, middleware.Delete)
+	registerCommandHandler(b, "reminders", handlers.RemindersHandler, middleware.Delete)
+	registerCommandHandler(b, "sessions", handlers.SessionsHandler, middleware.Delete)
+	registerCommandHandler(b, "rsvp", handlers.RsvpHandler, middleware.Delete)
+	registerCommandHandler(b, "history", handlers.HistoryHandler, middleware.Delete)
+	registerCommandHandler(b, "order", handlers.OrderHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
+	registerCommandHandler(b, "restore_session", handlers.RestoreSessionHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
+	registerCommandHandler(b, "venue", handlers.VenueHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
+	registerCommandHandler(b, "rating_window", handlers.RatingWindowHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
+	registerCommandHandler(b, "calendar", handlers.CalendarHandler, middleware.Delete)
+	registerCommandHandler(b, "skip", handlers.SkipHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
+	registerCommandHandler(b, "unsuggest", handlers.UnsuggestHandler, middleware.Delete)
+	registerCommandHandler(b, "grant_suggestions", handlers.GrantSuggestionsHandler, middleware.AdminOnly(cfg.Telegram.GroupID, services.UserService), middleware.Delete)
 }
 
 func registerCommandHandler(bot *bot.Bot, command string, handler bot.HandlerFunc, middlewares ...bot.Middleware) {
@@ -0,0 +1,8 @@
+package config
+
+type CalendarConfig struct {
+	// Key of the feed links, empty disables the calendar
+	Secret string `env:"CALENDAR_SECRET"`
+	// Address of the standalone feed server in long polling mode, the webhook server serves the feed otherwise
+	ListenAddress string `env:"CALENDAR_LISTEN_ADDRESS" env-default:":2000"`
+}
@@ -13,6 +13,10 @@ type Config struct {
 	App           AppConfig
 	Kinopoisk     KinopoiskConfig
 	Redis         RedisConfig
+	Voting        VotingConfig
+	Session       SessionConfig
+	Calendar      CalendarConfig
+	Suggestion    SuggestionConfig
 }
 
 func LoadConfig() (*Config, error) {
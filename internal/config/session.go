@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+type SessionConfig struct {
+	// How long before a session the club is reminded about it, empty disables reminders
+	ReminderOffsets []time.Duration `env:"SESSION_REMINDER_OFFSETS" env-default:"24h,1h"`
+	// Break between the movies of a session, used to compute the start of every movie
+	Break time.Duration `env:"SESSION_BREAK" env-default:"15m"`
+	// How long after the cancel a session can still be restored with /restore_session
+	RestoreWindow time.Duration `env:"SESSION_RESTORE_WINDOW" env-default:"24h"`
+}
@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+type SuggestionConfig struct {
+	// Suggestions of a member waiting in #предложка, 0 disables the limit
+	MaxOpen int `env:"SUGGESTION_MAX_OPEN" env-default:"10"`
+	// New suggestions of a member in the last 7 days, 0 disables the limit
+	MaxPerWeek int `env:"SUGGESTION_MAX_PER_WEEK" env-default:"5"`
+	// How long the extra slots given by /grant_suggestions last
+	GrantDuration time.Duration `env:"SUGGESTION_GRANT_DURATION" env-default:"168h"`
+}
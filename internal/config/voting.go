@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+type VotingConfig struct {
+	RunoffDuration time.Duration `env:"VOTING_RUNOFF_DURATION" env-default:"2h"`
+	MaxRunoffs     int           `env:"VOTING_MAX_RUNOFFS" env-default:"1"`
+	// Default quorum for automatically opened rating votings, 0 disables it
+	QuorumType      string        `env:"VOTING_QUORUM_TYPE" env-default:"PERCENT"`
+	QuorumValue     int           `env:"VOTING_QUORUM_VALUE" env-default:"0"`
+	QuorumPolicy    string        `env:"VOTING_QUORUM_POLICY" env-default:"EXTEND"`
+	QuorumExtension time.Duration `env:"VOTING_QUORUM_EXTENSION" env-default:"24h"`
+	// Hide /results of automatically opened rating votings until they are closed
+	HideTally bool `env:"VOTING_HIDE_TALLY" env-default:"false"`
+	// Collect ballots of automatically opened rating votings in a private chat with the bot
+	SecretBallot bool `env:"VOTING_SECRET_BALLOT" env-default:"false"`
+	// How long after the close a voting can still be reopened with /reopen_voting
+	ReopenWindow time.Duration `env:"VOTING_REOPEN_WINDOW" env-default:"24h"`
+	// Number of virtual votes with the club average added to every movie rating
+	RatingPriorWeight float64 `env:"VOTING_RATING_PRIOR_WEIGHT" env-default:"5"`
+	// How long before the close members who haven't voted are reminded, 0 disables reminders
+	ReminderOffset time.Duration `env:"VOTING_REMINDER_OFFSET" env-default:"1h"`
+	// How long the rating voting of a movie stays open, sessions can override it with /rating_window
+	RatingDuration time.Duration `env:"VOTING_RATING_DURATION" env-default:"2h"`
+}
@@ -37,6 +37,10 @@ func NewPostgresDB(cfg config.DatabaseConfig) (*gorm.DB, error) {
 		panic("Failed to connect database")
 	}
 
+	// Movies of a session are ordered by the position in the join table
+	db.SetupJoinTable(&model.Session{}, "Movies", &model.MovieSession{})
+	db.SetupJoinTable(&model.Movie{}, "Sessions", &model.MovieSession{})
+
 	// Migrate the schema
 	db.AutoMigrate(&model.Role{})
 	db.AutoMigrate(&model.User{})
@@ -47,6 +51,12 @@ func NewPostgresDB(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	db.AutoMigrate(&model.Poll{})
 	db.AutoMigrate(&model.PollOption{})
 	db.AutoMigrate(&model.Schedule{})
+	db.AutoMigrate(&model.ScheduleException{})
+	db.AutoMigrate(&model.RatingSummary{})
+	db.AutoMigrate(&model.Bracket{})
+	db.AutoMigrate(&model.BracketEntry{})
+	db.AutoMigrate(&model.Attendance{})
+	db.AutoMigrate(&model.SuggestionGrant{})
 
 	// Seed data
 	seedRoles(db)
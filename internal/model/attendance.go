@@ -0,0 +1,20 @@
+package model
+
+import "gorm.io/gorm"
+
+const (
+	ATTENDANCE_YES_STATUS   = "YES"
+	ATTENDANCE_NO_STATUS    = "NO"
+	ATTENDANCE_MAYBE_STATUS = "MAYBE"
+)
+
+// Attendance is the RSVP answer of the user to the session, it is kept after the session is finished.
+type Attendance struct {
+	gorm.Model
+	ID        int64   `gorm:"primaryKey"`
+	SessionID int64   `gorm:"uniqueIndex:idx_attendance_session_user"`
+	Session   Session `gorm:"foreignKey:SessionID"`
+	UserID    int64   `gorm:"uniqueIndex:idx_attendance_session_user"`
+	User      User    `gorm:"foreignKey:UserID"`
+	Status    string  `gorm:"not null"` // yes, no, maybe
+}
@@ -0,0 +1,34 @@
+package model
+
+import "gorm.io/gorm"
+
+const (
+	BRACKET_GROUPS_STAGE = "GROUPS"
+	BRACKET_FINAL_STAGE  = "FINAL"
+)
+
+// Bracket splits a selection voting with too many candidates for one poll
+// into group polls, the top movies of every group meet in the final poll.
+type Bracket struct {
+	gorm.Model
+	ID           int64          `gorm:"primaryKey"`
+	Title        string         `gorm:"not null"`
+	Stage        string         `gorm:"default:'GROUPS'"`
+	AdvanceCount int            `gorm:"not null"` // movies advancing from every group
+	CreatedBy    int64          `gorm:"not null"`
+	Votings      []Voting       `gorm:"foreignKey:BracketID"`
+	Entries      []BracketEntry `gorm:"foreignKey:BracketID"`
+}
+
+// BracketEntry is the result of a movie in a group poll.
+type BracketEntry struct {
+	gorm.Model
+	ID        int64 `gorm:"primaryKey"`
+	BracketID int64 `gorm:"index"`
+	VotingID  int64
+	MovieID   int64
+	Movie     Movie `gorm:"foreignKey:MovieID"`
+	Group     int   `gorm:"column:group_number"`
+	Votes     int64
+	Advanced  bool `gorm:"default:false"`
+}
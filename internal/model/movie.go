@@ -5,26 +5,47 @@ import "gorm.io/gorm"
 const (
 	MOVIE_SUGGESTED_STATUS = "SUGGESTED"
 	MOVIE_WATCHED_STATUS   = "WATCHED"
+	// Suggestions withdrawn with /unsuggest, a new suggestion brings the movie back
+	MOVIE_ARCHIVED_STATUS = "ARCHIVED"
 )
 
 type Movie struct {
 	gorm.Model
-	ID          int64
-	Title       string
-	Description string
-	Directors   string
-	Year        int
-	Countries   string
-	Genres      string
-	Link        string
-	Duration    int
-	IMDBRating  float64
-	Rating      float64
-	Status      string  `gorm:"default:'SUGGESTED'"`
-	WatchCount  int     `gorm:"default:0"`
-	FinishedAt  *string `gorm:"default:null"`
-	SuggestedAt *int64
-	SuggestedBy *int64    `gorm:"default:null"`
-	Suggester   *User     `gorm:"foreignKey:SuggestedBy"`
-	Sessions    []Session `gorm:"many2many:movies_sessions;"`
+	ID               int64
+	Title            string
+	Description      string
+	Directors        string
+	Year             int
+	Countries        string
+	Genres           string
+	Link             string
+	Duration         int
+	IMDBRating       float64
+	PosterURL        string
+	PosterPreviewURL string
+	CoverURL         string
+	Rating           float64 // mean of all club ratings
+	RatingCount      int     `gorm:"default:0"`
+	AdjustedRating   float64 // Bayesian average, used for ranking
+	Status           string  `gorm:"default:'SUGGESTED'"`
+	WatchCount       int     `gorm:"default:0"`
+	FinishedAt       *string `gorm:"default:null"`
+	SuggestedAt      *int64
+	SuggestedBy      *int64          `gorm:"default:null"`
+	Suggester        *User           `gorm:"foreignKey:SuggestedBy"`
+	Sessions         []Session       `gorm:"many2many:movies_sessions;"`
+	RatingSummaries  []RatingSummary `gorm:"foreignKey:MovieID"`
+}
+
+// IsRated reports whether the club rated the movie, movies rated before the rating summaries have only Rating.
+func (m *Movie) IsRated() bool {
+	return m.RatingCount > 0 || m.Rating != 0
+}
+
+// RankingScore is the adjusted rating, movies rated before the rating summaries are ranked by their Rating.
+func (m *Movie) RankingScore() float64 {
+	if m.RatingCount > 0 {
+		return m.AdjustedRating
+	}
+	return m.Rating
 }
@@ -0,0 +1,12 @@
+package model
+
+// MovieSession is the join table of sessions and movies, Position is the running order of the movie in the session.
+type MovieSession struct {
+	SessionID int64 `gorm:"primaryKey"`
+	MovieID   int64 `gorm:"primaryKey"`
+	Position  int   `gorm:"default:0"`
+}
+
+func (MovieSession) TableName() string {
+	return "movies_sessions"
+}
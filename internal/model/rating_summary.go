@@ -0,0 +1,22 @@
+package model
+
+import "gorm.io/gorm"
+
+const MAX_RATING = 10
+
+// RatingSummary keeps the statistics of a finished rating voting,
+// so a second voting for the same movie doesn't overwrite the first one.
+type RatingSummary struct {
+	gorm.Model
+	ID            int64  `gorm:"primaryKey"`
+	VotingID      int64  `gorm:"uniqueIndex"`
+	Voting        Voting `gorm:"foreignKey:VotingID"`
+	MovieID       int64  `gorm:"index"`
+	Movie         Movie  `gorm:"foreignKey:MovieID"`
+	Count         int
+	Mean          float64
+	Median        float64
+	StdDev        float64
+	Histogram     []int `gorm:"serializer:json"` // Histogram[i] is the number of (i+1) ratings
+	AdjustedScore float64
+}
@@ -8,8 +8,19 @@ type Schedule struct {
 	gorm.Model
 	ID       int64  `gorm:"primaryKey"`
 	Weekday  int    `gorm:"not null"` // 1=Monday, ..., 6=Saturday, 7=Sunday
+	Weekdays string // Comma-separated weekdays of the recurrence, empty means Weekday only
+	Interval int    `gorm:"default:1"` // Meet every Interval weeks
+	StartsAt int64  // First occurrence, the weeks of Interval count from it
 	Hour     int    `gorm:"not null"` // 0-23
 	Minute   int    `gorm:"not null"` // 0-59
 	IsActive bool   `gorm:"default:true"`
-	Location string `gorm:"default:'Europe/Moscow'"`
+	Location string `gorm:"default:'Europe/Moscow'"`        // Timezone name
+	Venue    Venue  `gorm:"embedded;embeddedPrefix:venue_"` // Default venue of new sessions
+}
+
+// ScheduleException is a date without a session, it outlives replacements of the schedule.
+type ScheduleException struct {
+	ID        int64  `gorm:"primaryKey"`
+	Date      string `gorm:"uniqueIndex;not null"` // 2006-01-02 in the timezone of the schedule
+	CreatedAt int64  `gorm:"autoCreateTime"`
 }
@@ -3,19 +3,30 @@ package model
 import "gorm.io/gorm"
 
 const (
+	SESSION_PLANNED_STATUS   = "PLANNED"
 	SESSION_ONGOING_STATUS   = "ONGOING"
 	SESSION_FINISHED_STATUS  = "FINISHED"
 	SESSION_CANCELLED_STATUS = "CANCELLED"
 )
 
+// SESSION_UPCOMING_STATUSES are the statuses of sessions that haven't taken place yet.
+// Sessions created before planning was introduced are ONGOING.
+var SESSION_UPCOMING_STATUSES = []string{SESSION_PLANNED_STATUS, SESSION_ONGOING_STATUS}
+
 type Session struct {
 	gorm.Model
-	ID          int64 `gorm:"primaryKey"`
-	FinishedAt  int64
-	Status      string   `gorm:"default:'planned'"` // ongoing, finished, cancelled
-	Description string   // Custom description for the session
-	CreatedBy   int64    `gorm:"not null"`
-	Creator     User     `gorm:"foreignKey:CreatedBy"`
-	Movies      []Movie  `gorm:"many2many:movies_sessions;"`
-	Votings     []Voting `gorm:"foreignKey:SessionID"`
+	ID            int64 `gorm:"primaryKey"`
+	FinishedAt    int64
+	Status        string       `gorm:"default:'PLANNED'"` // planned, ongoing, finished, cancelled
+	Description   string       // Custom description for the session
+	RatingWindow  int64        // Minutes the rating votings of the movies stay open, 0 means the default duration
+	CancelledAt   *int64       // When the session was cancelled, /restore_session accepts only recent ones
+	CancelledFrom string       // Status the session had before it was cancelled
+	Venue         Venue        `gorm:"embedded;embeddedPrefix:venue_"` // Copied from the schedule when the session is created
+	RsvpMessageID int          // Pinned RSVP message announcing the session, 0 until it is posted
+	CreatedBy     int64        `gorm:"not null"`
+	Creator       User         `gorm:"foreignKey:CreatedBy"`
+	Movies        []Movie      `gorm:"many2many:movies_sessions;"`
+	Votings       []Voting     `gorm:"foreignKey:SessionID"`
+	Attendances   []Attendance `gorm:"foreignKey:SessionID"`
 }
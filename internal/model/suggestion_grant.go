@@ -0,0 +1,14 @@
+package model
+
+import "gorm.io/gorm"
+
+// SuggestionGrant gives the member extra suggestions over both quotas until it expires.
+type SuggestionGrant struct {
+	gorm.Model
+	ID        int64 `gorm:"primaryKey"`
+	UserID    int64 `gorm:"index"`
+	User      User  `gorm:"foreignKey:UserID"`
+	Slots     int   `gorm:"not null"`
+	ExpiresAt int64 `gorm:"index"`
+	GrantedBy int64
+}
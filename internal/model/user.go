@@ -10,4 +10,6 @@ type User struct {
 	LastName  string
 	Role      Role `gorm:"foreignKey:RoleID"`
 	RoleID    int64
+	// Remind about votings in a private chat instead of a mention in the group
+	VotingReminders bool `gorm:"default:false"`
 }
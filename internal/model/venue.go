@@ -0,0 +1,8 @@
+package model
+
+// Venue tells where or how the club watches, it is embedded into schedules and sessions.
+type Venue struct {
+	Address string // Address of an in-person meeting
+	RoomURL string // Link to the online watch party
+	Notes   string
+}
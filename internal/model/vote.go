@@ -4,12 +4,16 @@ import "gorm.io/gorm"
 
 type Vote struct {
 	gorm.Model
-	ID       int64 `gorm:"primaryKey"`
-	VotingID int64
+	ID int64 `gorm:"primaryKey"`
+	// A ranked ballot holds every movie and every rank once, two fast taps can't both add a preference
+	VotingID int64  `gorm:"uniqueIndex:idx_votes_ballot_movie,where:rank IS NOT NULL AND deleted_at IS NULL;uniqueIndex:idx_votes_ballot_rank,where:rank IS NOT NULL AND deleted_at IS NULL"`
 	Voting   Voting `gorm:"foreignKey:VotingID"`
-	UserID   int64
-	User     User `gorm:"foreignKey:UserID"`
-	MovieID  *int64
+	UserID   int64  `gorm:"uniqueIndex:idx_votes_ballot_movie,where:rank IS NOT NULL AND deleted_at IS NULL;uniqueIndex:idx_votes_ballot_rank,where:rank IS NOT NULL AND deleted_at IS NULL"`
+	User     User   `gorm:"foreignKey:UserID"`
+	MovieID  *int64 `gorm:"uniqueIndex:idx_votes_ballot_movie,where:rank IS NOT NULL AND deleted_at IS NULL"`
 	Movie    *Movie `gorm:"foreignKey:MovieID"`
 	Rating   *int   `gorm:"check:rating >= 1 AND rating <= 10"`
+	// 1-based preference for ranked ballots
+	Rank      *int `gorm:"default:null;uniqueIndex:idx_votes_ballot_rank,where:rank IS NOT NULL AND deleted_at IS NULL"`
+	Abstained bool `gorm:"default:false"` // "didn't watch" answer in rating polls
 }
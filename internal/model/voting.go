@@ -8,6 +8,20 @@ const (
 	VOTING_CANCELLED_STATUS = "CANCELLED"
 	VOTING_RATING_TYPE      = "RATING"
 	VOTING_SELECTION_TYPE   = "SELECTION"
+	VOTING_METHOD_PLURALITY = "PLURALITY"
+	VOTING_METHOD_RANKED    = "RANKED"
+)
+
+const (
+	QUORUM_ABSOLUTE_TYPE          = "ABSOLUTE"
+	QUORUM_PERCENT_TYPE           = "PERCENT"
+	QUORUM_EXTEND_POLICY          = "EXTEND"
+	QUORUM_CANCEL_POLICY          = "CANCEL"
+	QUORUM_ACCEPT_POLICY          = "ACCEPT"
+	QUORUM_MET_OUTCOME            = "MET"
+	QUORUM_EXTENDED_OUTCOME       = "EXTENDED"
+	QUORUM_CANCELLED_OUTCOME      = "CANCELLED"
+	QUORUM_LOW_CONFIDENCE_OUTCOME = "LOW_CONFIDENCE"
 )
 
 type Voting struct {
@@ -16,6 +30,7 @@ type Voting struct {
 	Title      string `gorm:"not null"`
 	Type       string `gorm:"not null;check:type IN ('SELECTION', 'RATING')"` // selection or rating
 	Status     string `gorm:"default:'ACTIVE'"`
+	Method     string `gorm:"default:'PLURALITY'"` // plurality poll or ranked (instant-runoff) ballot
 	FinishedAt *int64
 	MovieID    *int64
 	Movie      *Movie `gorm:"foreignKey:MovieID"`
@@ -24,4 +39,24 @@ type Voting struct {
 	CreatedBy  int64    `gorm:"not null"`
 	Creator    User     `gorm:"foreignKey:CreatedBy"`
 	Votes      []Vote   `gorm:"foreignKey:VotingID"`
+	// Runoff votings are linked to the tied voting they were started for
+	ParentID    *int64
+	Parent      *Voting `gorm:"foreignKey:ParentID"`
+	RunoffRound int     `gorm:"default:0"`
+	// Quorum is either an absolute number of voters or a percent of registered users
+	QuorumType    string
+	QuorumValue   int    `gorm:"default:0"`
+	QuorumPolicy  string `gorm:"default:'ACCEPT'"` // what to do when quorum is not met: extend, cancel or accept
+	QuorumOutcome *string
+	Extended      bool   `gorm:"default:false"`
+	LowConfidence bool   `gorm:"default:false"`
+	HideTally     bool   `gorm:"default:false"` // hide /results until the voting is closed
+	Secret        bool   `gorm:"default:false"` // ballots are cast in a private chat with the bot
+	WinnerMovieID *int64 // movie added to the session by a finished selection voting
+	// Close time a voting cancelled together with its session had, /restore_session reopens it for the rest
+	PlannedFinishedAt *int64
+	// Group polls of a bracket have a 1-based group number, the final has 0
+	BracketID    *int64
+	Bracket      *Bracket `gorm:"foreignKey:BracketID"`
+	BracketGroup int      `gorm:"default:0"`
 }
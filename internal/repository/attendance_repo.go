@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UpsertAttendanceParams struct {
+	SessionID int64
+	UserID    int64
+	Status    string
+	Tx        *gorm.DB
+}
+
+type FindAttendancesParams struct {
+	SessionID int64
+	Tx        *gorm.DB
+}
+
+type IAttendanceRepo interface {
+	Upsert(params *UpsertAttendanceParams) error
+	FindBySessionID(params *FindAttendancesParams) ([]*model.Attendance, error)
+}
+
+type AttendanceRepo struct {
+	db *gorm.DB
+}
+
+func NewAttendanceRepository(db *gorm.DB) IAttendanceRepo {
+	return &AttendanceRepo{db: db}
+}
+
+// Upsert saves the answer of the user, a repeated answer replaces the previous one.
+func (r *AttendanceRepo) Upsert(params *UpsertAttendanceParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	attendance := &model.Attendance{SessionID: params.SessionID, UserID: params.UserID, Status: params.Status}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "updated_at"}),
+	}).Create(attendance).Error
+}
+
+func (r *AttendanceRepo) FindBySessionID(params *FindAttendancesParams) ([]*model.Attendance, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	var attendances []*model.Attendance
+	err := tx.Preload("User").Where("session_id = ?", params.SessionID).Order("updated_at").Find(&attendances).Error
+	return attendances, err
+}
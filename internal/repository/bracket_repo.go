@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CreateBracketParams struct {
+	Bracket *model.Bracket
+	Tx      *gorm.DB
+}
+
+type LockBracketParams struct {
+	BracketID int64
+	Tx        *gorm.DB
+}
+
+type UpdateBracketStageParams struct {
+	BracketID int64
+	Stage     string
+	Tx        *gorm.DB
+}
+
+type CreateBracketEntriesParams struct {
+	Entries []*model.BracketEntry
+	Tx      *gorm.DB
+}
+
+type FindBracketEntriesParams struct {
+	BracketID    int64
+	AdvancedOnly bool
+	Tx           *gorm.DB
+}
+
+type CountActiveBracketGroupsParams struct {
+	BracketID int64
+	Tx        *gorm.DB
+}
+
+type IBracketRepo interface {
+	Create(params *CreateBracketParams) (*model.Bracket, error)
+	Lock(params *LockBracketParams) (*model.Bracket, error)
+	UpdateStage(params *UpdateBracketStageParams) error
+	CreateEntries(params *CreateBracketEntriesParams) error
+	FindEntries(params *FindBracketEntriesParams) ([]*model.BracketEntry, error)
+	CountActiveGroups(params *CountActiveBracketGroupsParams) (int64, error)
+}
+
+type BracketRepo struct {
+	db *gorm.DB
+}
+
+func NewBracketRepository(db *gorm.DB) IBracketRepo {
+	return &BracketRepo{db: db}
+}
+
+func (r *BracketRepo) Create(params *CreateBracketParams) (*model.Bracket, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	if err := tx.Create(params.Bracket).Error; err != nil {
+		return nil, err
+	}
+	return params.Bracket, nil
+}
+
+// Lock selects the bracket for update, so the group polls closing at the same time
+// decide one by one whether the final has to be started.
+func (r *BracketRepo) Lock(params *LockBracketParams) (*model.Bracket, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	var bracket model.Bracket
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bracket, params.BracketID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bracket, nil
+}
+
+func (r *BracketRepo) UpdateStage(params *UpdateBracketStageParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	return tx.Model(&model.Bracket{}).Where("id = ?", params.BracketID).Update("stage", params.Stage).Error
+}
+
+func (r *BracketRepo) CreateEntries(params *CreateBracketEntriesParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	if len(params.Entries) == 0 {
+		return nil
+	}
+	return tx.Create(params.Entries).Error
+}
+
+func (r *BracketRepo) FindEntries(params *FindBracketEntriesParams) ([]*model.BracketEntry, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	var entries []*model.BracketEntry
+	query := tx.Preload("Movie").Where("bracket_id = ?", params.BracketID)
+	if params.AdvancedOnly {
+		query = query.Where("advanced = ?", true)
+	}
+	err := query.Order("group_number, votes DESC, id").Find(&entries).Error
+	return entries, err
+}
+
+func (r *BracketRepo) CountActiveGroups(params *CountActiveBracketGroupsParams) (int64, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	var count int64
+	err := tx.Model(&model.Voting{}).
+		Where("bracket_id = ? AND bracket_group > 0 AND status = ?", params.BracketID, model.VOTING_ACTIVE_STATUS).
+		Count(&count).Error
+	return count, err
+}
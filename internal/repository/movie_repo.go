@@ -1,15 +1,27 @@
 package repository
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// RANKING_SCORE is model.Movie.RankingScore in SQL: legacy ratings have no summaries and no adjusted rating.
+const RANKING_SCORE = "CASE WHEN rating_count > 0 THEN adjusted_rating ELSE rating END"
+
+type FindLegacyRatingsParams struct {
+	Tx *gorm.DB
+}
+
 type UpdateRatingParams struct {
-	MovieID int64
-	Rating  float64
-	Tx      *gorm.DB
+	MovieID        int64
+	Rating         float64
+	RatingCount    int
+	AdjustedRating float64
+	Tx             *gorm.DB
 }
 
 type UpdateDatesParams struct {
@@ -23,15 +35,34 @@ type UpdateParams struct {
 	Tx    *gorm.DB
 }
 
+type CountSuggestionsParams struct {
+	UserID int64
+	// Counts the suggestions made since then whatever their status, the open ones otherwise
+	Since int64
+}
+
+type SearchMoviesParams struct {
+	// Matched against the title and the directors, a year matches the year too; empty returns the best rated movies
+	Query  string
+	Offset int
+	Limit  int
+}
+
 type IMovieRepo interface {
-	GetCurrentMovies() ([]*model.Movie, error)
 	GetAlreadyWatchedMovies() ([]*model.Movie, error)
 	GetSuggestedMovies() ([]*model.Movie, error)
 	GetMovieByID(id int64) (*model.Movie, error)
 	Create(movie *model.Movie) error
 	Update(params *UpdateParams) error
 	UpdateRating(params *UpdateRatingParams) error
+	GetTopRatedMovies(limit int) ([]*model.Movie, error)
+	FindLegacyRatings(params *FindLegacyRatingsParams) ([]float64, error)
 	Upsert(movie *model.Movie) error
+	CountSuggestions(params *CountSuggestionsParams) (int64, error)
+	FindSuggestionsBy(userID int64) ([]*model.Movie, error)
+	IsInActiveSelection(movieID int64) (bool, error)
+	UpdateStatus(movieID int64, status string) error
+	Search(params *SearchMoviesParams) ([]*model.Movie, error)
 }
 
 type MovieRepo struct {
@@ -47,7 +78,8 @@ func (r *MovieRepo) Create(movie *model.Movie) error {
 }
 
 func (r *MovieRepo) Upsert(movie *model.Movie) error {
-	return r.db.Clauses(clause.OnConflict{
+	// Club ratings are calculated from the rating summaries and must survive a new suggestion
+	return r.db.Omit("rating", "rating_count", "adjusted_rating").Clauses(clause.OnConflict{
 		UpdateAll: true,
 	}).Create(&movie).Error
 }
@@ -65,35 +97,43 @@ func (r *MovieRepo) UpdateRating(params *UpdateRatingParams) error {
 	if tx == nil {
 		tx = r.db
 	}
-	return tx.Model(&model.Movie{ID: params.MovieID}).Update("rating", params.Rating).Error
+	return tx.Model(&model.Movie{ID: params.MovieID}).Updates(map[string]any{
+		"rating":          params.Rating,
+		"rating_count":    params.RatingCount,
+		"adjusted_rating": params.AdjustedRating,
+	}).Error
 }
 
-func (r *MovieRepo) GetMovieByID(id int64) (*model.Movie, error) {
-	var movie model.Movie
-	if err := r.db.Model(&model.Movie{}).Where(&model.Movie{ID: id}).First(&movie).Error; err != nil {
+func (r *MovieRepo) GetTopRatedMovies(limit int) ([]*model.Movie, error) {
+	var movies []*model.Movie
+	if err := r.db.Model(&model.Movie{}).Where("rating_count > 0 OR rating <> 0").Order(RANKING_SCORE + " DESC").Limit(limit).Find(&movies).Error; err != nil {
 		return nil, err
 	}
-	return &movie, nil
+	return movies, nil
 }
 
-func (r *MovieRepo) GetCurrentMovies() ([]*model.Movie, error) {
-	var movies []*model.Movie
-
-	sub := r.db.Model(&model.Session{}).
-		Select("movies_sessions.movie_id").
-		Joins("JOIN movies_sessions ON movies_sessions.session_id = sessions.id").
-		Where(&model.Session{Status: model.SESSION_ONGOING_STATUS})
+// FindLegacyRatings returns the ratings of the movies rated before the rating summaries, e.g. imported with json_to_sql.
+func (r *MovieRepo) FindLegacyRatings(params *FindLegacyRatingsParams) ([]float64, error) {
+	tx := params.Tx
+	if tx == nil {
+		tx = r.db
+	}
+	var ratings []float64
+	err := tx.Model(&model.Movie{}).Where("rating_count = 0 AND rating <> 0").Pluck("rating", &ratings).Error
+	return ratings, err
+}
 
-	if err := r.db.Model(&model.Movie{}).Where("id IN (?)", sub).Find(&movies).Error; err != nil {
+func (r *MovieRepo) GetMovieByID(id int64) (*model.Movie, error) {
+	var movie model.Movie
+	if err := r.db.Model(&model.Movie{}).Preload("Suggester").Where(&model.Movie{ID: id}).First(&movie).Error; err != nil {
 		return nil, err
 	}
-
-	return movies, nil
+	return &movie, nil
 }
 
 func (r *MovieRepo) GetAlreadyWatchedMovies() ([]*model.Movie, error) {
 	var movies []*model.Movie
-	if err := r.db.Model(&model.Movie{}).Preload("Suggester").Where("watch_count > 0").Find(&movies).Error; err != nil {
+	if err := r.db.Model(&model.Movie{}).Preload("Suggester").Preload("RatingSummaries").Where("watch_count > 0").Find(&movies).Error; err != nil {
 		return nil, err
 	}
 	return movies, nil
@@ -106,3 +146,53 @@ func (r *MovieRepo) GetSuggestedMovies() ([]*model.Movie, error) {
 	}
 	return movies, nil
 }
+
+func (r *MovieRepo) CountSuggestions(params *CountSuggestionsParams) (int64, error) {
+	var count int64
+	tx := r.db.Model(&model.Movie{}).Where("suggested_by = ?", params.UserID)
+	if params.Since > 0 {
+		tx = tx.Where("suggested_at >= ?", params.Since)
+	} else {
+		tx = tx.Where("status = ?", model.MOVIE_SUGGESTED_STATUS)
+	}
+	err := tx.Count(&count).Error
+	return count, err
+}
+
+// FindSuggestionsBy returns the suggested movies of the user, the newest first.
+func (r *MovieRepo) FindSuggestionsBy(userID int64) ([]*model.Movie, error) {
+	var movies []*model.Movie
+	err := r.db.Model(&model.Movie{}).Preload("Suggester").Where(&model.Movie{Status: model.MOVIE_SUGGESTED_STATUS, SuggestedBy: &userID}).Order("suggested_at DESC").Find(&movies).Error
+	return movies, err
+}
+
+// IsInActiveSelection reports whether the movie is an option of a selection voting that is still open.
+func (r *MovieRepo) IsInActiveSelection(movieID int64) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.PollOption{}).
+		Joins("JOIN polls ON polls.id = poll_options.poll_id AND polls.deleted_at IS NULL").
+		Joins("JOIN votings ON votings.id = polls.voting_id AND votings.deleted_at IS NULL").
+		Where("poll_options.movie_id = ? AND votings.type = ? AND votings.status = ?", movieID, model.VOTING_SELECTION_TYPE, model.VOTING_ACTIVE_STATUS).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *MovieRepo) UpdateStatus(movieID int64, status string) error {
+	return r.db.Model(&model.Movie{ID: movieID}).Update("status", status).Error
+}
+
+// Search looks for suggested and watched movies, archived suggestions are left out.
+func (r *MovieRepo) Search(params *SearchMoviesParams) ([]*model.Movie, error) {
+	var movies []*model.Movie
+	tx := r.db.Model(&model.Movie{}).Where("status IN ?", []string{model.MOVIE_SUGGESTED_STATUS, model.MOVIE_WATCHED_STATUS})
+	if params.Query != "" {
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(params.Query) + "%"
+		condition := r.db.Where("title ILIKE ?", pattern).Or("directors ILIKE ?", pattern)
+		if year, err := strconv.Atoi(params.Query); err == nil {
+			condition = condition.Or("year = ?", year)
+		}
+		tx = tx.Where(condition)
+	}
+	err := tx.Order(RANKING_SCORE + " DESC, watch_count DESC, id").Offset(params.Offset).Limit(params.Limit).Find(&movies).Error
+	return movies, err
+}
@@ -16,6 +16,13 @@ type CreatePollParams struct {
 	Tx   *gorm.DB
 }
 
+type ReopenPollParams struct {
+	ID        int64
+	PollID    string
+	MessageID int
+	Tx        *gorm.DB
+}
+
 type IPollRepo interface {
 	Create(params *CreatePollParams) (*model.Poll, error)
 	CreatePollOption(option *model.PollOption) error
@@ -24,6 +31,8 @@ type IPollRepo interface {
 	FindPollOptionsByPollID(pollID int64) ([]*model.PollOption, error)
 	UpdateStatus(params *UpdateStatusParams) error
 	FindByVotingID(votingID int64) (*model.Poll, error)
+	FindLastByVotingID(votingID int64) (*model.Poll, error)
+	Reopen(params *ReopenPollParams) error
 }
 
 type PollRepo struct {
@@ -53,6 +62,27 @@ func (r *PollRepo) FindByVotingID(votingID int64) (*model.Poll, error) {
 	return &poll, err
 }
 
+// FindLastByVotingID returns the latest poll of the voting regardless of its status.
+func (r *PollRepo) FindLastByVotingID(votingID int64) (*model.Poll, error) {
+	var poll model.Poll
+	err := r.db.Model(&model.Poll{}).Where("voting_id = ?", votingID).Order("id DESC").First(&poll).Error
+	return &poll, err
+}
+
+// Reopen points the poll to the newly sent Telegram message, poll options stay linked to it.
+func (r *PollRepo) Reopen(params *ReopenPollParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	updates := map[string]interface{}{
+		"poll_id":    params.PollID,
+		"message_id": params.MessageID,
+		"status":     model.POLL_OPENED_STATUS,
+	}
+	return tx.Model(&model.Poll{}).Where("id = ?", params.ID).Updates(updates).Error
+}
+
 func (r *PollRepo) FindByPollID(pollID string) (*model.Poll, error) {
 	var poll model.Poll
 	err := r.db.Model(&model.Poll{}).Preload("Voting").Preload("Movie").Where("poll_id = ? AND status = ?", pollID, model.POLL_OPENED_STATUS).First(&poll).Error
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"gorm.io/gorm"
+)
+
+type CreateRatingSummaryParams struct {
+	Summary *model.RatingSummary
+	Tx      *gorm.DB
+}
+
+type FindAllRatingSummariesParams struct {
+	Tx *gorm.DB
+}
+
+type FindRatingSummariesByVotingIDsParams struct {
+	VotingIDs []int64
+	Tx        *gorm.DB
+}
+
+type DeleteRatingSummaryParams struct {
+	VotingID int64
+	Tx       *gorm.DB
+}
+
+type IRatingSummaryRepo interface {
+	Create(params *CreateRatingSummaryParams) error
+	FindAll(params *FindAllRatingSummariesParams) ([]*model.RatingSummary, error)
+	FindByVotingIDs(params *FindRatingSummariesByVotingIDsParams) ([]*model.RatingSummary, error)
+	DeleteByVotingID(params *DeleteRatingSummaryParams) error
+}
+
+type RatingSummaryRepo struct {
+	db *gorm.DB
+}
+
+func NewRatingSummaryRepository(db *gorm.DB) IRatingSummaryRepo {
+	return &RatingSummaryRepo{db: db}
+}
+
+func (r *RatingSummaryRepo) Create(params *CreateRatingSummaryParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	return tx.Create(params.Summary).Error
+}
+
+func (r *RatingSummaryRepo) FindAll(params *FindAllRatingSummariesParams) ([]*model.RatingSummary, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	var summaries []*model.RatingSummary
+	err := tx.Model(&model.RatingSummary{}).Order("id").Find(&summaries).Error
+	return summaries, err
+}
+
+func (r *RatingSummaryRepo) FindByVotingIDs(params *FindRatingSummariesByVotingIDsParams) ([]*model.RatingSummary, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	var summaries []*model.RatingSummary
+	if len(params.VotingIDs) == 0 {
+		return summaries, nil
+	}
+	err := tx.Where("voting_id IN ?", params.VotingIDs).Order("id").Find(&summaries).Error
+	return summaries, err
+}
+
+// DeleteByVotingID removes the summary permanently, so the voting can be summarized again.
+func (r *RatingSummaryRepo) DeleteByVotingID(params *DeleteRatingSummaryParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	return tx.Unscoped().Where("voting_id = ?", params.VotingID).Delete(&model.RatingSummary{}).Error
+}
@@ -10,6 +10,9 @@ type IScheduleRepository interface {
 	FindActive() (*model.Schedule, error)
 	Update(schedule *model.Schedule) error
 	Replace(schedule *model.Schedule) (*model.Schedule, error)
+	FindExceptions(from string) ([]*model.ScheduleException, error)
+	CreateException(exception *model.ScheduleException) error
+	DeleteException(date string) (bool, error)
 }
 
 type ScheduleRepository struct {
@@ -52,3 +55,20 @@ func (r *ScheduleRepository) FindActive() (*model.Schedule, error) {
 func (r *ScheduleRepository) Update(schedule *model.Schedule) error {
 	return r.db.Save(schedule).Error
 }
+
+// FindExceptions returns the skipped dates starting from the given one.
+func (r *ScheduleRepository) FindExceptions(from string) ([]*model.ScheduleException, error) {
+	var exceptions []*model.ScheduleException
+	err := r.db.Where("date >= ?", from).Order("date").Find(&exceptions).Error
+	return exceptions, err
+}
+
+func (r *ScheduleRepository) CreateException(exception *model.ScheduleException) error {
+	return r.db.Create(exception).Error
+}
+
+// DeleteException reports whether the date was skipped.
+func (r *ScheduleRepository) DeleteException(date string) (bool, error) {
+	result := r.db.Where("date = ?", date).Delete(&model.ScheduleException{})
+	return result.RowsAffected > 0, result.Error
+}
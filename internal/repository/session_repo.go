@@ -1,13 +1,18 @@
 package repository
 
 import (
-	"log"
+	"cmp"
+	"errors"
+	"slices"
 
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// ErrSessionNotUpcoming is returned when the chosen session was cancelled or took place in the meantime.
+var ErrSessionNotUpcoming = errors.New("session is not upcoming")
+
 type ConnectMoviesToSessionParams struct {
 	SessionID int64
 	MovieIDs  []int64
@@ -15,11 +20,44 @@ type ConnectMoviesToSessionParams struct {
 }
 
 type FindOrCreateSessionParams struct {
+	SessionID  *int64 // upcoming session to find, a new one is created when it is nil or not upcoming anymore
 	CreatedBy  int64
 	FinishedAt *int64
+	Venue      model.Venue // venue of a created session
 	Tx         *gorm.DB
 }
 
+type FindUpcomingSessionsParams struct {
+	Tx *gorm.DB
+}
+
+type FindPastSessionsParams struct {
+	Offset int
+	Limit  int
+	Tx     *gorm.DB
+}
+
+type CancelSessionParams struct {
+	SessionID   int64
+	CancelledAt int64
+	Tx          *gorm.DB
+}
+
+type FindCancelledSessionsParams struct {
+	Since int64 // sessions cancelled after this time
+	Tx    *gorm.DB
+}
+
+type FindCancelledSessionParams struct {
+	SessionID int64
+	Tx        *gorm.DB
+}
+
+type RestoreSessionParams struct {
+	SessionID int64
+	Tx        *gorm.DB
+}
+
 type CreateSessionParams struct {
 	Session *model.Session
 	Tx      *gorm.DB
@@ -30,6 +68,18 @@ type FinishSessionParams struct {
 	Tx        *gorm.DB
 }
 
+type SetMoviePositionsParams struct {
+	SessionID int64
+	MovieIDs  []int64 // movies of the session in the running order
+	Tx        *gorm.DB
+}
+
+type AppendMoviePositionsParams struct {
+	SessionID int64
+	MovieIDs  []int64
+	Tx        *gorm.DB
+}
+
 type DisconnectMoviesFromSessionParams struct {
 	SessionID int64
 	MovieIDs  []int64
@@ -37,16 +87,22 @@ type DisconnectMoviesFromSessionParams struct {
 }
 
 type ISessionRepo interface {
-	GetOngoingSession(tx *gorm.DB) (*model.Session, error)
 	FindOrCreateSession(params *FindOrCreateSessionParams) (*model.Session, bool, error)
 	ConnectMoviesToSession(params *ConnectMoviesToSessionParams) error
 	FinishSession(params *FinishSessionParams) (*model.Session, error)
-	CancelSession(tx *gorm.DB) (*model.Session, error)
-	FindOngoingSession() (*model.Session, error)
+	CancelSession(params *CancelSessionParams) (*model.Session, error)
+	FindCancelledSessions(params *FindCancelledSessionsParams) ([]*model.Session, error)
+	FindCancelledSession(params *FindCancelledSessionParams) (*model.Session, error)
+	RestoreSession(params *RestoreSessionParams) (*model.Session, error)
+	FindNextSession() (*model.Session, error)
+	FindUpcomingSessions(params *FindUpcomingSessionsParams) ([]*model.Session, error)
+	FindPastSessions(params *FindPastSessionsParams) ([]*model.Session, int64, error)
 	RescheduleSession(sessionID int64, finishedAt int64) error
 	Transaction(fc func(tx *gorm.DB) error) error
 	Create(params *CreateSessionParams) (*model.Session, error)
 	DisconnectMoviesFromSession(params *DisconnectMoviesFromSessionParams) error
+	SetMoviePositions(params *SetMoviePositionsParams) error
+	AppendMoviePositions(params *AppendMoviePositionsParams) error
 	FindByID(sessionID int64) (*model.Session, error)
 	Update(session *model.Session) error
 }
@@ -67,50 +123,157 @@ func (r *SessionRepo) RescheduleSession(sessionID int64, finishedAt int64) error
 	return r.db.Model(&model.Session{ID: sessionID}).Update("finished_at", finishedAt).Error
 }
 
-func (r *SessionRepo) FindOngoingSession() (*model.Session, error) {
+// FindNextSession returns the earliest upcoming session.
+func (r *SessionRepo) FindNextSession() (*model.Session, error) {
 	var session model.Session
-	err := r.db.Where(&model.Session{Status: model.SESSION_ONGOING_STATUS}).Preload("Movies").Preload("Movies.Suggester").First(&session).Error
+	err := r.db.Where("status IN ?", model.SESSION_UPCOMING_STATUSES).Order("finished_at").Preload("Movies").Preload("Movies.Suggester").First(&session).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := sortMovies(r.db, &session); err != nil {
+		return nil, err
+	}
 	return &session, nil
 }
 
-func (r *SessionRepo) CancelSession(tx *gorm.DB) (*model.Session, error) {
-	var session model.Session
-	err := tx.Model(&session).Where(&model.Session{Status: model.SESSION_ONGOING_STATUS}).Clauses(clause.Returning{}).Update("status", model.SESSION_CANCELLED_STATUS).Error
+func (r *SessionRepo) FindUpcomingSessions(params *FindUpcomingSessionsParams) ([]*model.Session, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	var sessions []*model.Session
+	err := tx.Where("status IN ?", model.SESSION_UPCOMING_STATUSES).Order("finished_at").Preload("Movies").Find(&sessions).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := sortMovies(tx, sessions...); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// FindPastSessions returns a page of finished and cancelled sessions, the latest first, and their total count.
+func (r *SessionRepo) FindPastSessions(params *FindPastSessionsParams) ([]*model.Session, int64, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	past := []string{model.SESSION_FINISHED_STATUS, model.SESSION_CANCELLED_STATUS}
+	var total int64
+	if err := tx.Model(&model.Session{}).Where("status IN ?", past).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var sessions []*model.Session
+	err := tx.Where("status IN ?", past).
+		Order("finished_at DESC, id DESC").
+		Offset(params.Offset).
+		Limit(params.Limit).
+		Preload("Movies").
+		Preload("Creator").
+		Preload("Votings").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := sortMovies(tx, sessions...); err != nil {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
+func (r *SessionRepo) CancelSession(params *CancelSessionParams) (*model.Session, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	session := model.Session{ID: params.SessionID}
+	updates := map[string]interface{}{
+		"status":         model.SESSION_CANCELLED_STATUS,
+		"cancelled_at":   params.CancelledAt,
+		"cancelled_from": gorm.Expr("status"),
+	}
+	result := tx.Model(&session).Where("status IN ?", model.SESSION_UPCOMING_STATUSES).Clauses(clause.Returning{}).Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
 	return &session, nil
 }
 
-func (r *SessionRepo) FinishSession(params *FinishSessionParams) (*model.Session, error) {
+// FindCancelledSessions returns the sessions cancelled since the given time, the latest first.
+func (r *SessionRepo) FindCancelledSessions(params *FindCancelledSessionsParams) ([]*model.Session, error) {
 	var tx *gorm.DB = r.db
 	if params.Tx != nil {
 		tx = params.Tx
 	}
-	sessionID := params.SessionID
-	session := &model.Session{ID: sessionID}
-	err := tx.Model(session).Clauses(clause.Returning{}).Update("status", model.SESSION_FINISHED_STATUS).Error
+	var sessions []*model.Session
+	err := tx.Where("status = ? AND cancelled_at >= ?", model.SESSION_CANCELLED_STATUS, params.Since).
+		Order("cancelled_at DESC").
+		Preload("Movies").
+		Find(&sessions).Error
 	if err != nil {
 		return nil, err
 	}
-	return session, nil
+	if err := sortMovies(tx, sessions...); err != nil {
+		return nil, err
+	}
+	return sessions, nil
 }
 
-func (r *SessionRepo) GetOngoingSession(tx *gorm.DB) (*model.Session, error) {
-	db := r.db
-	if tx != nil {
-		db = tx
+// FindCancelledSession returns the session if it is cancelled and locks it until the end of the transaction.
+func (r *SessionRepo) FindCancelledSession(params *FindCancelledSessionParams) (*model.Session, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
 	}
 	var session model.Session
-	if err := db.Where(&model.Session{Status: model.SESSION_ONGOING_STATUS}).First(&session).Error; err != nil {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ?", params.SessionID, model.SESSION_CANCELLED_STATUS).
+		First(&session).Error
+	if err != nil {
 		return nil, err
 	}
 	return &session, nil
 }
 
+// RestoreSession returns the cancelled session to the status it had before the cancel.
+func (r *SessionRepo) RestoreSession(params *RestoreSessionParams) (*model.Session, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	session := model.Session{ID: params.SessionID}
+	updates := map[string]interface{}{
+		"status":         gorm.Expr("COALESCE(NULLIF(cancelled_from, ''), ?)", model.SESSION_PLANNED_STATUS),
+		"cancelled_at":   nil,
+		"cancelled_from": "",
+	}
+	result := tx.Model(&session).Where("status = ?", model.SESSION_CANCELLED_STATUS).Clauses(clause.Returning{}).Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &session, nil
+}
+
+func (r *SessionRepo) FinishSession(params *FinishSessionParams) (*model.Session, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	sessionID := params.SessionID
+	session := &model.Session{ID: sessionID}
+	err := tx.Model(session).Clauses(clause.Returning{}).Update("status", model.SESSION_FINISHED_STATUS).Error
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
 func (r *SessionRepo) FindOrCreateSession(params *FindOrCreateSessionParams) (*model.Session, bool, error) {
 	var session model.Session
 	var created bool = false
@@ -118,14 +281,21 @@ func (r *SessionRepo) FindOrCreateSession(params *FindOrCreateSessionParams) (*m
 	if params.Tx != nil {
 		tx = params.Tx
 	}
-	err := tx.Where("status = ?", model.SESSION_ONGOING_STATUS).Attrs(&model.Session{Status: model.SESSION_ONGOING_STATUS, CreatedBy: params.CreatedBy, FinishedAt: *params.FinishedAt}).FirstOrCreate(&session).Error
-	if session.CreatedAt.Equal(session.UpdatedAt) {
-		created = true
+	if params.SessionID != nil {
+		err := tx.Where("id = ? AND status IN ?", *params.SessionID, model.SESSION_UPCOMING_STATUSES).First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrSessionNotUpcoming
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return &session, created, nil
 	}
-	if err != nil {
+	session = model.Session{Status: model.SESSION_PLANNED_STATUS, CreatedBy: params.CreatedBy, FinishedAt: *params.FinishedAt, Venue: params.Venue}
+	if err := tx.Create(&session).Error; err != nil {
 		return nil, false, err
 	}
-	log.Println(session)
+	created = true
 	return &session, created, nil
 }
 
@@ -145,6 +315,81 @@ func (r *SessionRepo) ConnectMoviesToSession(params *ConnectMoviesToSessionParam
 	if err := tx.Model(&session).Association("Movies").Append(&movies); err != nil {
 		return err
 	}
+	return r.AppendMoviePositions(&AppendMoviePositionsParams{SessionID: params.SessionID, MovieIDs: params.MovieIDs, Tx: tx})
+}
+
+// SetMoviePositions saves the running order of the session movies.
+func (r *SessionRepo) SetMoviePositions(params *SetMoviePositionsParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	for i, movieID := range params.MovieIDs {
+		err := tx.Model(&model.MovieSession{}).
+			Where("session_id = ? AND movie_id = ?", params.SessionID, movieID).
+			Update("position", i+1).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendMoviePositions puts the just attached movies to the end of the lineup in the given order.
+// Movies that already have a position keep it.
+func (r *SessionRepo) AppendMoviePositions(params *AppendMoviePositionsParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	var last int
+	err := tx.Model(&model.MovieSession{}).
+		Where("session_id = ?", params.SessionID).
+		Select("COALESCE(MAX(position), 0)").
+		Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	for _, movieID := range params.MovieIDs {
+		result := tx.Model(&model.MovieSession{}).
+			Where("session_id = ? AND movie_id = ? AND position = 0", params.SessionID, movieID).
+			Update("position", last+1)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			last++
+		}
+	}
+	return nil
+}
+
+// sortMovies orders the preloaded movies of the sessions by their position, the preload itself can't order
+// by a column of the join table. Movies attached before the lineup was introduced come first by ID.
+func sortMovies(tx *gorm.DB, sessions ...*model.Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	sessionIDs := make([]int64, 0, len(sessions))
+	for _, session := range sessions {
+		sessionIDs = append(sessionIDs, session.ID)
+	}
+	var rows []model.MovieSession
+	if err := tx.Where("session_id IN ?", sessionIDs).Find(&rows).Error; err != nil {
+		return err
+	}
+	positions := make(map[[2]int64]int, len(rows))
+	for _, row := range rows {
+		positions[[2]int64{row.SessionID, row.MovieID}] = row.Position
+	}
+	for _, session := range sessions {
+		slices.SortStableFunc(session.Movies, func(a, b model.Movie) int {
+			if diff := positions[[2]int64{session.ID, a.ID}] - positions[[2]int64{session.ID, b.ID}]; diff != 0 {
+				return diff
+			}
+			return cmp.Compare(a.ID, b.ID)
+		})
+	}
 	return nil
 }
 
@@ -178,10 +423,13 @@ func (r *SessionRepo) Create(params *CreateSessionParams) (*model.Session, error
 
 func (r *SessionRepo) FindByID(sessionID int64) (*model.Session, error) {
 	var session model.Session
-	err := r.db.Preload("Movies").Preload("Votings").First(&session, sessionID).Error
+	err := r.db.Preload("Movies.Suggester").Preload("Votings").Preload("Creator").First(&session, sessionID).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := sortMovies(r.db, &session); err != nil {
+		return nil, err
+	}
 	return &session, nil
 }
 
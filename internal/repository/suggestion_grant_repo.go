@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"gorm.io/gorm"
+)
+
+type ISuggestionGrantRepo interface {
+	Create(grant *model.SuggestionGrant) error
+	SumActiveSlots(userID int64, now int64) (int, error)
+}
+
+type SuggestionGrantRepo struct {
+	db *gorm.DB
+}
+
+func NewSuggestionGrantRepository(db *gorm.DB) ISuggestionGrantRepo {
+	return &SuggestionGrantRepo{db: db}
+}
+
+func (r *SuggestionGrantRepo) Create(grant *model.SuggestionGrant) error {
+	return r.db.Create(grant).Error
+}
+
+// SumActiveSlots returns the extra slots of the grants of the user that haven't expired.
+func (r *SuggestionGrantRepo) SumActiveSlots(userID int64, now int64) (int, error) {
+	var slots int
+	err := r.db.Model(&model.SuggestionGrant{}).Where("user_id = ? AND expires_at > ?", userID, now).Select("COALESCE(SUM(slots), 0)").Scan(&slots).Error
+	return slots, err
+}
@@ -12,6 +12,9 @@ type UserRepo struct {
 type IUserRepo interface {
 	FindByID(userID int64) (*model.User, error)
 	Save(user *model.User) error
+	Count() (int64, error)
+	FindNonVoters(votingID int64) ([]*model.User, error)
+	UpdateVotingReminders(userID int64, enabled bool) error
 }
 
 func NewUserRepository(db *gorm.DB) IUserRepo {
@@ -22,6 +25,14 @@ func (r *UserRepo) Save(user *model.User) error {
 	return r.db.Save(user).Error
 }
 
+func (r *UserRepo) Count() (int64, error) {
+	var count int64
+	if err := r.db.Model(&model.User{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func (r *UserRepo) FindByID(userID int64) (*model.User, error) {
 	var user model.User
 	if err := r.db.Where(&model.User{ID: userID}).Preload("Role").First(&user).Error; err != nil {
@@ -29,3 +40,18 @@ func (r *UserRepo) FindByID(userID int64) (*model.User, error) {
 	}
 	return &user, nil
 }
+
+// FindNonVoters returns registered users without a single vote in the voting,
+// "didn't watch" answers count as votes.
+func (r *UserRepo) FindNonVoters(votingID int64) ([]*model.User, error) {
+	var users []*model.User
+	voters := r.db.Model(&model.Vote{}).Select("user_id").Where("voting_id = ?", votingID)
+	if err := r.db.Where("id NOT IN (?)", voters).Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *UserRepo) UpdateVotingReminders(userID int64, enabled bool) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Update("voting_reminders", enabled).Error
+}
@@ -16,11 +16,28 @@ type CreateVoteParams struct {
 	Tx   *gorm.DB
 }
 
+type FindByUserIdAndVotingIdParams struct {
+	UserID   int64
+	VotingID int64
+	Tx       *gorm.DB
+}
+
+type MovieVoteCount struct {
+	MovieID    int64
+	MovieCount int64
+}
+
 type IVoteRepo interface {
 	Create(params *CreateVoteParams) error
+	FindByVotingID(votingID int64) ([]*model.Vote, error)
+	FindByUserIdAndVotingId(params *FindByUserIdAndVotingIdParams) ([]*model.Vote, error)
 	DeleteByUserIdAndVotingId(params *DeleteByUserIdAndVotingIdParams) error
 	CalculateRatingMean(votingID int64) (float64, error)
 	CalculateMaxMovieCount(votingID int64) (int64, int64, error)
+	CalculateTopMovies(votingID int64) (int64, []int64, error)
+	CountVoters(votingID int64) (int64, error)
+	CountAbstained(votingID int64) (int64, error)
+	CountVotesByMovie(votingID int64) ([]*MovieVoteCount, error)
 	Transaction(func(tx *gorm.DB) error) error
 }
 
@@ -44,6 +61,32 @@ func (r *VoteRepo) DeleteByUserIdAndVotingId(params *DeleteByUserIdAndVotingIdPa
 	return tx.Where(&model.Vote{UserID: params.UserID, VotingID: params.VotingID}).Delete(&model.Vote{}).Error
 }
 
+func (r *VoteRepo) FindByVotingID(votingID int64) ([]*model.Vote, error) {
+	var votes []*model.Vote
+	err := r.db.Where("voting_id = ?", votingID).
+		Order("user_id, rank").
+		Find(&votes).Error
+	if err != nil {
+		return nil, err
+	}
+	return votes, nil
+}
+
+func (r *VoteRepo) FindByUserIdAndVotingId(params *FindByUserIdAndVotingIdParams) ([]*model.Vote, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	var votes []*model.Vote
+	err := tx.Where(&model.Vote{UserID: params.UserID, VotingID: params.VotingID}).
+		Order("rank").
+		Find(&votes).Error
+	if err != nil {
+		return nil, err
+	}
+	return votes, nil
+}
+
 func (r *VoteRepo) Create(params *CreateVoteParams) error {
 	var tx *gorm.DB = r.db
 	if params.Tx != nil {
@@ -73,13 +116,70 @@ func (r *VoteRepo) CalculateMaxMovieCount(votingID int64) (int64, int64, error)
 	return result.MovieCount, result.MovieID, nil
 }
 
+func (r *VoteRepo) CountVotesByMovie(votingID int64) ([]*MovieVoteCount, error) {
+	var results []*MovieVoteCount
+	err := r.db.Model(&model.Vote{}).
+		Select("COUNT(*) as movie_count, movie_id").
+		Where("voting_id = ?", votingID).
+		Group("movie_id").
+		Order("movie_count DESC").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CalculateTopMovies returns the highest vote count and every movie that reached it.
+func (r *VoteRepo) CalculateTopMovies(votingID int64) (int64, []int64, error) {
+	results, err := r.CountVotesByMovie(votingID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(results) == 0 {
+		return 0, nil, nil
+	}
+	var movieIDs []int64
+	for _, result := range results {
+		if result.MovieCount != results[0].MovieCount {
+			break
+		}
+		movieIDs = append(movieIDs, result.MovieID)
+	}
+	return results[0].MovieCount, movieIDs, nil
+}
+
+func (r *VoteRepo) CountVoters(votingID int64) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Vote{}).
+		Where("voting_id = ? AND abstained = ?", votingID, false).
+		Distinct("user_id").
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *VoteRepo) CountAbstained(votingID int64) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Vote{}).
+		Where("voting_id = ? AND abstained = ?", votingID, true).
+		Distinct("user_id").
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func (r *VoteRepo) CalculateRatingMean(votingID int64) (float64, error) {
 	var result struct {
 		Mean float64
 	}
 	err := r.db.Model(&model.Vote{}).
 		Select("AVG(rating) as mean").
-		Where("voting_id = ?", votingID).
+		Where("voting_id = ? AND abstained = ?", votingID, false).
 		Scan(&result).Error
 	if err != nil {
 		return 0, err
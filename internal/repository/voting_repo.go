@@ -10,8 +10,37 @@ import (
 )
 
 type FinishVotingParams struct {
-	VotingID int64
-	Tx       *gorm.DB
+	VotingID      int64
+	QuorumOutcome *string
+	WinnerMovieID *int64
+	SessionID     *int64
+	Tx            *gorm.DB
+}
+
+type RescheduleVotingParams struct {
+	VotingID   int64
+	FinishedAt int64
+	Tx         *gorm.DB
+}
+
+type ReopenVotingParams struct {
+	VotingID   int64
+	FinishedAt int64
+	// Detaches the voting from its session, the winner goes to a session picked at the next close
+	DetachSession bool
+	Tx            *gorm.DB
+}
+
+type ExtendVotingParams struct {
+	VotingID   int64
+	FinishedAt int64
+	Tx         *gorm.DB
+}
+
+type CancelVotingParams struct {
+	VotingID      int64
+	QuorumOutcome *string
+	Tx            *gorm.DB
 }
 
 type CreateVotingParams struct {
@@ -20,8 +49,16 @@ type CreateVotingParams struct {
 }
 
 type CancelVotingsBySessionIDParams struct {
-	SessionID int64
-	Tx        *gorm.DB
+	SessionID  int64
+	MovieIDs   []int64 // cancel only the votings of these movies, all of them when empty
+	FinishedAt int64   // time of the cancel, now when zero
+	Tx         *gorm.DB
+}
+
+type FindCancelledWithSessionParams struct {
+	SessionID   int64
+	CancelledAt int64
+	Tx          *gorm.DB
 }
 
 type IVotingRepo interface {
@@ -29,10 +66,17 @@ type IVotingRepo interface {
 	CreateVoting(params *CreateVotingParams) (*model.Voting, error)
 	FindVotingByID(id int64) (*model.Voting, error)
 	FindVotingsByStatus(status string) ([]*model.Voting, error)
+	FindClosedVotingsSince(since int64) ([]*model.Voting, error)
 	UpdateVotingStatus(voting *model.Voting) (*model.Voting, error)
 	FinishVoting(params *FinishVotingParams) error
+	ExtendVoting(params *ExtendVotingParams) error
+	CancelVoting(params *CancelVotingParams) error
+	RescheduleVoting(params *RescheduleVotingParams) error
+	ReopenVoting(params *ReopenVotingParams) error
 	FindVotingsBySessionID(sessionID int64) ([]*model.Voting, error)
+	FindRunoff(parentID int64) (*model.Voting, error)
 	CancelVotingsBySessionID(params *CancelVotingsBySessionIDParams) ([]*model.Voting, error)
+	FindCancelledWithSession(params *FindCancelledWithSessionParams) ([]*model.Voting, error)
 }
 
 type VotingRepo struct {
@@ -54,12 +98,39 @@ func (r *VotingRepo) CancelVotingsBySessionID(params *CancelVotingsBySessionIDPa
 	if params.Tx != nil {
 		tx = params.Tx
 	}
+	finishedAt := params.FinishedAt
+	if finishedAt == 0 {
+		finishedAt = time.Now().Unix()
+	}
 	updates := map[string]interface{}{
-		"status":      model.VOTING_CANCELLED_STATUS,
-		"finished_at": time.Now().Unix(),
+		"status":              model.VOTING_CANCELLED_STATUS,
+		"finished_at":         finishedAt,
+		"planned_finished_at": gorm.Expr("finished_at"),
 	}
+	// Finished votings of the session keep their results
 	votings := []*model.Voting{}
-	err := tx.Model(&model.Voting{}).Clauses(clause.Returning{}).Where(&model.Voting{SessionID: &params.SessionID}).Updates(updates).Find(&votings).Error
+	query := tx.Model(&votings).Clauses(clause.Returning{}).Where("session_id = ? AND status = ?", params.SessionID, model.VOTING_ACTIVE_STATUS)
+	if len(params.MovieIDs) > 0 {
+		query = query.Where("movie_id IN ?", params.MovieIDs)
+	}
+	err := query.Updates(updates).Error
+	if err != nil {
+		return nil, err
+	}
+	return votings, nil
+}
+
+// FindCancelledWithSession returns the votings cancelled together with the session,
+// they have the time of the session cancel as the finish time.
+func (r *VotingRepo) FindCancelledWithSession(params *FindCancelledWithSessionParams) ([]*model.Voting, error) {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	var votings []*model.Voting
+	err := tx.Where("session_id = ? AND status = ? AND finished_at = ?", params.SessionID, model.VOTING_CANCELLED_STATUS, params.CancelledAt).
+		Order("id").
+		Find(&votings).Error
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +145,18 @@ func (r *VotingRepo) FindVotingsBySessionID(sessionID int64) ([]*model.Voting, e
 	return votings, nil
 }
 
+// FindRunoff returns the runoff started from the tied voting, nil when there is none yet.
+func (r *VotingRepo) FindRunoff(parentID int64) (*model.Voting, error) {
+	var votings []*model.Voting
+	if err := r.db.Where(&model.Voting{ParentID: &parentID}).Where("runoff_round > 0").Order("id DESC").Limit(1).Find(&votings).Error; err != nil {
+		return nil, err
+	}
+	if len(votings) == 0 {
+		return nil, nil
+	}
+	return votings[0], nil
+}
+
 func (r *VotingRepo) CreateVoting(params *CreateVotingParams) (*model.Voting, error) {
 	var tx *gorm.DB = r.db
 	if params.Tx != nil {
@@ -96,6 +179,16 @@ func (r *VotingRepo) FinishVoting(params *FinishVotingParams) error {
 		"status":      model.VOTING_INACTIVE_STATUS,
 		"finished_at": time.Now().Unix(),
 	}
+	if params.QuorumOutcome != nil {
+		updates["quorum_outcome"] = *params.QuorumOutcome
+		updates["low_confidence"] = *params.QuorumOutcome == model.QUORUM_LOW_CONFIDENCE_OUTCOME
+	}
+	if params.WinnerMovieID != nil {
+		updates["winner_movie_id"] = *params.WinnerMovieID
+	}
+	if params.SessionID != nil {
+		updates["session_id"] = *params.SessionID
+	}
 	err := tx.Model(&model.Voting{}).Where(&model.Voting{ID: params.VotingID}).Updates(updates).Error
 	if err != nil {
 		return err
@@ -103,6 +196,62 @@ func (r *VotingRepo) FinishVoting(params *FinishVotingParams) error {
 	return nil
 }
 
+func (r *VotingRepo) ExtendVoting(params *ExtendVotingParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	updates := map[string]interface{}{
+		"finished_at":    params.FinishedAt,
+		"extended":       true,
+		"quorum_outcome": model.QUORUM_EXTENDED_OUTCOME,
+	}
+	return tx.Model(&model.Voting{}).Where(&model.Voting{ID: params.VotingID}).Updates(updates).Error
+}
+
+func (r *VotingRepo) RescheduleVoting(params *RescheduleVotingParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	return tx.Model(&model.Voting{}).Where(&model.Voting{ID: params.VotingID}).Update("finished_at", params.FinishedAt).Error
+}
+
+// ReopenVoting makes the voting active again and forgets the outcome of the previous close.
+func (r *VotingRepo) ReopenVoting(params *ReopenVotingParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	updates := map[string]interface{}{
+		"status":          model.VOTING_ACTIVE_STATUS,
+		"finished_at":     params.FinishedAt,
+		"quorum_outcome":  nil,
+		"low_confidence":  false,
+		"extended":        false,
+		"winner_movie_id": nil,
+	}
+	if params.DetachSession {
+		updates["session_id"] = nil
+	}
+	return tx.Model(&model.Voting{}).Where(&model.Voting{ID: params.VotingID}).Updates(updates).Error
+}
+
+func (r *VotingRepo) CancelVoting(params *CancelVotingParams) error {
+	var tx *gorm.DB = r.db
+	if params.Tx != nil {
+		tx = params.Tx
+	}
+	updates := map[string]interface{}{
+		"status":      model.VOTING_CANCELLED_STATUS,
+		"finished_at": time.Now().Unix(),
+	}
+	if params.QuorumOutcome != nil {
+		updates["quorum_outcome"] = *params.QuorumOutcome
+	}
+	return tx.Model(&model.Voting{}).Where(&model.Voting{ID: params.VotingID}).Updates(updates).Error
+}
+
 func (r *VotingRepo) UpdateVotingStatus(voting *model.Voting) (*model.Voting, error) {
 	if err := r.db.Model(&voting).Update("status", voting.Status).Error; err != nil {
 		return nil, err
@@ -118,6 +267,23 @@ func (r *VotingRepo) FindVotingByID(id int64) (*model.Voting, error) {
 	return &voting, nil
 }
 
+// FindClosedVotingsSince returns finished and cancelled votings closed after the given time.
+// Votings that were handed over to a runoff are skipped.
+func (r *VotingRepo) FindClosedVotingsSince(since int64) ([]*model.Voting, error) {
+	var votings []*model.Voting
+	runoffs := r.db.Model(&model.Voting{}).Select("parent_id").Where("parent_id IS NOT NULL")
+	err := r.db.Where("status IN ? AND finished_at >= ?", []string{model.VOTING_INACTIVE_STATUS, model.VOTING_CANCELLED_STATUS}, since).
+		Where("id NOT IN (?)", runoffs).
+		// Results of bracket groups have already been moved to the final
+		Where("bracket_group = 0").
+		Order("finished_at DESC").
+		Find(&votings).Error
+	if err != nil {
+		return nil, err
+	}
+	return votings, nil
+}
+
 func (r *VotingRepo) FindVotingsByStatus(status string) ([]*model.Voting, error) {
 	var votings []*model.Voting
 	if err := r.db.Where(&model.Voting{Status: status}).Find(&votings).Error; err != nil {
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/repository"
+	"github.com/go-telegram/bot/models"
+)
+
+const RSVP_PREFIX = "rsvp_"
+
+var ErrSessionNotUpcoming = errors.New("session is not upcoming")
+
+type IAttendanceService interface {
+	SetAttendance(sessionID int64, userID int64, status string) error
+	GetAttendances(sessionID int64) ([]*model.Attendance, error)
+}
+
+type AttendanceService struct {
+	repo        repository.IAttendanceRepo
+	sessionRepo repository.ISessionRepo
+}
+
+func NewAttendanceService(repo repository.IAttendanceRepo, sessionRepo repository.ISessionRepo) IAttendanceService {
+	return &AttendanceService{repo: repo, sessionRepo: sessionRepo}
+}
+
+// SetAttendance saves the RSVP answer, answers are accepted only until the session takes place.
+func (s *AttendanceService) SetAttendance(sessionID int64, userID int64, status string) error {
+	if !slices.Contains([]string{model.ATTENDANCE_YES_STATUS, model.ATTENDANCE_NO_STATUS, model.ATTENDANCE_MAYBE_STATUS}, status) {
+		return fmt.Errorf("unknown attendance status %q", status)
+	}
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(model.SESSION_UPCOMING_STATUSES, session.Status) {
+		return ErrSessionNotUpcoming
+	}
+	return s.repo.Upsert(&repository.UpsertAttendanceParams{
+		SessionID: sessionID,
+		UserID:    userID,
+		Status:    status,
+	})
+}
+
+func (s *AttendanceService) GetAttendances(sessionID int64) ([]*model.Attendance, error) {
+	return s.repo.FindBySessionID(&repository.FindAttendancesParams{SessionID: sessionID})
+}
+
+func RsvpKeyboard(sessionID int64) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{
+		{Text: "✅ Приду", CallbackData: fmt.Sprintf("%s%d_%s", RSVP_PREFIX, sessionID, model.ATTENDANCE_YES_STATUS)},
+		{Text: "❌ Не приду", CallbackData: fmt.Sprintf("%s%d_%s", RSVP_PREFIX, sessionID, model.ATTENDANCE_NO_STATUS)},
+		{Text: "🤔 Может быть", CallbackData: fmt.Sprintf("%s%d_%s", RSVP_PREFIX, sessionID, model.ATTENDANCE_MAYBE_STATUS)},
+	}}}
+}
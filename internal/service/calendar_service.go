@@ -0,0 +1,151 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/repository"
+)
+
+// CALENDAR_PATH is the path of the feed, links look like /calendar/<user ID>/<token>.ics
+const CALENDAR_PATH = "/calendar/"
+
+// CALENDAR_EVENT_LENGTH is the length of events of sessions without movie durations.
+const CALENDAR_EVENT_LENGTH = 2 * time.Hour
+
+const calendarTimeFormat = "20060102T150405Z"
+
+type ICalendarService interface {
+	Enabled() bool
+	FeedURL(domain string, userID int64) string
+	CheckToken(userID int64, token string) bool
+	Feed() (string, error)
+}
+
+type CalendarService struct {
+	sessionRepo repository.ISessionRepo
+	secret      []byte
+	lineupBreak time.Duration
+}
+
+func NewCalendarService(sessionRepo repository.ISessionRepo, secret string, lineupBreak time.Duration) ICalendarService {
+	return &CalendarService{sessionRepo: sessionRepo, secret: []byte(secret), lineupBreak: lineupBreak}
+}
+
+func (s *CalendarService) Enabled() bool {
+	return len(s.secret) > 0
+}
+
+// FeedURL returns the personal subscription link of the member.
+func (s *CalendarService) FeedURL(domain string, userID int64) string {
+	return fmt.Sprintf("%s%s%d/%s.ics", strings.TrimSuffix(domain, "/"), CALENDAR_PATH, userID, s.token(userID))
+}
+
+func (s *CalendarService) CheckToken(userID int64, token string) bool {
+	if !s.Enabled() {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(s.token(userID)))
+}
+
+func (s *CalendarService) token(userID int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(strconv.FormatInt(userID, 10)))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// Feed builds the iCalendar with an event per upcoming session, the event lasts until the last movie ends.
+func (s *CalendarService) Feed() (string, error) {
+	sessions, err := s.sessionRepo.FindUpcomingSessions(&repository.FindUpcomingSessionsParams{})
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC().Format(calendarTimeFormat)
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//tg-bot-movieclub-go//RU",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:КиноКласс",
+	}
+	for _, session := range sessions {
+		lines = append(lines, s.event(session, now)...)
+	}
+	lines = append(lines, "END:VCALENDAR")
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(foldCalendarLine(line))
+		sb.WriteString("\r\n")
+	}
+	return sb.String(), nil
+}
+
+func (s *CalendarService) event(session *model.Session, stamp string) []string {
+	start := time.Unix(session.FinishedAt, 0)
+	end := start.Add(CALENDAR_EVENT_LENGTH)
+	lineup := Lineup(session, s.lineupBreak)
+	if len(lineup) > 0 && lineup[len(lineup)-1].End.After(start) {
+		end = lineup[len(lineup)-1].End
+	}
+	titles := make([]string, 0, len(lineup))
+	var description []string
+	if session.Description != "" {
+		description = append(description, session.Description, "")
+	}
+	for _, slot := range lineup {
+		titles = append(titles, slot.Movie.Title)
+		description = append(description, fmt.Sprintf("%s %s (%d) %s", slot.Start.Format("15:04"), slot.Movie.Title, slot.Movie.Year, slot.Movie.Link))
+	}
+	if notes := session.Venue.Notes; notes != "" {
+		description = append(description, "", notes)
+	}
+	summary := "КиноКласс"
+	if len(titles) > 0 {
+		summary += ": " + strings.Join(titles, ", ")
+	}
+	event := []string{
+		"BEGIN:VEVENT",
+		fmt.Sprintf("UID:session-%d@tg-bot-movieclub-go", session.ID),
+		"DTSTAMP:" + stamp,
+		"DTSTART:" + start.UTC().Format(calendarTimeFormat),
+		"DTEND:" + end.UTC().Format(calendarTimeFormat),
+		"SUMMARY:" + escapeCalendarText(summary),
+		"DESCRIPTION:" + escapeCalendarText(strings.Join(description, "\n")),
+	}
+	if location := session.Venue.Address; location != "" {
+		event = append(event, "LOCATION:"+escapeCalendarText(location))
+	} else if session.Venue.RoomURL != "" {
+		event = append(event, "LOCATION:"+escapeCalendarText(session.Venue.RoomURL))
+	}
+	if session.Venue.RoomURL != "" {
+		event = append(event, "URL:"+session.Venue.RoomURL)
+	}
+	return append(event, "END:VEVENT")
+}
+
+func escapeCalendarText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// foldCalendarLine splits lines longer than 75 octets as RFC 5545 requires, without breaking UTF-8 characters.
+func foldCalendarLine(line string) string {
+	var sb strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > 75 {
+			sb.WriteString("\r\n ")
+			length = 1
+		}
+		sb.WriteRune(r)
+		length += size
+	}
+	return sb.String()
+}
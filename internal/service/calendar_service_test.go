@@ -0,0 +1,76 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFoldCalendarLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "short line",
+			line: "SUMMARY:КиноКласс",
+			want: "SUMMARY:КиноКласс",
+		},
+		{
+			name: "exactly 75 octets",
+			line: strings.Repeat("a", 75),
+			want: strings.Repeat("a", 75),
+		},
+		{
+			name: "ascii",
+			line: strings.Repeat("a", 80),
+			want: strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 5),
+		},
+		{
+			name: "two-octet characters aren't split",
+			line: strings.Repeat("я", 40),
+			want: strings.Repeat("я", 37) + "\r\n " + strings.Repeat("я", 3),
+		},
+		{
+			name: "several folds",
+			line: strings.Repeat("a", 200),
+			want: strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n " + strings.Repeat("a", 51),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := foldCalendarLine(tt.line)
+			if got != tt.want {
+				t.Errorf("foldCalendarLine() = %q, want %q", got, tt.want)
+			}
+			for _, line := range strings.Split(got, "\r\n") {
+				if len(line) > 75 {
+					t.Errorf("line of %d octets: %q", len(line), line)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line with a broken character: %q", line)
+				}
+			}
+			if unfolded := strings.ReplaceAll(got, "\r\n ", ""); unfolded != tt.line {
+				t.Errorf("unfolded line = %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
+
+func TestEscapeCalendarText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "Дюна, часть 2; ИМАКС", want: `Дюна\, часть 2\; ИМАКС`},
+		{text: `C:\movies`, want: `C:\\movies`},
+		{text: "строка\nстрока\r\nстрока", want: `строка\nстрока\nстрока`},
+	}
+	for _, tt := range tests {
+		if got := escapeCalendarText(tt.text); got != tt.want {
+			t.Errorf("escapeCalendarText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -7,17 +7,28 @@ import (
 )
 
 type MovieDTO struct {
-	KinopoiskID int64    `json:"kinopoisk_id"`
-	Title       string   `json:"title"`
-	Description string   `json:"description"`
-	Directors   []string `json:"director"`
-	Year        int      `json:"year"`
-	Countries   []string `json:"countries"`
-	Genres      []string `json:"genres"`
-	Link        string   `json:"link"`
-	Duration    int      `json:"duration"`
-	IMDBRating  float64  `json:"imdb"`
-	SuggestedBy *int64   `json:"suggested_by"`
+	KinopoiskID      int64    `json:"kinopoisk_id"`
+	Title            string   `json:"title"`
+	Description      string   `json:"description"`
+	Directors        []string `json:"director"`
+	Year             int      `json:"year"`
+	Countries        []string `json:"countries"`
+	Genres           []string `json:"genres"`
+	Link             string   `json:"link"`
+	Duration         int      `json:"duration"`
+	IMDBRating       float64  `json:"imdb"`
+	PosterURL        string   `json:"poster_url"`
+	PosterPreviewURL string   `json:"poster_preview_url"`
+	CoverURL         string   `json:"cover_url"`
+	SuggestedBy      *int64   `json:"suggested_by"`
+}
+
+// MovieMatch is a film found by its title, the suggestion is made from its KinopoiskID.
+type MovieMatch struct {
+	KinopoiskID int64
+	Title       string
+	Year        string
+	Directors   []string
 }
 
 type KinopoiskService struct {
@@ -27,6 +38,7 @@ type KinopoiskService struct {
 type IKinopoiskService interface {
 	SearchMovies(ids []int64, suggestedBy int64) ([]MovieDTO, error)
 	ParseMovies(response *[]kinopoisk.KinopoiskMovieWithStaff, suggestedBy *int64) ([]MovieDTO, error)
+	SearchByTitle(title string, limit int) ([]MovieMatch, error)
 }
 
 func NewKinopoiskService(kinopoiskAPI kinopoisk.IKinopoiskAPI) *KinopoiskService {
@@ -70,8 +82,42 @@ func (s *KinopoiskService) ParseMovies(response *[]kinopoisk.KinopoiskMovieWithS
 		movieDto.Year = item.Movie.Year
 		movieDto.Duration = item.Movie.FilmLength
 		movieDto.IMDBRating = item.Movie.RatingImdb
+		movieDto.PosterURL = item.Movie.PosterURL
+		movieDto.PosterPreviewURL = item.Movie.PosterURLPreview
+		movieDto.CoverURL = item.Movie.CoverURL
 		movieDto.SuggestedBy = suggestedBy
 		moviesDto = append(moviesDto, movieDto)
 	}
 	return moviesDto, nil
 }
+
+// SearchByTitle returns up to limit best matches of the title with their directors.
+func (s *KinopoiskService) SearchByTitle(title string, limit int) ([]MovieMatch, error) {
+	films, err := s.kinopoiskAPI.SearchByKeyword(title)
+	if err != nil {
+		return nil, err
+	}
+	var matches []MovieMatch
+	for _, film := range *films {
+		if len(matches) == limit {
+			break
+		}
+		if film.FilmID == 0 {
+			continue
+		}
+		match := MovieMatch{KinopoiskID: film.FilmID, Title: film.NameRu, Year: film.Year}
+		if match.Title == "" {
+			match.Title = film.NameEn
+		}
+		staff, err := s.kinopoiskAPI.SearchStaff(film.FilmID)
+		if err == nil {
+			for _, person := range *staff {
+				if person.ProfessionKey == "DIRECTOR" {
+					match.Directors = append(match.Directors, person.NameRu)
+				}
+			}
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
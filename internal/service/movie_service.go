@@ -1,12 +1,18 @@
 package service
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"html"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/repository"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/stats"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/media"
 	"github.com/go-telegram/bot"
 	"github.com/goodsign/monday"
 )
@@ -18,11 +24,14 @@ const MOVIE_FORMAT = `
 <i>Рейтинг IMDb: %f.</i>
 <i>Режиссер: %s.</i>
 <i>Год: %d.</i>
-<i>Длительность в минутах: %d.</i>
+%s<i>Длительность в минутах: %d.</i>
 <i>Предложен: %s.</i>
 <i>Ссылка на кинопоиск: %s.</i>
 `
 
+// MOVIE_START_FORMAT is the start line of MOVIE_FORMAT, movies outside of a session have none.
+const MOVIE_START_FORMAT = "<i>Начало: %s.</i>\n"
+
 const ALREADY_WATCHED_MOVIES_FORMAT = `<p>
 <b>#%d: %s (%d)</b>
 <b>Режиссер(ы): %s.</b>
@@ -31,6 +40,7 @@ const ALREADY_WATCHED_MOVIES_FORMAT = `<p>
 <b>Длительность в минутах: %d.</b>
 <b>Рейтинг IMDb: %f.</b>
 <b>Рейтинг КиноКласса: %s.</b>
+<b>Оценки КиноКласса: %s.</b>
 <i>Дата просмотра: %s.</i>
 <i>Предложен: %s.</i>
 <a href=%s><i>Ссылка</i></a>
@@ -38,40 +48,97 @@ const ALREADY_WATCHED_MOVIES_FORMAT = `<p>
 
 const ALREADY_WATCHED_MOVIES_PAGE_SIZE = 50
 
+const TOP_MOVIE_FORMAT = `%d. <b>%s</b> (%d) — ⭐ %.2f, среднее: %.2f, оценок: %d`
+
 type IMovieService interface {
 	GetCurrentMovies() (*string, error)
+	GetSessionMovies(sessionID int64) (*string, error)
+	GetCurrentMovieCards() (string, []media.Card, error)
+	GetMovieCards(ids []int64) ([]media.Card, error)
+	GetLineup(sessionID int64) ([]LineupSlot, error)
 	GetAlreadyWatchedMovies() ([]string, error)
 	GetSuggestedOrWatchedMovies(suggested bool) ([][]string, error)
 	GetMovieByID(id int64) (*model.Movie, error)
+	GetTopRatedMovies(limit int) (*string, error)
 	Create(movie *MovieDTO, suggestedBy int64) error
 	Upsert(movie *MovieDTO, suggestedBy int64) error
+	GetSuggestions(suggestedBy int64) ([]*model.Movie, error)
+	ArchiveSuggestion(movieID int64) (*model.Movie, error)
+	FindMovies(query string, offset int, limit int) ([]*model.Movie, error)
 	generateHTMLForWatchedMovies(movies []*model.Movie) []string
 }
 
+var (
+	ErrMovieNotSuggested   = errors.New("movie is not suggested")
+	ErrMovieInActiveVoting = errors.New("movie is in an active selection voting")
+)
+
 type MovieService struct {
 	repo        repository.IMovieRepo
 	sessionRepo repository.ISessionRepo
+	lineupBreak time.Duration
+}
+
+// LineupSlot is a movie of the session with the time it starts and ends.
+type LineupSlot struct {
+	Movie *model.Movie
+	Start time.Time
+	End   time.Time
+}
+
+func NewMovieService(repo repository.IMovieRepo, sessionRepo repository.ISessionRepo, lineupBreak time.Duration) *MovieService {
+	return &MovieService{repo: repo, sessionRepo: sessionRepo, lineupBreak: lineupBreak}
 }
 
-func NewMovieService(repo repository.IMovieRepo, sessionRepo repository.ISessionRepo) *MovieService {
-	return &MovieService{repo: repo, sessionRepo: sessionRepo}
+// Lineup computes the start of every movie of the session: the first one starts with the session,
+// every next one after the previous movie and the break.
+func Lineup(session *model.Session, lineupBreak time.Duration) []LineupSlot {
+	slots := make([]LineupSlot, 0, len(session.Movies))
+	start := time.Unix(session.FinishedAt, 0)
+	for i := range session.Movies {
+		movie := &session.Movies[i]
+		end := start.Add(time.Duration(movie.Duration) * time.Minute)
+		slots = append(slots, LineupSlot{Movie: movie, Start: start, End: end})
+		start = end.Add(lineupBreak)
+	}
+	return slots
+}
+
+// LineupEnd returns when the last movie of the lineup ends, a session without movies ends when it starts.
+func LineupEnd(start time.Time, lineup []LineupSlot) time.Time {
+	if len(lineup) == 0 || !lineup[len(lineup)-1].End.After(start) {
+		return start
+	}
+	return lineup[len(lineup)-1].End
+}
+
+// GetLineup returns the movies of the session in the running order with their start times.
+func (s *MovieService) GetLineup(sessionID int64) ([]LineupSlot, error) {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return Lineup(session, s.lineupBreak), nil
 }
 
 func (s *MovieService) Upsert(movie *MovieDTO, suggestedBy int64) error {
 	suggestedAt := time.Now().Unix()
 	newMovie := model.Movie{
-		ID:          movie.KinopoiskID,
-		Title:       movie.Title,
-		Description: movie.Description,
-		Directors:   strings.Join(movie.Directors, ", "),
-		Year:        movie.Year,
-		Countries:   strings.Join(movie.Countries, ", "),
-		Genres:      strings.Join(movie.Genres, ", "),
-		Link:        movie.Link,
-		Duration:    movie.Duration,
-		IMDBRating:  movie.IMDBRating,
-		SuggestedBy: &suggestedBy,
-		SuggestedAt: &suggestedAt,
+		ID:               movie.KinopoiskID,
+		Title:            movie.Title,
+		Description:      movie.Description,
+		Directors:        strings.Join(movie.Directors, ", "),
+		Year:             movie.Year,
+		Countries:        strings.Join(movie.Countries, ", "),
+		Genres:           strings.Join(movie.Genres, ", "),
+		Link:             movie.Link,
+		Duration:         movie.Duration,
+		IMDBRating:       movie.IMDBRating,
+		PosterURL:        movie.PosterURL,
+		PosterPreviewURL: movie.PosterPreviewURL,
+		CoverURL:         movie.CoverURL,
+		SuggestedBy:      &suggestedBy,
+		SuggestedAt:      &suggestedAt,
 	}
 	return s.repo.Upsert(&newMovie)
 }
@@ -79,18 +146,21 @@ func (s *MovieService) Upsert(movie *MovieDTO, suggestedBy int64) error {
 func (s *MovieService) Create(movie *MovieDTO, suggestedBy int64) error {
 	suggestedAt := time.Now().Unix()
 	newMovie := model.Movie{
-		ID:          movie.KinopoiskID,
-		Title:       movie.Title,
-		Description: movie.Description,
-		Directors:   strings.Join(movie.Directors, ", "),
-		Year:        movie.Year,
-		Countries:   strings.Join(movie.Countries, ", "),
-		Genres:      strings.Join(movie.Genres, ", "),
-		Link:        movie.Link,
-		Duration:    movie.Duration,
-		IMDBRating:  movie.IMDBRating,
-		SuggestedBy: &suggestedBy,
-		SuggestedAt: &suggestedAt,
+		ID:               movie.KinopoiskID,
+		Title:            movie.Title,
+		Description:      movie.Description,
+		Directors:        strings.Join(movie.Directors, ", "),
+		Year:             movie.Year,
+		Countries:        strings.Join(movie.Countries, ", "),
+		Genres:           strings.Join(movie.Genres, ", "),
+		Link:             movie.Link,
+		Duration:         movie.Duration,
+		IMDBRating:       movie.IMDBRating,
+		PosterURL:        movie.PosterURL,
+		PosterPreviewURL: movie.PosterPreviewURL,
+		CoverURL:         movie.CoverURL,
+		SuggestedBy:      &suggestedBy,
+		SuggestedAt:      &suggestedAt,
 	}
 	return s.repo.Create(&newMovie)
 }
@@ -104,15 +174,104 @@ func (s *MovieService) GetMovieByID(id int64) (*model.Movie, error) {
 }
 
 func (s *MovieService) GetCurrentMovies() (*string, error) {
-	session, err := s.sessionRepo.FindOngoingSession()
+	session, err := s.sessionRepo.FindNextSession()
 	if err != nil {
 		return nil, err
 	}
 	if session == nil {
 		return nil, fmt.Errorf("no ongoing session found")
 	}
+	return formatSessionMovies(session, s.lineupBreak)
+}
+
+// GetSessionMovies formats the lineup of the given session the same way as GetCurrentMovies.
+func (s *MovieService) GetSessionMovies(sessionID int64) (*string, error) {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return formatSessionMovies(session, s.lineupBreak)
+}
+
+func formatSessionMovies(session *model.Session, lineupBreak time.Duration) (*string, error) {
+	header, cards, err := sessionCards(session, lineupBreak)
+	if err != nil {
+		return nil, err
+	}
+	formattedMovies := make([]string, 0, len(cards)+1)
+	formattedMovies = append(formattedMovies, header)
+	for _, card := range cards {
+		formattedMovies = append(formattedMovies, card.Caption)
+	}
+	result := strings.Join(formattedMovies, "\n")
+	return &result, nil
+}
+
+// GetCurrentMovieCards returns the header of the next session and a card with the poster of every movie in it.
+func (s *MovieService) GetCurrentMovieCards() (string, []media.Card, error) {
+	session, err := s.sessionRepo.FindNextSession()
+	if err != nil {
+		return "", nil, err
+	}
+	if session == nil {
+		return "", nil, fmt.Errorf("no ongoing session found")
+	}
+	return sessionCards(session, s.lineupBreak)
+}
+
+// GetMovieCards returns the cards of the movies in the given order, the start line is left out.
+func (s *MovieService) GetMovieCards(ids []int64) ([]media.Card, error) {
+	cards := make([]media.Card, 0, len(ids))
+	for i, id := range ids {
+		movie, err := s.repo.GetMovieByID(id)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, MovieCard(i+1, movie, ""))
+	}
+	return cards, nil
+}
+
+// MovieCard is the MOVIE_FORMAT caption of the movie under its poster.
+func MovieCard(index int, movie *model.Movie, start string) media.Card {
+	return media.Card{PhotoURL: MoviePoster(movie), Caption: FormatMovie(index, movie, start)}
+}
+
+// MoviePoster returns the poster of the movie, the preview is used for the movies saved without the full one.
+func MoviePoster(movie *model.Movie) string {
+	if movie.PosterURL != "" {
+		return movie.PosterURL
+	}
+	return movie.PosterPreviewURL
+}
+
+// FormatMovie formats the movie with MOVIE_FORMAT, an empty start leaves the start line out.
+func FormatMovie(index int, movie *model.Movie, start string) string {
+	var suggestedBy string = "Неизвестно"
+	if movie.Suggester != nil {
+		suggestedBy = fmt.Sprintf("%s %s", movie.Suggester.FirstName, movie.Suggester.LastName)
+	}
+	if start != "" {
+		start = fmt.Sprintf(MOVIE_START_FORMAT, start)
+	}
+	return fmt.Sprintf(MOVIE_FORMAT,
+		index,
+		html.EscapeString(movie.Title),
+		html.EscapeString(movie.Genres),
+		html.EscapeString(movie.Countries),
+		movie.IMDBRating,
+		html.EscapeString(movie.Directors),
+		movie.Year,
+		start,
+		movie.Duration,
+		html.EscapeString(suggestedBy),
+		movie.Link,
+	)
+}
+
+func sessionCards(session *model.Session, lineupBreak time.Duration) (string, []media.Card, error) {
 	if len(session.Movies) == 0 {
-		return nil, fmt.Errorf("no current movies found")
+		return "", nil, fmt.Errorf("no current movies found")
 	}
 	finishedAt := time.Unix(session.FinishedAt, 0)
 	month := monday.Format(finishedAt, "January", monday.LocaleRuRU)
@@ -122,41 +281,19 @@ func (s *MovieService) GetCurrentMovies() (*string, error) {
 	dateStr := finishedAt.Format("02.01.2006")
 	timeStr := finishedAt.Format("15:04")
 	schedule := fmt.Sprintf("%s | %s 🗓️\n%s | %s 🕤", month, dateStr, day, timeStr)
-	movies := session.Movies
-	var offset int
-	var formattedMovies []string
+	if venue := FormatVenue(session.Venue); venue != "" {
+		schedule += "\n" + html.EscapeString(venue)
+	}
+	header := []string{schedule, "<b>#смотрим</b>"}
 	if session.Description != "" {
-		offset = 3
-		formattedMovies = make([]string, len(movies)+offset)
-		formattedMovies[0] = session.Description
-		formattedMovies[1] = schedule
-		formattedMovies[2] = "<b>#смотрим</b>"
-	} else {
-		offset = 2
-		formattedMovies = make([]string, len(movies)+offset)
-		formattedMovies[0] = schedule
-		formattedMovies[1] = "<b>#смотрим</b>"
+		header = append([]string{session.Description}, header...)
 	}
-	for i, movie := range movies {
-		var suggestedBy string = "Неизвестно"
-		if movie.Suggester != nil {
-			suggestedBy = fmt.Sprintf("%s %s", movie.Suggester.FirstName, movie.Suggester.LastName)
-		}
-		formattedMovies[i+offset] = fmt.Sprintf(MOVIE_FORMAT,
-			i+1,
-			movie.Title,
-			movie.Genres,
-			movie.Countries,
-			movie.IMDBRating,
-			movie.Directors,
-			movie.Year,
-			movie.Duration,
-			suggestedBy,
-			movie.Link,
-		)
+	lineup := Lineup(session, lineupBreak)
+	cards := make([]media.Card, len(session.Movies))
+	for i := range session.Movies {
+		cards[i] = MovieCard(i+1, &session.Movies[i], lineup[i].Start.Format("15:04"))
 	}
-	result := strings.Join(formattedMovies, "\n")
-	return &result, nil
+	return strings.Join(header, "\n"), cards, nil
 }
 
 func (s *MovieService) GetAlreadyWatchedMovies() ([]string, error) {
@@ -194,13 +331,96 @@ func (s *MovieService) GetSuggestedOrWatchedMovies(suggested bool) ([][]string,
 	return list, nil
 }
 
+// GetSuggestions returns the suggested movies of the member, 0 returns the ones of every member; the newest come first.
+func (s *MovieService) GetSuggestions(suggestedBy int64) ([]*model.Movie, error) {
+	if suggestedBy != 0 {
+		return s.repo.FindSuggestionsBy(suggestedBy)
+	}
+	movies, err := s.repo.GetSuggestedMovies()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(movies, func(a, b *model.Movie) int {
+		var x, y int64
+		if a.SuggestedAt != nil {
+			x = *a.SuggestedAt
+		}
+		if b.SuggestedAt != nil {
+			y = *b.SuggestedAt
+		}
+		return cmp.Compare(y, x)
+	})
+	return movies, nil
+}
+
+// ArchiveSuggestion takes the movie out of the suggestions, movies of open selection votings stay.
+func (s *MovieService) ArchiveSuggestion(movieID int64) (*model.Movie, error) {
+	movie, err := s.repo.GetMovieByID(movieID)
+	if err != nil {
+		return nil, err
+	}
+	if movie.Status != model.MOVIE_SUGGESTED_STATUS {
+		return movie, ErrMovieNotSuggested
+	}
+	active, err := s.repo.IsInActiveSelection(movieID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return movie, ErrMovieInActiveVoting
+	}
+	if err := s.repo.UpdateStatus(movieID, model.MOVIE_ARCHIVED_STATUS); err != nil {
+		return nil, err
+	}
+	movie.Status = model.MOVIE_ARCHIVED_STATUS
+	return movie, nil
+}
+
+// FindMovies searches the club movies by title, director or year.
+func (s *MovieService) FindMovies(query string, offset int, limit int) ([]*model.Movie, error) {
+	return s.repo.Search(&repository.SearchMoviesParams{Query: strings.TrimSpace(query), Offset: offset, Limit: limit})
+}
+
+// FormatMovieCard is the HTML card of the movie shared from the inline mode.
+func FormatMovieCard(movie *model.Movie) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🎬 <b>%s</b> (%d)", html.EscapeString(movie.Title), movie.Year))
+	if movie.Directors != "" {
+		sb.WriteString(fmt.Sprintf("\n🎥 Режиссер: %s", html.EscapeString(movie.Directors)))
+	}
+	status := "в предложке"
+	if movie.Status == model.MOVIE_WATCHED_STATUS {
+		status = "просмотрен"
+	}
+	sb.WriteString("\n📌 Статус: " + status)
+	if movie.RatingCount > 0 {
+		sb.WriteString(fmt.Sprintf("\n⭐ Рейтинг КиноКласса: %.1f (оценок: %d)", movie.Rating, movie.RatingCount))
+	} else if movie.Rating != 0 {
+		sb.WriteString(fmt.Sprintf("\n⭐ Рейтинг КиноКласса: %.1f", movie.Rating))
+	}
+	if movie.FinishedAt != nil {
+		if tm, err := time.Parse("2006-01-02 15:04:05", *movie.FinishedAt); err == nil {
+			sb.WriteString("\n📅 Просмотрен: " + monday.Format(tm, "02 January 2006", monday.LocaleRuRU))
+		}
+	}
+	if movie.Link != "" {
+		sb.WriteString("\n🔗 " + html.EscapeString(movie.Link))
+	}
+	return sb.String()
+}
+
 func (s *MovieService) generateHTMLForWatchedMovies(movies []*model.Movie) []string {
 	var pages []string
 	var html strings.Builder
 
 	for i, movie := range movies {
 		var rating string = "N/A"
-		if movie.Rating != 0 {
+		var ratingStats string = "N/A"
+		if movie.RatingCount != 0 {
+			rating = fmt.Sprintf("%.1f (скорректированный: %.1f)", movie.Rating, movie.AdjustedRating)
+			ratingStats = formatRatingStats(movie.RatingSummaries)
+		} else if movie.Rating != 0 {
+			// Rated before the rating summaries, only the mean is known
 			rating = fmt.Sprintf("%.1f", movie.Rating)
 		}
 		var suggestedBy string = "Неизвестно"
@@ -216,7 +436,7 @@ func (s *MovieService) generateHTMLForWatchedMovies(movies []*model.Movie) []str
 				finishedAt = monday.Format(tm, "02 January 2006", monday.LocaleRuRU)
 			}
 		}
-		html.WriteString(fmt.Sprintf(ALREADY_WATCHED_MOVIES_FORMAT, i+1, movie.Title, movie.Year, movie.Directors, movie.Countries, movie.Genres, movie.Duration, movie.IMDBRating, rating, finishedAt, suggestedBy, movie.Link))
+		html.WriteString(fmt.Sprintf(ALREADY_WATCHED_MOVIES_FORMAT, i+1, movie.Title, movie.Year, movie.Directors, movie.Countries, movie.Genres, movie.Duration, movie.IMDBRating, rating, ratingStats, finishedAt, suggestedBy, movie.Link))
 
 		if (i+1)%ALREADY_WATCHED_MOVIES_PAGE_SIZE == 0 || i == len(movies)-1 {
 			pages = append(pages, html.String())
@@ -225,3 +445,38 @@ func (s *MovieService) generateHTMLForWatchedMovies(movies []*model.Movie) []str
 	}
 	return pages
 }
+
+func (s *MovieService) GetTopRatedMovies(limit int) (*string, error) {
+	movies, err := s.repo.GetTopRatedMovies(limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(movies) == 0 {
+		return nil, fmt.Errorf("no rated movies found")
+	}
+	lines := make([]string, len(movies))
+	for i, movie := range movies {
+		lines[i] = fmt.Sprintf(TOP_MOVIE_FORMAT, i+1, movie.Title, movie.Year, movie.RankingScore(), movie.Rating, movie.RatingCount)
+	}
+	result := strings.Join(lines, "\n")
+	return &result, nil
+}
+
+// formatRatingStats describes all the ratings of the movie from its summaries:
+// count, median, standard deviation and the number of votes for every rating.
+func formatRatingStats(summaries []model.RatingSummary) string {
+	var ratings []int
+	for _, summary := range summaries {
+		ratings = append(ratings, stats.Expand(summary.Histogram)...)
+	}
+	if len(ratings) == 0 {
+		return "N/A"
+	}
+	histogram := stats.Histogram(ratings, model.MAX_RATING)
+	counts := make([]string, len(histogram))
+	for i, count := range histogram {
+		counts[i] = fmt.Sprintf("%d: %d", i+1, count)
+	}
+	return fmt.Sprintf("голосов %d, медиана %.1f, отклонение %.2f, голосований %d (%s)",
+		len(ratings), stats.Median(ratings), stats.StdDev(ratings), len(summaries), strings.Join(counts, ", "))
+}
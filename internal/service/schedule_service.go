@@ -1,17 +1,35 @@
 package service
 
 import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/repository"
-	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/date"
 )
 
 type IScheduleService interface {
 	GetActiveSchedule() (*model.Schedule, error)
 	ReplaceSchedule(schedule *model.Schedule) (*model.Schedule, error)
+	SetVenue(venue model.Venue) error
 	GetNextScheduledTime() (int64, error)
+	GetScheduledTimeAfter(after int64) (int64, error)
+	GetOccurrences(after int64, count int) ([]int64, error)
+	GetExceptions() ([]*model.ScheduleException, error)
+	ToggleException(date time.Time) (bool, error)
 }
 
+var ErrNoActiveSchedule = errors.New("no active schedule")
+
+// SCHEDULE_EXCEPTION_FORMAT is the format of the skipped dates.
+const SCHEDULE_EXCEPTION_FORMAT = "2006-01-02"
+
+// occurrenceSearchWeeks bounds the search of occurrences when every date is skipped.
+const occurrenceSearchWeeks = 53
+
 type ScheduleService struct {
 	repo repository.IScheduleRepository
 }
@@ -20,24 +38,152 @@ func NewScheduleService(scheduleRepo repository.IScheduleRepository) IScheduleSe
 	return &ScheduleService{repo: scheduleRepo}
 }
 
+// ReplaceSchedule makes the schedule active, the venue of the previous one is kept when it has none.
 func (s *ScheduleService) ReplaceSchedule(schedule *model.Schedule) (*model.Schedule, error) {
+	active, err := s.repo.FindActive()
+	if err == nil && active.ID != 0 && schedule.Venue == (model.Venue{}) {
+		schedule.Venue = active.Venue
+	}
 	return s.repo.Replace(schedule)
 }
 
+// SetVenue changes the default venue of new sessions.
+func (s *ScheduleService) SetVenue(venue model.Venue) error {
+	schedule, err := s.repo.FindActive()
+	if err != nil {
+		return err
+	}
+	if schedule.ID == 0 {
+		return ErrNoActiveSchedule
+	}
+	schedule.Venue = venue
+	return s.repo.Update(schedule)
+}
+
 func (s *ScheduleService) GetActiveSchedule() (*model.Schedule, error) {
 	return s.repo.FindActive()
 }
 
+// GetNextScheduledTime returns the next occurrence of the schedule, 0 when there is none.
 func (s *ScheduleService) GetNextScheduledTime() (int64, error) {
-	schedule, err := s.repo.FindActive()
-	if err != nil || schedule == nil {
+	return s.GetScheduledTimeAfter(time.Now().Unix())
+}
+
+// GetScheduledTimeAfter returns the first occurrence of the schedule later than the given time and now.
+func (s *ScheduleService) GetScheduledTimeAfter(after int64) (int64, error) {
+	occurrences, err := s.GetOccurrences(max(after, time.Now().Unix()), 1)
+	if err != nil || len(occurrences) == 0 {
 		return 0, err
 	}
+	return occurrences[0], nil
+}
+
+// GetOccurrences returns up to count occurrences of the active schedule later than the given time, skipped dates are left out.
+func (s *ScheduleService) GetOccurrences(after int64, count int) ([]int64, error) {
+	schedule, err := s.repo.FindActive()
+	if err != nil || schedule == nil || schedule.ID == 0 {
+		return nil, err
+	}
+	location := ScheduleLocation(schedule)
+	from := time.Unix(after, 0).In(location)
+	exceptions, err := s.repo.FindExceptions(from.Format(SCHEDULE_EXCEPTION_FORMAT))
+	if err != nil {
+		return nil, err
+	}
+	skipped := make(map[string]bool, len(exceptions))
+	for _, exception := range exceptions {
+		skipped[exception.Date] = true
+	}
+	weekdays := make(map[time.Weekday]bool)
+	for _, weekday := range ScheduleWeekdays(schedule) {
+		weekdays[time.Weekday(weekday%7)] = true
+	}
+	interval := max(schedule.Interval, 1)
+	anchor := from
+	if schedule.StartsAt > 0 {
+		anchor = time.Unix(schedule.StartsAt, 0).In(location)
+	}
+	anchorWeek := weekStart(anchor)
+
+	var occurrences []int64
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, location)
+	for i := 0; i < occurrenceSearchWeeks*interval*7 && len(occurrences) < count; i++ {
+		date := day.AddDate(0, 0, i)
+		if !weekdays[date.Weekday()] || skipped[date.Format(SCHEDULE_EXCEPTION_FORMAT)] {
+			continue
+		}
+		weeks := daysBetween(anchorWeek, weekStart(date)) / 7
+		if ((weeks%interval)+interval)%interval != 0 {
+			continue
+		}
+		occurrence := time.Date(date.Year(), date.Month(), date.Day(), schedule.Hour, schedule.Minute, 0, 0, location)
+		if occurrence.Unix() <= after {
+			continue
+		}
+		occurrences = append(occurrences, occurrence.Unix())
+	}
+	return occurrences, nil
+}
+
+// GetExceptions returns the skipped dates from today on.
+func (s *ScheduleService) GetExceptions() ([]*model.ScheduleException, error) {
+	location := time.UTC
+	if schedule, err := s.repo.FindActive(); err == nil && schedule.ID != 0 {
+		location = ScheduleLocation(schedule)
+	}
+	return s.repo.FindExceptions(time.Now().In(location).Format(SCHEDULE_EXCEPTION_FORMAT))
+}
+
+// ToggleException skips the date or brings it back, it reports whether the date is skipped now.
+func (s *ScheduleService) ToggleException(date time.Time) (bool, error) {
+	day := date.Format(SCHEDULE_EXCEPTION_FORMAT)
+	deleted, err := s.repo.DeleteException(day)
+	if err != nil || deleted {
+		return false, err
+	}
+	return true, s.repo.CreateException(&model.ScheduleException{Date: day})
+}
+
+// ScheduleWeekdays returns the weekdays of the schedule, 1=Monday, ..., 7=Sunday.
+func ScheduleWeekdays(schedule *model.Schedule) []int {
+	var weekdays []int
+	for part := range strings.SplitSeq(schedule.Weekdays, ",") {
+		weekday, err := strconv.Atoi(strings.TrimSpace(part))
+		if err == nil && weekday >= 0 && weekday <= 7 {
+			if weekday == 0 {
+				weekday = 7
+			}
+			weekdays = append(weekdays, weekday)
+		}
+	}
+	if len(weekdays) == 0 {
+		weekday := schedule.Weekday
+		if weekday == 0 {
+			weekday = 7
+		}
+		weekdays = append(weekdays, weekday)
+	}
+	return weekdays
+}
+
+func ScheduleLocation(schedule *model.Schedule) *time.Location {
+	location, err := time.LoadLocation(schedule.Location)
+	if err != nil {
+		log.Printf("Error loading location: %v", err)
+		return time.FixedZone("UTC+3", 3*60*60)
+	}
+	return location
+}
+
+// weekStart returns the Monday of the week of the time.
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
 
-	return date.GetRelativeDate(&date.GetRelativeDateParams{
-		Day:      &schedule.Weekday,
-		Minute:   &schedule.Minute,
-		Hour:     &schedule.Hour,
-		Location: &schedule.Location,
-	}), nil
+// daysBetween counts calendar days, the DST shifts of the location don't matter.
+func daysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
 }
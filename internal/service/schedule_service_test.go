@@ -0,0 +1,154 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/repository"
+)
+
+// fakeScheduleRepo keeps the active schedule and the skipped dates in memory.
+type fakeScheduleRepo struct {
+	repository.IScheduleRepository
+	schedule   *model.Schedule
+	exceptions []string
+}
+
+func (r *fakeScheduleRepo) FindActive() (*model.Schedule, error) {
+	if r.schedule == nil {
+		return &model.Schedule{}, nil
+	}
+	return r.schedule, nil
+}
+
+func (r *fakeScheduleRepo) FindExceptions(from string) ([]*model.ScheduleException, error) {
+	var exceptions []*model.ScheduleException
+	for _, date := range r.exceptions {
+		if date >= from {
+			exceptions = append(exceptions, &model.ScheduleException{Date: date})
+		}
+	}
+	return exceptions, nil
+}
+
+func utc(day int, hour int, minute int) int64 {
+	return time.Date(2026, time.January, day, hour, minute, 0, 0, time.UTC).Unix()
+}
+
+func TestGetOccurrences(t *testing.T) {
+	// 5 January 2026 is a Monday
+	monday := utc(5, 0, 0)
+	tests := []struct {
+		name       string
+		schedule   *model.Schedule
+		exceptions []string
+		after      int64
+		count      int
+		want       []int64
+	}{
+		{
+			name:  "no active schedule",
+			after: monday,
+			count: 3,
+			want:  nil,
+		},
+		{
+			name:     "weekly",
+			schedule: &model.Schedule{ID: 1, Weekday: 1, Interval: 1, Hour: 20, Location: "UTC"},
+			after:    monday,
+			count:    3,
+			want:     []int64{utc(5, 20, 0), utc(12, 20, 0), utc(19, 20, 0)},
+		},
+		{
+			name:     "occurrence at the given time is left out",
+			schedule: &model.Schedule{ID: 1, Weekday: 1, Interval: 1, Hour: 20, Location: "UTC"},
+			after:    utc(5, 20, 0),
+			count:    2,
+			want:     []int64{utc(12, 20, 0), utc(19, 20, 0)},
+		},
+		{
+			name:     "several weekdays",
+			schedule: &model.Schedule{ID: 1, Weekday: 3, Weekdays: "3,6", Interval: 1, Hour: 19, Minute: 30, Location: "UTC"},
+			after:    monday,
+			count:    3,
+			want:     []int64{utc(7, 19, 30), utc(10, 19, 30), utc(14, 19, 30)},
+		},
+		{
+			name:     "sunday stored as zero",
+			schedule: &model.Schedule{ID: 1, Weekday: 0, Interval: 1, Hour: 18, Location: "UTC"},
+			after:    monday,
+			count:    1,
+			want:     []int64{utc(11, 18, 0)},
+		},
+		{
+			name:     "biweekly counts weeks from the start",
+			schedule: &model.Schedule{ID: 1, Weekday: 1, Interval: 2, StartsAt: utc(12, 20, 0), Hour: 20, Location: "UTC"},
+			after:    monday,
+			count:    3,
+			want:     []int64{utc(12, 20, 0), utc(26, 20, 0), time.Date(2026, time.February, 9, 20, 0, 0, 0, time.UTC).Unix()},
+		},
+		{
+			name:       "skipped dates",
+			schedule:   &model.Schedule{ID: 1, Weekday: 1, Interval: 1, Hour: 20, Location: "UTC"},
+			exceptions: []string{"2026-01-12", "2026-01-01"},
+			after:      monday,
+			count:      3,
+			want:       []int64{utc(5, 20, 0), utc(19, 20, 0), utc(26, 20, 0)},
+		},
+		{
+			name:       "every date skipped",
+			schedule:   &model.Schedule{ID: 1, Weekday: 1, Interval: 1, Hour: 20, Location: "UTC"},
+			exceptions: weeklyDates(time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC), occurrenceSearchWeeks+1),
+			after:      monday,
+			count:      1,
+			want:       nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduleService(&fakeScheduleRepo{schedule: tt.schedule, exceptions: tt.exceptions})
+			got, err := s.GetOccurrences(tt.after, tt.count)
+			if err != nil {
+				t.Fatalf("GetOccurrences() err = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetOccurrences() = %v, want %v", formatUnix(got), formatUnix(tt.want))
+			}
+		})
+	}
+}
+
+func TestScheduleWeekdays(t *testing.T) {
+	tests := []struct {
+		schedule *model.Schedule
+		want     []int
+	}{
+		{schedule: &model.Schedule{Weekday: 3}, want: []int{3}},
+		{schedule: &model.Schedule{Weekday: 0}, want: []int{7}},
+		{schedule: &model.Schedule{Weekday: 3, Weekdays: "1, 4,0"}, want: []int{1, 4, 7}},
+		{schedule: &model.Schedule{Weekday: 2, Weekdays: "9,x"}, want: []int{2}},
+	}
+	for _, tt := range tests {
+		if got := ScheduleWeekdays(tt.schedule); !slices.Equal(got, tt.want) {
+			t.Errorf("ScheduleWeekdays(%q, %d) = %v, want %v", tt.schedule.Weekdays, tt.schedule.Weekday, got, tt.want)
+		}
+	}
+}
+
+func weeklyDates(from time.Time, weeks int) []string {
+	dates := make([]string, 0, weeks)
+	for i := 0; i < weeks; i++ {
+		dates = append(dates, from.AddDate(0, 0, 7*i).Format(SCHEDULE_EXCEPTION_FORMAT))
+	}
+	return dates
+}
+
+func formatUnix(times []int64) []string {
+	formatted := make([]string, 0, len(times))
+	for _, t := range times {
+		formatted = append(formatted, time.Unix(t, 0).UTC().Format(time.DateTime))
+	}
+	return formatted
+}
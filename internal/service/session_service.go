@@ -3,6 +3,8 @@ package service
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
@@ -12,23 +14,45 @@ import (
 
 type ISessionService interface {
 	FinishSession(sessionID int64) error
-	CancelSession() (*model.Session, []*model.Voting, error)
-	AddMoviesToSession(createdBy int64, movieIDs []int64) (*model.Session, []int64, bool, error)
-	FindOngoingSession() (*model.Session, error)
+	CancelSession(sessionID int64) (*model.Session, []*model.Voting, error)
+	FindCancelledSessionsSince(since int64) ([]*model.Session, error)
+	RestoreSession(sessionID int64, window time.Duration) (*model.Session, []*model.Voting, error)
+	AddMoviesToSession(createdBy int64, sessionID *int64, movieIDs []int64) (*model.Session, []int64, bool, error)
+	FindNextSession() (*model.Session, error)
+	FindUpcomingSessions() ([]*model.Session, error)
+	FindSessionByID(sessionID int64) (*model.Session, error)
 	RescheduleSession(sessionID int64, finishedAt int64) error
 	RemoveMoviesFromSession(movieIDs []int64, sessionID int64) ([]*model.Voting, error)
 	UpdateSessionDescription(sessionID int64, description string) error
+	SetRatingWindow(sessionID int64, window time.Duration) error
+	SetVenue(sessionID int64, venue model.Venue) error
+	SetRsvpMessageID(sessionID int64, messageID int) error
+	GetSessionHistory(page int, perPage int) ([]*SessionHistoryEntry, int64, error)
+	GetSessionHistoryEntry(sessionID int64) (*SessionHistoryEntry, error)
+	MoveMovie(sessionID int64, movieID int64, up bool) (*model.Session, bool, error)
 }
 
+// SessionHistoryEntry is a past session with the club ratings of its movies and the selection votings
+// that brought the movies to it.
+type SessionHistoryEntry struct {
+	Session          *model.Session
+	Ratings          map[int64]*model.RatingSummary // by movie ID
+	SelectionVotings []*model.Voting
+}
+
+var ErrSessionPassed = errors.New("session has already taken place")
+var ErrRestoreWindowPassed = errors.New("session was cancelled too long ago")
+
 type SessionService struct {
-	repo            repository.ISessionRepo
-	movieRepo       repository.IMovieRepo
-	votingRepo      repository.IVotingRepo
-	scheduleService IScheduleService
+	repo              repository.ISessionRepo
+	movieRepo         repository.IMovieRepo
+	votingRepo        repository.IVotingRepo
+	ratingSummaryRepo repository.IRatingSummaryRepo
+	scheduleService   IScheduleService
 }
 
-func NewSessionService(repo repository.ISessionRepo, movieRepo repository.IMovieRepo, votingRepo repository.IVotingRepo, scheduleService IScheduleService) ISessionService {
-	return &SessionService{repo: repo, movieRepo: movieRepo, votingRepo: votingRepo, scheduleService: scheduleService}
+func NewSessionService(repo repository.ISessionRepo, movieRepo repository.IMovieRepo, votingRepo repository.IVotingRepo, ratingSummaryRepo repository.IRatingSummaryRepo, scheduleService IScheduleService) ISessionService {
+	return &SessionService{repo: repo, movieRepo: movieRepo, votingRepo: votingRepo, ratingSummaryRepo: ratingSummaryRepo, scheduleService: scheduleService}
 }
 
 func (s *SessionService) RemoveMoviesFromSession(movieIDs []int64, sessionID int64) ([]*model.Voting, error) {
@@ -42,8 +66,10 @@ func (s *SessionService) RemoveMoviesFromSession(movieIDs []int64, sessionID int
 		if err != nil {
 			return err
 		}
+		// Only the rating votings of the removed movies, the session may have a selection voting going on
 		votings, err = s.votingRepo.CancelVotingsBySessionID(&repository.CancelVotingsBySessionIDParams{
 			SessionID: sessionID,
+			MovieIDs:  movieIDs,
 			Tx:        tx,
 		})
 		if err != nil {
@@ -61,18 +87,20 @@ func (s *SessionService) RescheduleSession(sessionID int64, finishedAt int64) er
 	return s.repo.RescheduleSession(sessionID, finishedAt)
 }
 
-func (s *SessionService) CancelSession() (*model.Session, []*model.Voting, error) {
+func (s *SessionService) CancelSession(sessionID int64) (*model.Session, []*model.Voting, error) {
 	var session *model.Session
 	var votings []*model.Voting
 	var err error
+	cancelledAt := time.Now().Unix()
 	err = s.repo.Transaction(func(tx *gorm.DB) error {
-		session, err = s.repo.CancelSession(tx)
+		session, err = s.repo.CancelSession(&repository.CancelSessionParams{SessionID: sessionID, CancelledAt: cancelledAt, Tx: tx})
 		if err != nil {
 			return err
 		}
 		votings, err = s.votingRepo.CancelVotingsBySessionID(&repository.CancelVotingsBySessionIDParams{
-			SessionID: session.ID,
-			Tx:        tx,
+			SessionID:  session.ID,
+			FinishedAt: cancelledAt,
+			Tx:         tx,
 		})
 		if err != nil {
 			return err
@@ -85,12 +113,116 @@ func (s *SessionService) CancelSession() (*model.Session, []*model.Voting, error
 	return session, votings, nil
 }
 
-func (s *SessionService) FindOngoingSession() (*model.Session, error) {
-	return s.repo.FindOngoingSession()
+func (s *SessionService) FindCancelledSessionsSince(since int64) ([]*model.Session, error) {
+	return s.repo.FindCancelledSessions(&repository.FindCancelledSessionsParams{Since: since})
 }
 
-func (s *SessionService) FinishSession(sessionID int64) error {
+// RestoreSession undoes the cancel of the session and returns it with the votings cancelled together with it.
+// Sessions whose time has already passed or that were cancelled more than window ago can't be restored.
+func (s *SessionService) RestoreSession(sessionID int64, window time.Duration) (*model.Session, []*model.Voting, error) {
+	var session *model.Session
+	var votings []*model.Voting
 	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		cancelled, err := s.repo.FindCancelledSession(&repository.FindCancelledSessionParams{SessionID: sessionID, Tx: tx})
+		if err != nil {
+			return err
+		}
+		if cancelled.FinishedAt <= time.Now().Unix() {
+			return ErrSessionPassed
+		}
+		// The keyboard of /restore_session may be tapped long after it was sent
+		if cancelled.CancelledAt != nil && time.Since(time.Unix(*cancelled.CancelledAt, 0)) > window {
+			return ErrRestoreWindowPassed
+		}
+		if cancelled.CancelledAt != nil {
+			votings, err = s.votingRepo.FindCancelledWithSession(&repository.FindCancelledWithSessionParams{
+				SessionID:   cancelled.ID,
+				CancelledAt: *cancelled.CancelledAt,
+				Tx:          tx,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		session, err = s.repo.RestoreSession(&repository.RestoreSessionParams{SessionID: sessionID, Tx: tx})
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, votings, nil
+}
+
+// FindNextSession returns the earliest upcoming session.
+func (s *SessionService) FindNextSession() (*model.Session, error) {
+	return s.repo.FindNextSession()
+}
+
+func (s *SessionService) FindUpcomingSessions() ([]*model.Session, error) {
+	return s.repo.FindUpcomingSessions(&repository.FindUpcomingSessionsParams{})
+}
+
+func (s *SessionService) FindSessionByID(sessionID int64) (*model.Session, error) {
+	return s.repo.FindByID(sessionID)
+}
+
+// GetSessionHistory returns a page of past sessions, the latest first, and the number of past sessions.
+func (s *SessionService) GetSessionHistory(page int, perPage int) ([]*SessionHistoryEntry, int64, error) {
+	sessions, total, err := s.repo.FindPastSessions(&repository.FindPastSessionsParams{
+		Offset: page * perPage,
+		Limit:  perPage,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	entries := make([]*SessionHistoryEntry, 0, len(sessions))
+	for _, session := range sessions {
+		entry, err := s.historyEntry(session)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, nil
+}
+
+func (s *SessionService) GetSessionHistoryEntry(sessionID int64) (*SessionHistoryEntry, error) {
+	session, err := s.repo.FindByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.historyEntry(session)
+}
+
+func (s *SessionService) historyEntry(session *model.Session) (*SessionHistoryEntry, error) {
+	entry := &SessionHistoryEntry{Session: session, Ratings: make(map[int64]*model.RatingSummary)}
+	var ratingVotingIDs []int64
+	for i := range session.Votings {
+		voting := &session.Votings[i]
+		switch {
+		case voting.Type == model.VOTING_RATING_TYPE && voting.MovieID != nil:
+			ratingVotingIDs = append(ratingVotingIDs, voting.ID)
+		case voting.Type == model.VOTING_SELECTION_TYPE && voting.WinnerMovieID != nil:
+			entry.SelectionVotings = append(entry.SelectionVotings, voting)
+		}
+	}
+	summaries, err := s.ratingSummaryRepo.FindByVotingIDs(&repository.FindRatingSummariesByVotingIDsParams{VotingIDs: ratingVotingIDs})
+	if err != nil {
+		return nil, err
+	}
+	// Summaries are ordered by ID, so a repeated rating voting of the movie replaces the earlier one
+	for _, summary := range summaries {
+		entry.Ratings[summary.MovieID] = summary
+	}
+	return entry, nil
+}
+
+func (s *SessionService) FinishSession(sessionID int64) error {
+	planned, err := s.repo.FindByID(sessionID)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
 		session, err := s.repo.FinishSession(&repository.FinishSessionParams{
 			SessionID: int64(sessionID),
 			Tx:        tx,
@@ -98,12 +230,9 @@ func (s *SessionService) FinishSession(sessionID int64) error {
 		if err != nil {
 			return err
 		}
-		movies, err := s.movieRepo.GetCurrentMovies()
-		if err != nil {
-			return err
-		}
 		finishedAt := time.Unix(session.FinishedAt, 0).String()
-		for _, movie := range movies {
+		for i := range planned.Movies {
+			movie := &planned.Movies[i]
 			movie.WatchCount += 1
 			movie.FinishedAt = &finishedAt
 			movie.Status = model.MOVIE_WATCHED_STATUS
@@ -119,7 +248,9 @@ func (s *SessionService) FinishSession(sessionID int64) error {
 	return nil
 }
 
-func (s *SessionService) AddMoviesToSession(createdBy int64, movieIDs []int64) (*model.Session, []int64, bool, error) {
+// AddMoviesToSession adds the movies to the upcoming session, a new session is planned
+// for the next free time of the schedule when sessionID is nil.
+func (s *SessionService) AddMoviesToSession(createdBy int64, sessionID *int64, movieIDs []int64) (*model.Session, []int64, bool, error) {
 	if len(movieIDs) == 0 {
 		return nil, nil, false, fmt.Errorf("movieIDs cannot be empty")
 	}
@@ -128,34 +259,28 @@ func (s *SessionService) AddMoviesToSession(createdBy int64, movieIDs []int64) (
 	var newMovieIDs []int64
 	var sessionCreated bool
 	err := s.repo.Transaction(func(tx *gorm.DB) error {
-		var err error
-		session, err = s.repo.GetOngoingSession(tx)
+		if s.scheduleService == nil {
+			return fmt.Errorf("schedule service is not configured")
+		}
+		nextFinishedAt, err := nextSessionTime(tx, s.repo, s.scheduleService)
 		if err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				return err
-			}
-			if s.scheduleService == nil {
-				return fmt.Errorf("schedule service is not configured")
-			}
-			nextFinishedAt, schErr := s.scheduleService.GetNextScheduledTime()
-			if schErr != nil {
-				return schErr
-			}
-			if nextFinishedAt == 0 {
-				return fmt.Errorf("no active schedule configured for session creation")
-			}
-			session, err = s.repo.Create(&repository.CreateSessionParams{
-				Session: &model.Session{
-					Status:     model.SESSION_ONGOING_STATUS,
-					CreatedBy:  createdBy,
-					FinishedAt: nextFinishedAt,
-				},
-				Tx: tx,
-			})
-			if err != nil {
-				return err
-			}
-			sessionCreated = true
+			return err
+		}
+		if sessionID == nil && nextFinishedAt == 0 {
+			return fmt.Errorf("no active schedule configured for session creation")
+		}
+		session, sessionCreated, err = s.repo.FindOrCreateSession(&repository.FindOrCreateSessionParams{
+			SessionID:  sessionID,
+			CreatedBy:  createdBy,
+			FinishedAt: &nextFinishedAt,
+			Venue:      scheduleVenue(s.scheduleService),
+			Tx:         tx,
+		})
+		if errors.Is(err, repository.ErrSessionNotUpcoming) {
+			return ErrSessionNotUpcoming
+		}
+		if err != nil {
+			return err
 		}
 		var existingMovies []model.Movie
 		if err := tx.Model(session).Association("Movies").Find(&existingMovies); err != nil {
@@ -186,7 +311,7 @@ func (s *SessionService) AddMoviesToSession(createdBy int64, movieIDs []int64) (
 		if err := tx.Model(session).Association("Movies").Append(moviesToAttach); err != nil {
 			return err
 		}
-		return nil
+		return s.repo.AppendMoviePositions(&repository.AppendMoviePositionsParams{SessionID: session.ID, MovieIDs: newMovieIDs, Tx: tx})
 	})
 	if err != nil {
 		return nil, nil, false, err
@@ -194,6 +319,36 @@ func (s *SessionService) AddMoviesToSession(createdBy int64, movieIDs []int64) (
 	return session, newMovieIDs, sessionCreated, nil
 }
 
+// MoveMovie swaps the movie with its neighbour in the lineup of the session and returns the reordered session.
+// The first movie can't go up and the last one down, then the session is returned unchanged and moved is false.
+func (s *SessionService) MoveMovie(sessionID int64, movieID int64, up bool) (*model.Session, bool, error) {
+	session, err := s.repo.FindByID(sessionID)
+	if err != nil {
+		return nil, false, err
+	}
+	index := slices.IndexFunc(session.Movies, func(movie model.Movie) bool { return movie.ID == movieID })
+	if index == -1 {
+		return nil, false, fmt.Errorf("movie %d is not in session %d", movieID, sessionID)
+	}
+	neighbour := index + 1
+	if up {
+		neighbour = index - 1
+	}
+	if neighbour < 0 || neighbour >= len(session.Movies) {
+		return session, false, nil
+	}
+	session.Movies[index], session.Movies[neighbour] = session.Movies[neighbour], session.Movies[index]
+	movieIDs := make([]int64, 0, len(session.Movies))
+	for _, movie := range session.Movies {
+		movieIDs = append(movieIDs, movie.ID)
+	}
+	err = s.repo.SetMoviePositions(&repository.SetMoviePositionsParams{SessionID: sessionID, MovieIDs: movieIDs})
+	if err != nil {
+		return nil, false, err
+	}
+	return session, true, nil
+}
+
 func (s *SessionService) UpdateSessionDescription(sessionID int64, description string) error {
 	session, err := s.repo.FindByID(sessionID)
 	if err != nil {
@@ -204,6 +359,72 @@ func (s *SessionService) UpdateSessionDescription(sessionID int64, description s
 	return s.repo.Update(session)
 }
 
+// SetRatingWindow changes how long the rating votings of the session movies stay open.
+func (s *SessionService) SetRatingWindow(sessionID int64, window time.Duration) error {
+	session, err := s.repo.FindByID(sessionID)
+	if err != nil {
+		return err
+	}
+	session.RatingWindow = int64(window.Minutes())
+	return s.repo.Update(session)
+}
+
+func (s *SessionService) SetVenue(sessionID int64, venue model.Venue) error {
+	session, err := s.repo.FindByID(sessionID)
+	if err != nil {
+		return err
+	}
+	session.Venue = venue
+	return s.repo.Update(session)
+}
+
+// SetRsvpMessageID remembers the pinned RSVP message of the session, it is edited instead of posting another one.
+func (s *SessionService) SetRsvpMessageID(sessionID int64, messageID int) error {
+	session, err := s.repo.FindByID(sessionID)
+	if err != nil {
+		return err
+	}
+	session.RsvpMessageID = messageID
+	return s.repo.Update(session)
+}
+
+// nextSessionTime returns the first time of the schedule after the last upcoming session,
+// so a new session doesn't take the place of an already planned one.
+func nextSessionTime(tx *gorm.DB, sessionRepo repository.ISessionRepo, scheduleService IScheduleService) (int64, error) {
+	sessions, err := sessionRepo.FindUpcomingSessions(&repository.FindUpcomingSessionsParams{Tx: tx})
+	if err != nil {
+		return 0, err
+	}
+	if len(sessions) == 0 {
+		return scheduleService.GetNextScheduledTime()
+	}
+	return scheduleService.GetScheduledTimeAfter(sessions[len(sessions)-1].FinishedAt)
+}
+
+// scheduleVenue returns the venue of the active schedule, new sessions start with it.
+func scheduleVenue(scheduleService IScheduleService) model.Venue {
+	schedule, err := scheduleService.GetActiveSchedule()
+	if err != nil || schedule == nil {
+		return model.Venue{}
+	}
+	return schedule.Venue
+}
+
+// FormatVenue lists the filled venue fields, it is empty when the venue is not set.
+func FormatVenue(venue model.Venue) string {
+	var lines []string
+	if venue.Address != "" {
+		lines = append(lines, "📍 "+venue.Address)
+	}
+	if venue.RoomURL != "" {
+		lines = append(lines, "🔗 "+venue.RoomURL)
+	}
+	if venue.Notes != "" {
+		lines = append(lines, "ℹ️ "+venue.Notes)
+	}
+	return strings.Join(lines, "\n")
+}
+
 func uniqueInts(values []int64) []int64 {
 	seen := make(map[int64]struct{}, len(values))
 	result := make([]int64, 0, len(values))
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/repository"
+)
+
+// SUGGESTION_WEEK is the rolling window of the weekly quota.
+const SUGGESTION_WEEK = 7 * 24 * time.Hour
+
+// SuggestionQuota is the usage of the member, a zero limit means there is none.
+type SuggestionQuota struct {
+	Open       int
+	MaxOpen    int
+	Week       int
+	MaxPerWeek int
+	Extra      int
+}
+
+// Remaining returns how many movies the member can suggest now, -1 when there are no limits.
+func (q *SuggestionQuota) Remaining() int {
+	remaining := -1
+	if q.MaxOpen > 0 {
+		remaining = max(q.MaxOpen+q.Extra-q.Open, 0)
+	}
+	if q.MaxPerWeek > 0 {
+		week := max(q.MaxPerWeek+q.Extra-q.Week, 0)
+		if remaining == -1 || week < remaining {
+			remaining = week
+		}
+	}
+	return remaining
+}
+
+type ISuggestionService interface {
+	GetQuota(userID int64) (*SuggestionQuota, error)
+	GrantSlots(userID int64, slots int, grantedBy int64) (*model.SuggestionGrant, error)
+}
+
+type SuggestionService struct {
+	movieRepo repository.IMovieRepo
+	grantRepo repository.ISuggestionGrantRepo
+	cfg       *config.SuggestionConfig
+}
+
+func NewSuggestionService(movieRepo repository.IMovieRepo, grantRepo repository.ISuggestionGrantRepo, cfg *config.SuggestionConfig) ISuggestionService {
+	return &SuggestionService{movieRepo: movieRepo, grantRepo: grantRepo, cfg: cfg}
+}
+
+// GetQuota counts the open suggestions of the member and the ones made in the last week.
+func (s *SuggestionService) GetQuota(userID int64) (*SuggestionQuota, error) {
+	now := time.Now()
+	open, err := s.movieRepo.CountSuggestions(&repository.CountSuggestionsParams{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	week, err := s.movieRepo.CountSuggestions(&repository.CountSuggestionsParams{UserID: userID, Since: now.Add(-SUGGESTION_WEEK).Unix()})
+	if err != nil {
+		return nil, err
+	}
+	extra, err := s.grantRepo.SumActiveSlots(userID, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	return &SuggestionQuota{
+		Open:       int(open),
+		MaxOpen:    s.cfg.MaxOpen,
+		Week:       int(week),
+		MaxPerWeek: s.cfg.MaxPerWeek,
+		Extra:      extra,
+	}, nil
+}
+
+// GrantSlots gives the member extra suggestions for SUGGESTION_GRANT_DURATION.
+func (s *SuggestionService) GrantSlots(userID int64, slots int, grantedBy int64) (*model.SuggestionGrant, error) {
+	grant := &model.SuggestionGrant{
+		UserID:    userID,
+		Slots:     slots,
+		ExpiresAt: time.Now().Add(s.cfg.GrantDuration).Unix(),
+		GrantedBy: grantedBy,
+	}
+	if err := s.grantRepo.Create(grant); err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
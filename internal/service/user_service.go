@@ -1,6 +1,10 @@
 package service
 
 import (
+	"fmt"
+	"html"
+	"strings"
+
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/repository"
 )
@@ -13,6 +17,8 @@ type UserService struct {
 type IUserService interface {
 	Create(user *model.User, role string) error
 	FindByID(userID int64) (*model.User, error)
+	FindNonVoters(votingID int64) ([]*model.User, error)
+	SetVotingReminders(userID int64, enabled bool) error
 }
 
 func NewUserService(repo repository.IUserRepo, roleRepo repository.IRoleRepo) *UserService {
@@ -31,3 +37,20 @@ func (s *UserService) Create(user *model.User, role string) error {
 func (s *UserService) FindByID(userID int64) (*model.User, error) {
 	return s.repo.FindByID(userID)
 }
+
+func (s *UserService) FindNonVoters(votingID int64) ([]*model.User, error) {
+	return s.repo.FindNonVoters(votingID)
+}
+
+func (s *UserService) SetVotingReminders(userID int64, enabled bool) error {
+	return s.repo.UpdateVotingReminders(userID, enabled)
+}
+
+// Mention returns an HTML link notifying the user in a group message.
+func Mention(user *model.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" {
+		name = user.Username
+	}
+	return fmt.Sprintf("<a href=\"tg://user?id=%d\">%s</a>", user.ID, html.EscapeString(name))
+}
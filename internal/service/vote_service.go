@@ -1,8 +1,11 @@
 package service
 
 import (
+	"errors"
+
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/repository"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/runoff"
 	"gorm.io/gorm"
 )
 
@@ -10,6 +13,15 @@ type IVoteService interface {
 	CreateMultiple(votingID int64, userID int64, votes []*model.Vote) error
 	CalculateRatingMean(votingID int64) (float64, error)
 	CalculateMaxMovieCount(votingID int64) (int64, int64, error)
+	CalculateTopMovies(votingID int64) (int64, []int64, error)
+	AddRankedVote(votingID int64, userID int64, movieID int64) ([]*model.Vote, error)
+	ResetVotes(votingID int64, userID int64) error
+	CalculateInstantRunoff(votingID int64) (*runoff.Result, error)
+	CountVotesByMovie(votingID int64) (map[int64]int64, error)
+	CountVoters(votingID int64) (int64, error)
+	CountAbstained(votingID int64) (int64, error)
+	GetRatings(votingID int64) ([]int, error)
+	GetUserVotes(votingID int64, userID int64) ([]*model.Vote, error)
 }
 
 type VoteService struct {
@@ -28,6 +40,51 @@ func (s *VoteService) CalculateMaxMovieCount(votingID int64) (int64, int64, erro
 	return s.repo.CalculateMaxMovieCount(votingID)
 }
 
+func (s *VoteService) CalculateTopMovies(votingID int64) (int64, []int64, error) {
+	return s.repo.CalculateTopMovies(votingID)
+}
+
+func (s *VoteService) CountVotesByMovie(votingID int64) (map[int64]int64, error) {
+	results, err := s.repo.CountVotesByMovie(votingID)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int64]int64, len(results))
+	for _, result := range results {
+		counts[result.MovieID] = result.MovieCount
+	}
+	return counts, nil
+}
+
+func (s *VoteService) CountVoters(votingID int64) (int64, error) {
+	return s.repo.CountVoters(votingID)
+}
+
+func (s *VoteService) CountAbstained(votingID int64) (int64, error) {
+	return s.repo.CountAbstained(votingID)
+}
+
+func (s *VoteService) GetRatings(votingID int64) ([]int, error) {
+	votes, err := s.repo.FindByVotingID(votingID)
+	if err != nil {
+		return nil, err
+	}
+	var ratings []int
+	for _, vote := range votes {
+		if vote.Rating != nil {
+			ratings = append(ratings, *vote.Rating)
+		}
+	}
+	return ratings, nil
+}
+
+func (s *VoteService) GetUserVotes(votingID int64, userID int64) ([]*model.Vote, error) {
+	return s.repo.FindByUserIdAndVotingId(&repository.FindByUserIdAndVotingIdParams{
+		UserID:   userID,
+		VotingID: votingID,
+	})
+}
+
 func (s *VoteService) CreateMultiple(votingID int64, userID int64, votes []*model.Vote) error {
 	err := s.repo.Transaction(func(tx *gorm.DB) error {
 		deleteParams := &repository.DeleteByUserIdAndVotingIdParams{
@@ -52,3 +109,75 @@ func (s *VoteService) CreateMultiple(votingID int64, userID int64, votes []*mode
 	})
 	return err
 }
+
+// AddRankedVote appends the movie to the user's ballot as their next preference
+// and returns the whole ballot ordered by rank.
+func (s *VoteService) AddRankedVote(votingID int64, userID int64, movieID int64) ([]*model.Vote, error) {
+	var ballot []*model.Vote
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ballot, err = s.repo.FindByUserIdAndVotingId(&repository.FindByUserIdAndVotingIdParams{
+			UserID:   userID,
+			VotingID: votingID,
+			Tx:       tx,
+		})
+		if err != nil {
+			return err
+		}
+		for _, vote := range ballot {
+			if vote.MovieID != nil && *vote.MovieID == movieID {
+				return nil
+			}
+		}
+		rank := len(ballot) + 1
+		vote := &model.Vote{
+			VotingID: votingID,
+			UserID:   userID,
+			MovieID:  &movieID,
+			Rank:     &rank,
+		}
+		if err := s.repo.Create(&repository.CreateVoteParams{Vote: vote, Tx: tx}); err != nil {
+			return err
+		}
+		ballot = append(ballot, vote)
+		return nil
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// Another tap took the rank or added the movie first, its ballot is the current one
+		return s.repo.FindByUserIdAndVotingId(&repository.FindByUserIdAndVotingIdParams{
+			UserID:   userID,
+			VotingID: votingID,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ballot, nil
+}
+
+func (s *VoteService) ResetVotes(votingID int64, userID int64) error {
+	return s.repo.DeleteByUserIdAndVotingId(&repository.DeleteByUserIdAndVotingIdParams{
+		UserID:   userID,
+		VotingID: votingID,
+	})
+}
+
+func (s *VoteService) CalculateInstantRunoff(votingID int64) (*runoff.Result, error) {
+	votes, err := s.repo.FindByVotingID(votingID)
+	if err != nil {
+		return nil, err
+	}
+	var ballots [][]int64
+	var lastUserID int64
+	for _, vote := range votes {
+		if vote.MovieID == nil || vote.Rank == nil {
+			continue
+		}
+		if len(ballots) == 0 || vote.UserID != lastUserID {
+			ballots = append(ballots, []int64{})
+			lastUserID = vote.UserID
+		}
+		ballots[len(ballots)-1] = append(ballots[len(ballots)-1], *vote.MovieID)
+	}
+	return runoff.InstantRunoff(ballots), nil
+}
@@ -2,37 +2,90 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"math"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/repository"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/bracket"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/stats"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"gorm.io/gorm"
 )
 
+const RANKED_BALLOT_PREFIX = "ranked_"
+
+const (
+	SECRET_BALLOT_PREFIX       = "secret_"
+	SECRET_BALLOT_START_PREFIX = "vote_" // deep link payload, /start vote_<votingID>
+)
+
 type FinishSelectionVotingParams struct {
-	VotingID  int64
-	PollID    string
-	MovieID   int64
-	CreatedBy int64
+	VotingID      int64
+	PollID        string
+	MovieID       int64
+	SessionID     *int64 // session targeted by the voting
+	CreatedBy     int64
+	QuorumOutcome *string
 }
 
 type FinishRatingVotingParams struct {
+	VotingID      int64
+	PollID        string
+	MovieID       int64
+	Ratings       []int
+	PriorWeight   float64
+	CreatedBy     int64
+	QuorumOutcome *string
+}
+
+type ReopenVotingParams struct {
+	Bot         *bot.Bot
+	Context     context.Context
+	ChatID      int64
+	VotingID    int64
+	FinishedAt  int64
+	PollOptions []models.InputPollOption // options of rating polls, selection options are taken from the poll
+	PriorWeight float64
+}
+
+type AdvanceBracketParams struct {
+	BracketID int64
 	VotingID  int64
 	PollID    string
-	MovieID   int64
-	Mean      float64
-	CreatedBy int64
+	Group     int
+	Tallies   []bracket.Tally // votes of the group poll in the order of its options
+}
+
+type QuorumResult struct {
+	Outcome  string // empty when the voting has no quorum
+	Voters   int64
+	Required int64
 }
 
 type VotingOptions struct {
-	Title      string
-	Type       string
-	CreatedBy  int64
-	FinishedAt *int64
-	MovieID    *int64
-	SessionID  *int64
+	Title        string
+	Type         string
+	Method       string
+	CreatedBy    int64
+	FinishedAt   *int64
+	MovieID      *int64
+	SessionID    *int64
+	ParentID     *int64
+	RunoffRound  int
+	QuorumType   string
+	QuorumValue  int
+	QuorumPolicy string
+	HideTally    bool
+	Secret       bool
+	BracketID    *int64
+	BracketGroup int
 }
 
 type StartRatingVotingParams struct {
@@ -47,22 +100,38 @@ type StartRatingVotingParams struct {
 
 type IVotingService interface {
 	FindVotingByStatus(status string) ([]*model.Voting, error)
-	FinishRatingVoting(params *FinishRatingVotingParams) error
+	FindClosedVotingsSince(since int64) ([]*model.Voting, error)
+	GetVotingByID(id int64) (*model.Voting, error)
+	GetRunoff(parentID int64) (*model.Voting, error)
+	FinishRatingVoting(params *FinishRatingVotingParams) (*model.RatingSummary, error)
 	FinishSelectionVoting(params *FinishSelectionVotingParams) (*model.Session, bool, error)
+	FinishVoting(votingID int64, pollID string, quorumOutcome *string) error
+	CheckQuorum(voting *model.Voting) (*QuorumResult, error)
+	ExtendVoting(votingID int64, finishedAt int64) error
+	RescheduleVoting(votingID int64, finishedAt int64) error
+	ReopenVoting(params *ReopenVotingParams) (*model.Poll, *model.Session, error)
+	CancelVotingByQuorum(votingID int64, pollID string) error
 	StartVoting(params *StartRatingVotingParams) (*model.Poll, error)
 	CancelByVotingID(votingIDs []int64) ([]*model.Voting, error)
+	CreateBracket(title string, advanceCount int, createdBy int64) (*model.Bracket, error)
+	AdvanceBracket(params *AdvanceBracketParams) (*model.Bracket, error)
+	GetBracketEntries(bracketID int64) ([]*model.BracketEntry, error)
 }
 
 type VotingService struct {
-	repo            repository.IVotingRepo
-	sessionRepo     repository.ISessionRepo
-	movieRepo       repository.IMovieRepo
-	pollRepo        repository.IPollRepo
-	scheduleService IScheduleService
+	repo              repository.IVotingRepo
+	sessionRepo       repository.ISessionRepo
+	movieRepo         repository.IMovieRepo
+	pollRepo          repository.IPollRepo
+	voteRepo          repository.IVoteRepo
+	userRepo          repository.IUserRepo
+	ratingSummaryRepo repository.IRatingSummaryRepo
+	bracketRepo       repository.IBracketRepo
+	scheduleService   IScheduleService
 }
 
-func NewVotingService(repo repository.IVotingRepo, scheduleService IScheduleService, sessionRepo repository.ISessionRepo, movieRepo repository.IMovieRepo, pollRepo repository.IPollRepo) *VotingService {
-	return &VotingService{repo: repo, scheduleService: scheduleService, sessionRepo: sessionRepo, movieRepo: movieRepo, pollRepo: pollRepo}
+func NewVotingService(repo repository.IVotingRepo, scheduleService IScheduleService, sessionRepo repository.ISessionRepo, movieRepo repository.IMovieRepo, pollRepo repository.IPollRepo, voteRepo repository.IVoteRepo, userRepo repository.IUserRepo, ratingSummaryRepo repository.IRatingSummaryRepo, bracketRepo repository.IBracketRepo) *VotingService {
+	return &VotingService{repo: repo, scheduleService: scheduleService, sessionRepo: sessionRepo, movieRepo: movieRepo, pollRepo: pollRepo, voteRepo: voteRepo, userRepo: userRepo, ratingSummaryRepo: ratingSummaryRepo, bracketRepo: bracketRepo}
 }
 
 func (s *VotingService) CancelByVotingID(votingIDs []int64) ([]*model.Voting, error) {
@@ -105,11 +174,16 @@ func (s *VotingService) CancelByVotingID(votingIDs []int64) ([]*model.Voting, er
 	return votings, nil
 }
 
-func (s *VotingService) FinishRatingVoting(params *FinishRatingVotingParams) error {
+// FinishRatingVoting closes the rating voting and stores its summary.
+// The club average changes with every voting, so the pooled and adjusted
+// ratings of all rated movies are recalculated from the summaries.
+func (s *VotingService) FinishRatingVoting(params *FinishRatingVotingParams) (*model.RatingSummary, error) {
+	var summary *model.RatingSummary
 	err := s.repo.Transaction(func(tx *gorm.DB) error {
 		err := s.repo.FinishVoting(&repository.FinishVotingParams{
-			VotingID: params.VotingID,
-			Tx:       tx,
+			VotingID:      params.VotingID,
+			QuorumOutcome: params.QuorumOutcome,
+			Tx:            tx,
 		})
 		if err != nil {
 			return err
@@ -122,49 +196,360 @@ func (s *VotingService) FinishRatingVoting(params *FinishRatingVotingParams) err
 		if err != nil {
 			return err
 		}
-		err = s.movieRepo.UpdateRating(&repository.UpdateRatingParams{
-			MovieID: params.MovieID,
-			Rating:  params.Mean,
+		if len(params.Ratings) == 0 {
+			return nil
+		}
+		summaries, err := s.ratingSummaryRepo.FindAll(&repository.FindAllRatingSummariesParams{Tx: tx})
+		if err != nil {
+			return err
+		}
+		summary = &model.RatingSummary{
+			VotingID:  params.VotingID,
+			MovieID:   params.MovieID,
+			Count:     len(params.Ratings),
+			Mean:      stats.Mean(params.Ratings),
+			Median:    stats.Median(params.Ratings),
+			StdDev:    stats.StdDev(params.Ratings),
+			Histogram: stats.Histogram(params.Ratings, model.MAX_RATING),
+		}
+		summaries = append(summaries, summary)
+		legacy, err := s.movieRepo.FindLegacyRatings(&repository.FindLegacyRatingsParams{Tx: tx})
+		if err != nil {
+			return err
+		}
+		clubMean := clubRatingMean(summaries, legacy)
+		summary.AdjustedScore = stats.BayesianAverage(summary.Mean, summary.Count, clubMean, params.PriorWeight)
+		err = s.ratingSummaryRepo.Create(&repository.CreateRatingSummaryParams{
+			Summary: summary,
 			Tx:      tx,
 		})
 		if err != nil {
 			return err
 		}
-		return nil
+		return s.updateMovieRatings(tx, summaries, clubMean, params.PriorWeight)
 	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return summary, nil
+}
+
+func (s *VotingService) updateMovieRatings(tx *gorm.DB, summaries []*model.RatingSummary, clubMean float64, priorWeight float64) error {
+	histograms := make(map[int64][]int)
+	for _, summary := range summaries {
+		histograms[summary.MovieID] = addHistograms(histograms[summary.MovieID], summary.Histogram)
+	}
+	for movieID, histogram := range histograms {
+		ratings := stats.Expand(histogram)
+		mean := stats.Mean(ratings)
+		err := s.movieRepo.UpdateRating(&repository.UpdateRatingParams{
+			MovieID:        movieID,
+			Rating:         mean,
+			RatingCount:    len(ratings),
+			AdjustedRating: stats.BayesianAverage(mean, len(ratings), clubMean, priorWeight),
+			Tx:             tx,
+		})
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (s *VotingService) FinishSelectionVoting(params *FinishSelectionVotingParams) (*model.Session, bool, error) {
-	var created bool = false
-	var session *model.Session
-	err := s.repo.Transaction(func(tx *gorm.DB) error {
+// clubRatingMean is the mean of every rating ever given in the club.
+// The vote count of a legacy rating is unknown, it counts as a single rating.
+func clubRatingMean(summaries []*model.RatingSummary, legacy []float64) float64 {
+	var total float64
+	var count int
+	for _, summary := range summaries {
+		total += summary.Mean * float64(summary.Count)
+		count += summary.Count
+	}
+	for _, rating := range legacy {
+		total += rating
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+func addHistograms(a []int, b []int) []int {
+	if len(b) > len(a) {
+		a, b = b, a
+	}
+	result := make([]int, len(a))
+	copy(result, a)
+	for i, count := range b {
+		result[i] += count
+	}
+	return result
+}
+
+// FinishVoting closes the voting and its poll without picking a winner,
+// e.g. when the decision is handed over to a runoff.
+func (s *VotingService) FinishVoting(votingID int64, pollID string, quorumOutcome *string) error {
+	return s.repo.Transaction(func(tx *gorm.DB) error {
 		err := s.repo.FinishVoting(&repository.FinishVotingParams{
-			VotingID: params.VotingID,
-			Tx:       tx,
+			VotingID:      votingID,
+			QuorumOutcome: quorumOutcome,
+			Tx:            tx,
 		})
 		if err != nil {
 			return err
 		}
-		err = s.pollRepo.UpdateStatus(&repository.UpdateStatusParams{
-			PollID: params.PollID,
+		return s.pollRepo.UpdateStatus(&repository.UpdateStatusParams{
+			PollID: pollID,
 			Status: model.POLL_CLOSED_STATUS,
 			Tx:     tx,
 		})
+	})
+}
+
+// CheckQuorum compares the number of voters with the voting quorum and
+// applies the voting policy when the quorum is not met. Extension is allowed only once,
+// after that the result is accepted with low confidence.
+func (s *VotingService) CheckQuorum(voting *model.Voting) (*QuorumResult, error) {
+	result := &QuorumResult{}
+	if voting.QuorumType == "" || voting.QuorumValue <= 0 {
+		return result, nil
+	}
+	voters, err := s.voteRepo.CountVoters(voting.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.Voters = voters
+	switch voting.QuorumType {
+	case model.QUORUM_PERCENT_TYPE:
+		members, err := s.userRepo.Count()
+		if err != nil {
+			return nil, err
+		}
+		result.Required = int64(math.Ceil(float64(members) * float64(voting.QuorumValue) / 100))
+	default:
+		result.Required = int64(voting.QuorumValue)
+	}
+	if voters >= result.Required {
+		result.Outcome = model.QUORUM_MET_OUTCOME
+		return result, nil
+	}
+	switch voting.QuorumPolicy {
+	case model.QUORUM_EXTEND_POLICY:
+		if voting.Extended {
+			result.Outcome = model.QUORUM_LOW_CONFIDENCE_OUTCOME
+		} else {
+			result.Outcome = model.QUORUM_EXTENDED_OUTCOME
+		}
+	case model.QUORUM_CANCEL_POLICY:
+		result.Outcome = model.QUORUM_CANCELLED_OUTCOME
+	default:
+		result.Outcome = model.QUORUM_LOW_CONFIDENCE_OUTCOME
+	}
+	return result, nil
+}
+
+func (s *VotingService) ExtendVoting(votingID int64, finishedAt int64) error {
+	return s.repo.ExtendVoting(&repository.ExtendVotingParams{
+		VotingID:   votingID,
+		FinishedAt: finishedAt,
+	})
+}
+
+func (s *VotingService) RescheduleVoting(votingID int64, finishedAt int64) error {
+	return s.repo.RescheduleVoting(&repository.RescheduleVotingParams{
+		VotingID:   votingID,
+		FinishedAt: finishedAt,
+	})
+}
+
+// ReopenVoting undoes the result of a closed voting and posts its ballot again,
+// votes cast before the close are kept. When the winner of a selection voting is taken
+// out of its session, the session is returned; a session left without movies is cancelled
+// and the voting picks a session again at the next close.
+func (s *VotingService) ReopenVoting(params *ReopenVotingParams) (*model.Poll, *model.Session, error) {
+	var poll *model.Poll
+	var session *model.Session
+	var detach bool
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		voting, err := s.repo.FindVotingByID(params.VotingID)
 		if err != nil {
 			return err
 		}
-		finishedAt, err := s.scheduleService.GetNextScheduledTime()
+		poll, err = s.pollRepo.FindLastByVotingID(voting.ID)
 		if err != nil {
 			return err
 		}
-		session, created, err = s.sessionRepo.FindOrCreateSession(&repository.FindOrCreateSessionParams{
+		switch voting.Type {
+		case model.VOTING_SELECTION_TYPE:
+			options, err := s.pollRepo.FindPollOptionsByPollID(poll.ID)
+			if err != nil {
+				return err
+			}
+			params.PollOptions = make([]models.InputPollOption, 0, len(options))
+			for _, option := range options {
+				params.PollOptions = append(params.PollOptions, models.InputPollOption{Text: fmt.Sprintf("%s (%d)", option.Movie.Title, option.Movie.Year)})
+			}
+			if voting.WinnerMovieID != nil && voting.SessionID != nil {
+				session, err = s.sessionRepo.FindByID(*voting.SessionID)
+				if err != nil {
+					return err
+				}
+				if !slices.Contains(model.SESSION_UPCOMING_STATUSES, session.Status) {
+					// The session is already over, nothing to take the winner out of
+					session = nil
+					break
+				}
+				err = s.sessionRepo.DisconnectMoviesFromSession(&repository.DisconnectMoviesFromSessionParams{
+					SessionID: session.ID,
+					MovieIDs:  []int64{*voting.WinnerMovieID},
+					Tx:        tx,
+				})
+				if err != nil {
+					return err
+				}
+				if len(session.Movies) <= 1 {
+					// Only the winner was planned, the session would stay empty
+					session, err = s.sessionRepo.CancelSession(&repository.CancelSessionParams{
+						SessionID:   session.ID,
+						CancelledAt: time.Now().Unix(),
+						Tx:          tx,
+					})
+					if err != nil {
+						return err
+					}
+					detach = true
+				}
+			}
+		case model.VOTING_RATING_TYPE:
+			err = s.ratingSummaryRepo.DeleteByVotingID(&repository.DeleteRatingSummaryParams{
+				VotingID: voting.ID,
+				Tx:       tx,
+			})
+			if err != nil {
+				return err
+			}
+			summaries, err := s.ratingSummaryRepo.FindAll(&repository.FindAllRatingSummariesParams{Tx: tx})
+			if err != nil {
+				return err
+			}
+			rated := false
+			for _, summary := range summaries {
+				if voting.MovieID != nil && summary.MovieID == *voting.MovieID {
+					rated = true
+				}
+			}
+			if !rated && voting.MovieID != nil {
+				err = s.movieRepo.UpdateRating(&repository.UpdateRatingParams{MovieID: *voting.MovieID, Tx: tx})
+				if err != nil {
+					return err
+				}
+			}
+			legacy, err := s.movieRepo.FindLegacyRatings(&repository.FindLegacyRatingsParams{Tx: tx})
+			if err != nil {
+				return err
+			}
+			err = s.updateMovieRatings(tx, summaries, clubRatingMean(summaries, legacy), params.PriorWeight)
+			if err != nil {
+				return err
+			}
+		}
+		err = s.repo.ReopenVoting(&repository.ReopenVotingParams{
+			VotingID:      voting.ID,
+			FinishedAt:    params.FinishedAt,
+			DetachSession: detach,
+			Tx:            tx,
+		})
+		if err != nil {
+			return err
+		}
+		multi := voting.Type == model.VOTING_SELECTION_TYPE
+		sent, err := s.sendBallot(&StartRatingVotingParams{
+			Bot:         params.Bot,
+			Context:     params.Context,
+			ChatID:      params.ChatID,
+			Question:    voting.Title,
+			Multi:       &multi,
+			PollOptions: params.PollOptions,
+		}, voting)
+		if err != nil {
+			return err
+		}
+		poll.PollID = sent.PollID
+		poll.MessageID = sent.MessageID
+		poll.Status = model.POLL_OPENED_STATUS
+		return s.pollRepo.Reopen(&repository.ReopenPollParams{
+			ID:        poll.ID,
+			PollID:    poll.PollID,
+			MessageID: poll.MessageID,
+			Tx:        tx,
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return poll, session, nil
+}
+
+func (s *VotingService) CancelVotingByQuorum(votingID int64, pollID string) error {
+	outcome := model.QUORUM_CANCELLED_OUTCOME
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		err := s.repo.CancelVoting(&repository.CancelVotingParams{
+			VotingID:      votingID,
+			QuorumOutcome: &outcome,
+			Tx:            tx,
+		})
+		if err != nil {
+			return err
+		}
+		return s.pollRepo.UpdateStatus(&repository.UpdateStatusParams{
+			PollID: pollID,
+			Status: model.POLL_CLOSED_STATUS,
+			Tx:     tx,
+		})
+	})
+}
+
+// FinishSelectionVoting closes the voting and adds the winner to the session targeted by the voting.
+// When the voting has no target or its session is not upcoming anymore, a new session is planned.
+func (s *VotingService) FinishSelectionVoting(params *FinishSelectionVotingParams) (*model.Session, bool, error) {
+	var created bool = false
+	var session *model.Session
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		finishedAt, err := nextSessionTime(tx, s.sessionRepo, s.scheduleService)
+		if err != nil {
+			return err
+		}
+		findParams := &repository.FindOrCreateSessionParams{
+			SessionID:  params.SessionID,
 			CreatedBy:  params.CreatedBy,
 			FinishedAt: &finishedAt,
+			Venue:      scheduleVenue(s.scheduleService),
 			Tx:         tx,
+		}
+		session, created, err = s.sessionRepo.FindOrCreateSession(findParams)
+		if errors.Is(err, repository.ErrSessionNotUpcoming) {
+			// Nobody is there to pick another session when the voting closes, the winner gets a new one
+			findParams.SessionID = nil
+			session, created, err = s.sessionRepo.FindOrCreateSession(findParams)
+		}
+		if err != nil {
+			return err
+		}
+		err = s.repo.FinishVoting(&repository.FinishVotingParams{
+			VotingID:      params.VotingID,
+			QuorumOutcome: params.QuorumOutcome,
+			WinnerMovieID: &params.MovieID,
+			SessionID:     &session.ID,
+			Tx:            tx,
+		})
+		if err != nil {
+			return err
+		}
+		err = s.pollRepo.UpdateStatus(&repository.UpdateStatusParams{
+			PollID: params.PollID,
+			Status: model.POLL_CLOSED_STATUS,
+			Tx:     tx,
 		})
 		if err != nil {
 			return err
@@ -189,10 +574,26 @@ func (s *VotingService) StartVoting(params *StartRatingVotingParams) (*model.Pol
 	var poll *model.Poll
 	err := s.repo.Transaction(func(tx *gorm.DB) error {
 		voting := &model.Voting{
-			Title:      params.Options.Title,
-			Type:       params.Options.Type,
-			CreatedBy:  params.Options.CreatedBy,
-			FinishedAt: params.Options.FinishedAt,
+			Title:        params.Options.Title,
+			Type:         params.Options.Type,
+			Method:       params.Options.Method,
+			CreatedBy:    params.Options.CreatedBy,
+			FinishedAt:   params.Options.FinishedAt,
+			ParentID:     params.Options.ParentID,
+			RunoffRound:  params.Options.RunoffRound,
+			QuorumType:   params.Options.QuorumType,
+			QuorumValue:  params.Options.QuorumValue,
+			QuorumPolicy: params.Options.QuorumPolicy,
+			HideTally:    params.Options.HideTally,
+			Secret:       params.Options.Secret,
+			BracketID:    params.Options.BracketID,
+			BracketGroup: params.Options.BracketGroup,
+		}
+		if voting.QuorumPolicy == "" {
+			voting.QuorumPolicy = model.QUORUM_ACCEPT_POLICY
+		}
+		if voting.Method == "" {
+			voting.Method = model.VOTING_METHOD_PLURALITY
 		}
 		if params.Options.MovieID != nil {
 			voting.MovieID = params.Options.MovieID
@@ -215,6 +616,78 @@ func (s *VotingService) StartVoting(params *StartRatingVotingParams) (*model.Pol
 			})
 			return err
 		}
+		pollModel, err := s.sendBallot(params, createdVoting)
+		if err != nil {
+			return err
+		}
+
+		if params.Options.MovieID != nil {
+			pollModel.MovieID = params.Options.MovieID
+		}
+
+		poll, err = s.pollRepo.Create(&repository.CreatePollParams{
+			Poll: pollModel,
+			Tx:   tx,
+		})
+		if err != nil {
+			log.Printf("Error saving poll: %v", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+// sendBallot posts the poll, the ranked ballot or the secret ballot announcement of the voting
+// and returns the poll model to be saved.
+func (s *VotingService) sendBallot(params *StartRatingVotingParams, voting *model.Voting) (*model.Poll, error) {
+	var pollModel *model.Poll
+	if voting.Secret {
+		me, err := params.Bot.GetMe(params.Context)
+		if err != nil {
+			log.Printf("Error getting bot info: %v", err)
+			return nil, err
+		}
+		link := fmt.Sprintf("https://t.me/%s?start=%s%d", me.Username, SECRET_BALLOT_START_PREFIX, voting.ID)
+		announcementMsg, err := params.Bot.SendMessage(params.Context, &bot.SendMessageParams{
+			ChatID: params.ChatID,
+			Text:   secretBallotText(params.Question, voting.Type, params.PollOptions),
+			ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{
+				{Text: "🗳️ Проголосовать", URL: link},
+			}}},
+		})
+		if err != nil {
+			log.Printf("Error sending secret ballot announcement: %v", err)
+			return nil, err
+		}
+		pollModel = &model.Poll{
+			PollID:    fmt.Sprintf("secret-%d", voting.ID),
+			MessageID: announcementMsg.ID,
+			VotingID:  voting.ID,
+			Type:      voting.Type,
+			Status:    model.POLL_OPENED_STATUS,
+		}
+	} else if voting.Method == model.VOTING_METHOD_RANKED {
+		ballotMsg, err := params.Bot.SendMessage(params.Context, &bot.SendMessageParams{
+			ChatID:      params.ChatID,
+			Text:        rankedBallotText(params.Question, params.PollOptions),
+			ReplyMarkup: RankedBallotKeyboard(voting.ID, params.PollOptions),
+		})
+		if err != nil {
+			log.Printf("Error sending ranked ballot: %v", err)
+			return nil, err
+		}
+		pollModel = &model.Poll{
+			PollID:    fmt.Sprintf("ballot-%d", voting.ID),
+			MessageID: ballotMsg.ID,
+			VotingID:  voting.ID,
+			Type:      voting.Type,
+			Status:    model.POLL_OPENED_STATUS,
+		}
+	} else {
 		pollMsg, err := params.Bot.SendPoll(params.Context, &bot.SendPollParams{
 			ChatID:                params.ChatID,
 			Question:              bot.EscapeMarkdownUnescaped(params.Question),
@@ -226,37 +699,164 @@ func (s *VotingService) StartVoting(params *StartRatingVotingParams) (*model.Pol
 		})
 		if err != nil {
 			log.Printf("Error sending poll: %v", err)
-			return err
+			return nil, err
 		}
-
-		pollModel := &model.Poll{
+		pollModel = &model.Poll{
 			PollID:    pollMsg.Poll.ID,
 			MessageID: pollMsg.ID,
-			VotingID:  createdVoting.ID,
-			Type:      params.Options.Type,
+			VotingID:  voting.ID,
+			Type:      voting.Type,
 			Status:    model.POLL_OPENED_STATUS,
 		}
+	}
+	return pollModel, nil
+}
 
-		if params.Options.MovieID != nil {
-			pollModel.MovieID = params.Options.MovieID
-		}
+func (s *VotingService) CreateBracket(title string, advanceCount int, createdBy int64) (*model.Bracket, error) {
+	return s.bracketRepo.Create(&repository.CreateBracketParams{
+		Bracket: &model.Bracket{
+			Title:        title,
+			Stage:        model.BRACKET_GROUPS_STAGE,
+			AdvanceCount: advanceCount,
+			CreatedBy:    createdBy,
+		},
+	})
+}
 
-		poll, err = s.pollRepo.Create(&repository.CreatePollParams{
-			Poll: pollModel,
-			Tx:   tx,
+// AdvanceBracket closes the group poll and saves its results. When it was the last
+// group to close, the bracket moves to the final stage and is returned with the finalists
+// as its entries, otherwise the result is nil.
+func (s *VotingService) AdvanceBracket(params *AdvanceBracketParams) (*model.Bracket, error) {
+	var final *model.Bracket
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		current, err := s.bracketRepo.Lock(&repository.LockBracketParams{BracketID: params.BracketID, Tx: tx})
+		if err != nil {
+			return err
+		}
+		err = s.repo.FinishVoting(&repository.FinishVotingParams{VotingID: params.VotingID, Tx: tx})
+		if err != nil {
+			return err
+		}
+		err = s.pollRepo.UpdateStatus(&repository.UpdateStatusParams{
+			PollID: params.PollID,
+			Status: model.POLL_CLOSED_STATUS,
+			Tx:     tx,
 		})
 		if err != nil {
-			log.Printf("Error saving poll: %v", err)
 			return err
 		}
+		advanced := make(map[int64]bool)
+		for _, movieID := range bracket.Advance(params.Tallies, current.AdvanceCount) {
+			advanced[movieID] = true
+		}
+		entries := make([]*model.BracketEntry, 0, len(params.Tallies))
+		for _, tally := range params.Tallies {
+			entries = append(entries, &model.BracketEntry{
+				BracketID: current.ID,
+				VotingID:  params.VotingID,
+				MovieID:   tally.MovieID,
+				Group:     params.Group,
+				Votes:     tally.Votes,
+				Advanced:  advanced[tally.MovieID],
+			})
+		}
+		err = s.bracketRepo.CreateEntries(&repository.CreateBracketEntriesParams{Entries: entries, Tx: tx})
+		if err != nil {
+			return err
+		}
+		active, err := s.bracketRepo.CountActiveGroups(&repository.CountActiveBracketGroupsParams{BracketID: current.ID, Tx: tx})
+		if err != nil {
+			return err
+		}
+		if active > 0 || current.Stage != model.BRACKET_GROUPS_STAGE {
+			return nil
+		}
+		err = s.bracketRepo.UpdateStage(&repository.UpdateBracketStageParams{
+			BracketID: current.ID,
+			Stage:     model.BRACKET_FINAL_STAGE,
+			Tx:        tx,
+		})
+		if err != nil {
+			return err
+		}
+		finalists, err := s.bracketRepo.FindEntries(&repository.FindBracketEntriesParams{
+			BracketID:    current.ID,
+			AdvancedOnly: true,
+			Tx:           tx,
+		})
+		if err != nil {
+			return err
+		}
+		current.Stage = model.BRACKET_FINAL_STAGE
+		for _, entry := range finalists {
+			current.Entries = append(current.Entries, *entry)
+		}
+		final = current
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	return poll, nil
+	return final, nil
+}
+
+func (s *VotingService) GetBracketEntries(bracketID int64) ([]*model.BracketEntry, error) {
+	return s.bracketRepo.FindEntries(&repository.FindBracketEntriesParams{BracketID: bracketID})
+}
+
+func (s *VotingService) GetVotingByID(id int64) (*model.Voting, error) {
+	return s.repo.FindVotingByID(id)
+}
+
+// GetRunoff returns the runoff of the tied voting, nil when it hasn't been started.
+func (s *VotingService) GetRunoff(parentID int64) (*model.Voting, error) {
+	return s.repo.FindRunoff(parentID)
 }
 
 func (s *VotingService) FindVotingByStatus(status string) ([]*model.Voting, error) {
 	return s.repo.FindVotingsByStatus(status)
 }
+
+func (s *VotingService) FindClosedVotingsSince(since int64) ([]*model.Voting, error) {
+	return s.repo.FindClosedVotingsSince(since)
+}
+
+func rankedBallotText(question string, options []models.InputPollOption) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🗳️ %s\n\n", question))
+	sb.WriteString("Рейтинговое голосование: нажимайте на фильмы в порядке предпочтения, начиная с самого желанного. ")
+	sb.WriteString("Можно ранжировать не все фильмы.\n\n")
+	for i, option := range options {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, option.Text))
+	}
+	return sb.String()
+}
+
+func secretBallotText(question string, votingType string, options []models.InputPollOption) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔒 Тайное голосование: %s\n\n", question))
+	if votingType == model.VOTING_SELECTION_TYPE {
+		for i, option := range options {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, option.Text))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Голосование проходит в личных сообщениях с ботом, никто не увидит ваш выбор. ")
+	sb.WriteString("После завершения будут опубликованы только общие итоги.")
+	return sb.String()
+}
+
+func RankedBallotKeyboard(votingID int64, options []models.InputPollOption) *models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(options)+1)
+	for i, option := range options {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         option.Text,
+			CallbackData: fmt.Sprintf("%s%d_%d", RANKED_BALLOT_PREFIX, votingID, i),
+		}})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{
+		Text:         "🔄 Сбросить мой выбор",
+		CallbackData: fmt.Sprintf("%s%d_reset", RANKED_BALLOT_PREFIX, votingID),
+	}})
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"log"
+	"strings"
 	"time"
 
 	"strconv"
 
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/media"
 	"github.com/go-telegram/bot"
 	"github.com/hibiken/asynq"
 )
@@ -21,6 +26,8 @@ type CloseRatingVotingTaskProcessor struct {
 	votingService service.IVotingService
 	voteService   service.IVoteService
 	movieService  service.IMovieService
+	client        *asynq.Client
+	cfg           *config.VotingConfig
 }
 
 type CloseRatingVotingPayload struct {
@@ -30,10 +37,11 @@ type CloseRatingVotingPayload struct {
 	VotingID  int64  `json:"voting_id"`
 	MovieID   int64  `json:"movie_id"`
 	UserID    int64  `json:"user_id"`
+	Extended  bool   `json:"extended"`
 }
 
-func NewCloseRatingVotingTask(pollID string, messageID int, chatID int64, votingID int64, movieID int64) (*asynq.Task, error) {
-	payload, err := json.Marshal(CloseRatingVotingPayload{PollID: pollID, MessageID: messageID, ChatID: chatID, VotingID: votingID, MovieID: movieID})
+func NewCloseRatingVotingTask(pollID string, messageID int, chatID int64, votingID int64, movieID int64, extended bool) (*asynq.Task, error) {
+	payload, err := json.Marshal(CloseRatingVotingPayload{PollID: pollID, MessageID: messageID, ChatID: chatID, VotingID: votingID, MovieID: movieID, Extended: extended})
 	if err != nil {
 		return nil, err
 	}
@@ -44,12 +52,14 @@ type ICloseRatingVotingTaskProcessor interface {
 	Process() error
 }
 
-func NewCloseRatingVotingTaskProcessor(b *bot.Bot, votingService service.IVotingService, voteService service.IVoteService, movieService service.IMovieService) *CloseRatingVotingTaskProcessor {
+func NewCloseRatingVotingTaskProcessor(b *bot.Bot, votingService service.IVotingService, voteService service.IVoteService, movieService service.IMovieService, client *asynq.Client, cfg *config.VotingConfig) *CloseRatingVotingTaskProcessor {
 	return &CloseRatingVotingTaskProcessor{
 		b:             b,
 		votingService: votingService,
 		voteService:   voteService,
 		movieService:  movieService,
+		client:        client,
+		cfg:           cfg,
 	}
 }
 
@@ -59,12 +69,12 @@ type EnqueueCloseRatingVotingParams struct {
 }
 
 func EnqueueCloseRatingVotingTask(client *asynq.Client, duration time.Duration, params *CloseRatingVotingPayload) error {
-	task, err := NewCloseRatingVotingTask(params.PollID, params.MessageID, params.ChatID, params.VotingID, params.MovieID)
+	task, err := NewCloseRatingVotingTask(params.PollID, params.MessageID, params.ChatID, params.VotingID, params.MovieID, params.Extended)
 	if err != nil {
 		log.Printf("Error creating close rating voting task: %v", err)
 		return err
 	}
-	scheduleOpts := []asynq.Option{asynq.MaxRetry(1), asynq.ProcessIn(duration), asynq.TaskID(fmt.Sprintf("%s-%d", CloseRatingVotingTaskType, params.VotingID)), asynq.Queue(QUEUE)}
+	scheduleOpts := []asynq.Option{asynq.MaxRetry(1), asynq.ProcessIn(duration), asynq.TaskID(CloseVotingTaskID(model.VOTING_RATING_TYPE, params.VotingID, params.Extended)), asynq.Queue(QUEUE)}
 	taskInfo, err := client.Enqueue(task, scheduleOpts...)
 	if err != nil {
 		log.Printf("Error scheduling voting end task: %v", err)
@@ -79,6 +89,38 @@ func (t *CloseRatingVotingTaskProcessor) Process(ctx context.Context, task *asyn
 	if err := json.Unmarshal(task.Payload(), &p); err != nil {
 		return err
 	}
+	voting, err := t.votingService.GetVotingByID(p.VotingID)
+	if err != nil {
+		log.Printf("Error getting voting by ID: %v", err)
+		return err
+	}
+	if voting.Status != model.VOTING_ACTIVE_STATUS {
+		log.Printf("Voting %d is not active anymore", voting.ID)
+		return nil
+	}
+	quorum, err := t.votingService.CheckQuorum(voting)
+	if err != nil {
+		log.Printf("Error checking quorum: %v", err)
+		return err
+	}
+	if quorum.Outcome == model.QUORUM_EXTENDED_OUTCOME {
+		p.Extended = true
+		return extendVoting(ctx, t.b, t.votingService, t.cfg, p.ChatID, voting, quorum, func() error {
+			if err := EnqueueCloseRatingVotingTask(t.client, t.cfg.QuorumExtension, &p); err != nil {
+				return err
+			}
+			err := EnqueueRemindVotingTask(t.client, &EnqueueRemindVotingParams{
+				ChatID:   p.ChatID,
+				VotingID: voting.ID,
+				Duration: t.cfg.QuorumExtension,
+				Offset:   t.cfg.ReminderOffset,
+			})
+			if err != nil {
+				log.Printf("Error scheduling remind voting task: %v", err)
+			}
+			return nil
+		})
+	}
 	ok, err := t.b.DeleteMessage(ctx, &bot.DeleteMessageParams{
 		ChatID:    p.ChatID,
 		MessageID: p.MessageID,
@@ -86,16 +128,25 @@ func (t *CloseRatingVotingTaskProcessor) Process(ctx context.Context, task *asyn
 	if err != nil || !ok {
 		log.Println("Message doesn't exist or couldn't be deleted")
 	}
-	mean, err := t.voteService.CalculateRatingMean(p.VotingID)
+	if quorum.Outcome == model.QUORUM_CANCELLED_OUTCOME {
+		return cancelVotingByQuorum(ctx, t.b, t.votingService, p.ChatID, p.PollID, voting, quorum)
+	}
+	ratings, err := t.voteService.GetRatings(p.VotingID)
 	if err != nil {
 		return err
 	}
-	err = t.votingService.FinishRatingVoting(&service.FinishRatingVotingParams{
-		VotingID:  p.VotingID,
-		PollID:    p.PollID,
-		MovieID:   p.MovieID,
-		Mean:      mean,
-		CreatedBy: p.UserID,
+	abstained, err := t.voteService.CountAbstained(p.VotingID)
+	if err != nil {
+		return err
+	}
+	summary, err := t.votingService.FinishRatingVoting(&service.FinishRatingVotingParams{
+		VotingID:      p.VotingID,
+		PollID:        p.PollID,
+		MovieID:       p.MovieID,
+		Ratings:       ratings,
+		PriorWeight:   t.cfg.RatingPriorWeight,
+		CreatedBy:     p.UserID,
+		QuorumOutcome: quorumOutcome(quorum),
 	})
 	if err != nil {
 		return err
@@ -104,16 +155,32 @@ func (t *CloseRatingVotingTaskProcessor) Process(ctx context.Context, task *asyn
 	if err != nil {
 		return err
 	}
-	_, err = t.b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: p.ChatID,
-		Text: "Голосование завершено!\n" +
-			"Фильм для просмотра: 🎬\n" +
-			"<b>" + movie.Title + "</b>\n" +
-			"Средний рейтинг: 🔥 " + strconv.FormatFloat(mean, 'f', 2, 64),
-		ParseMode: "HTML",
-	})
+	card := service.MovieCard(1, movie, "")
+	card.Caption = "Голосование завершено!\n" +
+		"Фильм для просмотра: 🎬\n" +
+		"<b>" + html.EscapeString(movie.Title) + "</b>\n" +
+		formatRatingSummary(summary) +
+		"Смотрели: 👀 " + strconv.Itoa(len(ratings)) + ", не смотрели: 🙈 " + strconv.FormatInt(abstained, 10) +
+		formatQuorum(quorum) + "\n" + card.Caption
+	err = media.Send(ctx, t.b, p.ChatID, "", []media.Card{card})
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+func formatRatingSummary(summary *model.RatingSummary) string {
+	if summary == nil {
+		return "Оценок нет 🤷\n"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Средний рейтинг: 🔥 %.2f\n", summary.Mean))
+	sb.WriteString(fmt.Sprintf("Медиана: %.1f, отклонение: %.2f\n", summary.Median, summary.StdDev))
+	sb.WriteString(fmt.Sprintf("Скорректированный рейтинг: ⭐ %.2f\n", summary.AdjustedScore))
+	sb.WriteString("<pre>")
+	for i := len(summary.Histogram) - 1; i >= 0; i-- {
+		sb.WriteString(fmt.Sprintf("%2d | %s %d\n", i+1, strings.Repeat("█", summary.Histogram[i]), summary.Histogram[i]))
+	}
+	sb.WriteString("</pre>\n")
+	return sb.String()
+}
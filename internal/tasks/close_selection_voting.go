@@ -3,13 +3,23 @@ package tasks
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"log"
+	"math/rand/v2"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/bracket"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/runoff"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/media"
 	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
 	"github.com/hibiken/asynq"
 )
 
@@ -20,8 +30,11 @@ type CloseSelectionVotingTaskProcessor struct {
 	movieService  service.IMovieService
 	votingService service.IVotingService
 	voteService   service.IVoteService
+	pollService   service.IPollService
 	client        *asynq.Client
 	inspector     *asynq.Inspector
+	cfg           *config.VotingConfig
+	sessionCfg    *config.SessionConfig
 }
 
 type CloseSelectionVotingPayload struct {
@@ -30,25 +43,29 @@ type CloseSelectionVotingPayload struct {
 	ChatID    int64  `json:"chat_id"`
 	VotingID  int64  `json:"voting_id"`
 	UserID    int64  `json:"user_id"`
+	Extended  bool   `json:"extended"`
 }
 
 type ICloseSelectionVotingProcessor interface {
 	Process(ctx context.Context, task *asynq.Task) error
 }
 
-func NewCloseSelectionVotingTaskProcessor(b *bot.Bot, votingService service.IVotingService, voteService service.IVoteService, movieService service.IMovieService, inspector *asynq.Inspector, client *asynq.Client) *CloseSelectionVotingTaskProcessor {
+func NewCloseSelectionVotingTaskProcessor(b *bot.Bot, votingService service.IVotingService, voteService service.IVoteService, movieService service.IMovieService, pollService service.IPollService, inspector *asynq.Inspector, client *asynq.Client, cfg *config.VotingConfig, sessionCfg *config.SessionConfig) *CloseSelectionVotingTaskProcessor {
 	return &CloseSelectionVotingTaskProcessor{
 		b:             b,
 		votingService: votingService,
 		voteService:   voteService,
 		movieService:  movieService,
+		pollService:   pollService,
 		inspector:     inspector,
 		client:        client,
+		cfg:           cfg,
+		sessionCfg:    sessionCfg,
 	}
 }
 
-func NewCloseSelectionVotingTask(pollID string, messageID int, chatID int64, votingID int64) (*asynq.Task, error) {
-	payload, err := json.Marshal(CloseSelectionVotingPayload{PollID: pollID, MessageID: messageID, ChatID: chatID, VotingID: votingID})
+func NewCloseSelectionVotingTask(pollID string, messageID int, chatID int64, votingID int64, extended bool) (*asynq.Task, error) {
+	payload, err := json.Marshal(CloseSelectionVotingPayload{PollID: pollID, MessageID: messageID, ChatID: chatID, VotingID: votingID, Extended: extended})
 	if err != nil {
 		return nil, err
 	}
@@ -56,12 +73,12 @@ func NewCloseSelectionVotingTask(pollID string, messageID int, chatID int64, vot
 }
 
 func EnqueueCloseSelectionVotingTask(client *asynq.Client, duration time.Duration, params *CloseSelectionVotingPayload) error {
-	task, err := NewCloseSelectionVotingTask(params.PollID, params.MessageID, params.ChatID, params.VotingID)
+	task, err := NewCloseSelectionVotingTask(params.PollID, params.MessageID, params.ChatID, params.VotingID, params.Extended)
 	if err != nil {
 		log.Printf("Error creating close selection voting task: %v", err)
 		return err
 	}
-	scheduleOpts := []asynq.Option{asynq.MaxRetry(1), asynq.ProcessIn(duration), asynq.TaskID(fmt.Sprintf("%s-%d", CloseSelectionVotingTaskType, params.VotingID)), asynq.Queue(QUEUE)}
+	scheduleOpts := []asynq.Option{asynq.MaxRetry(1), asynq.ProcessIn(duration), asynq.TaskID(CloseVotingTaskID(model.VOTING_SELECTION_TYPE, params.VotingID, params.Extended)), asynq.Queue(QUEUE)}
 	taskInfo, err := client.Enqueue(task, scheduleOpts...)
 	if err != nil {
 		log.Printf("Error scheduling voting end task: %v", err)
@@ -76,6 +93,36 @@ func (t *CloseSelectionVotingTaskProcessor) Process(ctx context.Context, task *a
 	if err := json.Unmarshal(task.Payload(), &p); err != nil {
 		return err
 	}
+	voting, err := t.votingService.GetVotingByID(p.VotingID)
+	if err != nil {
+		log.Printf("Error getting voting by ID: %v", err)
+		return err
+	}
+	if voting.Status != model.VOTING_ACTIVE_STATUS {
+		log.Printf("Voting %d is not active anymore", voting.ID)
+		return nil
+	}
+	if voting.BracketID != nil && voting.BracketGroup > 0 {
+		// The quorum of a bracket is checked only in the final
+		return t.closeBracketGroup(ctx, &p, voting)
+	}
+	quorum, err := t.votingService.CheckQuorum(voting)
+	if err != nil {
+		log.Printf("Error checking quorum: %v", err)
+		return err
+	}
+	if quorum.Outcome == model.QUORUM_EXTENDED_OUTCOME {
+		p.Extended = true
+		return extendVoting(ctx, t.b, t.votingService, t.cfg, p.ChatID, voting, quorum, func() error {
+			if err := EnqueueCloseSelectionVotingTask(t.client, t.cfg.QuorumExtension, &p); err != nil {
+				return err
+			}
+			if err := t.enqueueReminder(p.ChatID, voting.ID, t.cfg.QuorumExtension); err != nil {
+				log.Printf("Error scheduling remind voting task: %v", err)
+			}
+			return nil
+		})
+	}
 	ok, err := t.b.DeleteMessage(ctx, &bot.DeleteMessageParams{
 		ChatID:    p.ChatID,
 		MessageID: p.MessageID,
@@ -83,15 +130,56 @@ func (t *CloseSelectionVotingTaskProcessor) Process(ctx context.Context, task *a
 	if err != nil || !ok {
 		log.Println("Message doesn't exist or couldn't be deleted")
 	}
-	count, movieID, err := t.voteService.CalculateMaxMovieCount(p.VotingID)
-	if err != nil {
-		log.Printf("Error calculating max movie count: %v", err)
-		return err
+	if quorum.Outcome == model.QUORUM_CANCELLED_OUTCOME {
+		return cancelVotingByQuorum(ctx, t.b, t.votingService, p.ChatID, p.PollID, voting, quorum)
+	}
+	var count int64
+	var movieIDs []int64
+	var rounds string
+	if voting.Method == model.VOTING_METHOD_RANKED {
+		result, err := t.voteService.CalculateInstantRunoff(p.VotingID)
+		if err != nil {
+			log.Printf("Error calculating instant runoff: %v", err)
+			return err
+		}
+		if len(result.Winners) > 0 {
+			movieIDs = result.Winners
+			lastRound := result.Rounds[len(result.Rounds)-1]
+			for _, tally := range lastRound.Tallies {
+				if tally.MovieID == movieIDs[0] {
+					count = int64(tally.Votes)
+				}
+			}
+			rounds = t.formatRunoffRounds(result)
+		}
+	} else {
+		count, movieIDs, err = t.voteService.CalculateTopMovies(p.VotingID)
+		if err != nil {
+			log.Printf("Error calculating top movies: %v", err)
+			return err
+		}
+		if voting.Secret {
+			// Nobody saw the secret ballots, so the totals are published at close
+			rounds = t.formatTally(p.VotingID)
+		}
 	}
-	if count == 0 || movieID == 0 {
+	if count == 0 || len(movieIDs) == 0 {
 		log.Println("No votes were cast or no movie selected")
 		return nil
 	}
+	movieID := movieIDs[0]
+	var fallback string
+	if len(movieIDs) > 1 {
+		if voting.RunoffRound < t.cfg.MaxRunoffs {
+			return t.startRunoff(ctx, &p, voting, movieIDs, rounds, quorum)
+		}
+		movieID = movieIDs[rand.IntN(len(movieIDs))]
+		fallback = "\n🎲 Лимит переголосований исчерпан, победитель выбран случайно среди лидеров."
+	}
+	var path string
+	if voting.BracketID != nil {
+		path = t.formatBracketPath(*voting.BracketID, movieID, count)
+	}
 	log.Printf("Max movie count: %d for movie ID: %d", count, movieID)
 	movie, err := t.movieService.GetMovieByID(movieID)
 	if err != nil {
@@ -99,54 +187,416 @@ func (t *CloseSelectionVotingTaskProcessor) Process(ctx context.Context, task *a
 		return err
 	}
 	session, created, err := t.votingService.FinishSelectionVoting(&service.FinishSelectionVotingParams{
-		VotingID:  p.VotingID,
-		PollID:    p.PollID,
-		MovieID:   movie.ID,
-		CreatedBy: p.UserID,
+		VotingID:      p.VotingID,
+		PollID:        p.PollID,
+		MovieID:       movie.ID,
+		SessionID:     voting.SessionID,
+		CreatedBy:     p.UserID,
+		QuorumOutcome: quorumOutcome(quorum),
 	})
 	if err != nil {
 		log.Printf("Error finishing selection voting: %v", err)
 		return err
 	}
-	_, err = t.b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: p.ChatID,
-		Text:   "Финальное решение принято! Победил фильм: " + movie.Title + "; с количеством голосов: " + strconv.FormatInt(count, 10),
-	})
+	decision := "Финальное решение принято! Победил фильм: " + movie.Title + "; с количеством голосов: " + strconv.FormatInt(count, 10) + rounds + fallback + path + formatQuorum(quorum)
+	card := service.MovieCard(1, movie, "")
+	card.Caption = html.EscapeString(decision) + "\n" + card.Caption
+	err = media.Send(ctx, t.b, p.ChatID, "", []media.Card{card})
 	if err != nil {
 		log.Printf("Error sending final decision message: %v", err)
 		return err
 	}
-	duration := time.Until(time.Unix(session.FinishedAt, 0))
 	if created {
-		err = EnqueueFinishSessionTask(t.client, &EnqueueFinishSessionParams{
-			SessionID: session.ID,
-			Duration:  duration,
+		err = EnqueueRemindSessionTasks(t.client, &EnqueueRemindSessionParams{
+			ChatID:     p.ChatID,
+			SessionID:  session.ID,
+			FinishedAt: session.FinishedAt,
+			Offsets:    t.sessionCfg.ReminderOffsets,
+		})
+		if err != nil {
+			log.Printf("Error scheduling remind session tasks: %v", err)
+		}
+	}
+	lineup, err := t.movieService.GetLineup(session.ID)
+	if err != nil {
+		log.Printf("Error getting lineup of session %d: %v", session.ID, err)
+		return nil
+	}
+	// The winner lengthens the lineup, the session finishes after it
+	err = ScheduleFinishSessionTask(t.client, t.inspector, &ScheduleFinishSessionParams{
+		SessionID: session.ID,
+		StartsAt:  session.FinishedAt,
+		Lineup:    lineup,
+	})
+	if err != nil {
+		log.Printf("Error scheduling finish session task: %v", err)
+	} else {
+		log.Printf("Scheduled finish session task for session: %d", session.ID)
+	}
+	err = ScheduleOpenRatingVotingTasks(t.client, t.inspector, &ScheduleOpenRatingVotingsParams{
+		ChatID:    p.ChatID,
+		SessionID: session.ID,
+		UserID:    p.UserID,
+		Lineup:    lineup,
+	})
+	if err != nil {
+		log.Printf("Error scheduling open rating voting tasks: %v", err)
+	} else {
+		log.Printf("Scheduled open rating voting tasks for session: %d", session.ID)
+	}
+	return nil
+}
+
+func (t *CloseSelectionVotingTaskProcessor) enqueueReminder(chatID int64, votingID int64, duration time.Duration) error {
+	return EnqueueRemindVotingTask(t.client, &EnqueueRemindVotingParams{
+		ChatID:   chatID,
+		VotingID: votingID,
+		Duration: duration,
+		Offset:   t.cfg.ReminderOffset,
+	})
+}
+
+// startRunoff closes the tied voting and opens a short plurality poll with only the leaders.
+func (t *CloseSelectionVotingTaskProcessor) startRunoff(ctx context.Context, p *CloseSelectionVotingPayload, voting *model.Voting, movieIDs []int64, rounds string, quorum *service.QuorumResult) error {
+	var titles []string
+	var pollOpts []models.InputPollOption
+	for _, movieID := range movieIDs {
+		movie, err := t.movieService.GetMovieByID(movieID)
+		if err != nil {
+			log.Printf("Error getting movie by ID: %v", err)
+			return err
+		}
+		title := fmt.Sprintf("%s (%d)", movie.Title, movie.Year)
+		titles = append(titles, title)
+		if voting.Secret {
+			// The secret ballot announcement is plain text
+			pollOpts = append(pollOpts, models.InputPollOption{Text: title})
+			continue
+		}
+		pollOpts = append(pollOpts, models.InputPollOption{Text: bot.EscapeMarkdownUnescaped(title), TextParseMode: models.ParseModeMarkdown})
+	}
+	title := fmt.Sprintf("Переголосование: %s", voting.Title)
+	// A retried task finds the runoff of the previous attempt and only completes the steps after it
+	runoffVoting, err := t.votingService.GetRunoff(voting.ID)
+	if err != nil {
+		log.Printf("Error getting runoff voting: %v", err)
+		return err
+	}
+	var poll *model.Poll
+	if runoffVoting != nil {
+		poll, err = t.pollService.GetPollByVotingID(runoffVoting.ID)
+		if err != nil {
+			log.Printf("Error getting runoff poll: %v", err)
+			return err
+		}
+	} else {
+		AnnounceMovies(ctx, t.b, t.movieService, p.ChatID, fmt.Sprintf("⚖️ %s:", html.EscapeString(title)), movieIDs)
+		finishedAt := time.Now().Add(t.cfg.RunoffDuration).Unix()
+		poll, err = t.votingService.StartVoting(&service.StartRatingVotingParams{
+			Bot:     t.b,
+			Context: ctx,
+			ChatID:  p.ChatID,
+			Options: service.VotingOptions{
+				Title:       title,
+				Type:        model.VOTING_SELECTION_TYPE,
+				Method:      model.VOTING_METHOD_PLURALITY,
+				CreatedBy:   voting.CreatedBy,
+				FinishedAt:  &finishedAt,
+				SessionID:   voting.SessionID,
+				ParentID:    &voting.ID,
+				RunoffRound: voting.RunoffRound + 1,
+				Secret:      voting.Secret,
+				BracketID:   voting.BracketID,
+			},
+			PollOptions: pollOpts,
+			Question:    title,
 		})
 		if err != nil {
-			log.Printf("Error scheduling new finish session task: %v", err)
-		} else {
-			log.Printf("Scheduled new finish session task for session: %d", session.ID)
+			log.Printf("Error starting runoff voting: %v", err)
+			return err
+		}
+	}
+	options, err := t.pollService.GetPollOptionsByPollID(poll.ID)
+	if err != nil {
+		log.Printf("Error getting runoff poll options: %v", err)
+		return err
+	}
+	saved := make(map[int]bool, len(options))
+	for _, option := range options {
+		saved[option.OptionIndex] = true
+	}
+	for optionIndex, movieID := range movieIDs {
+		if saved[optionIndex] {
+			continue
+		}
+		err := t.pollService.CreatePollOption(&model.PollOption{
+			PollID:      poll.ID,
+			OptionIndex: optionIndex,
+			MovieID:     movieID,
+		})
+		if err != nil {
+			log.Printf("Error saving poll option: %v", err)
+			return err
+		}
+	}
+	if err := t.votingService.FinishVoting(p.VotingID, p.PollID, quorumOutcome(quorum)); err != nil {
+		log.Printf("Error finishing tied voting: %v", err)
+		return err
+	}
+	err = EnqueueCloseSelectionVotingTask(t.client, t.cfg.RunoffDuration, &CloseSelectionVotingPayload{
+		PollID:    poll.PollID,
+		MessageID: poll.MessageID,
+		ChatID:    p.ChatID,
+		VotingID:  poll.VotingID,
+		UserID:    p.UserID,
+	})
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		log.Printf("Error scheduling close runoff voting task: %v", err)
+		return err
+	}
+	if err := t.enqueueReminder(p.ChatID, poll.VotingID, t.cfg.RunoffDuration); err != nil {
+		log.Printf("Error scheduling remind voting task: %v", err)
+	}
+	_, err = t.b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: p.ChatID,
+		Text:   fmt.Sprintf("⚖️ Ничья между: %s%s\n\nЗапускаю переголосование на %d мин.!", strings.Join(titles, ", "), rounds, int(t.cfg.RunoffDuration.Minutes())),
+	})
+	if err != nil {
+		log.Printf("Error sending runoff message: %v", err)
+	}
+	return nil
+}
+
+func (t *CloseSelectionVotingTaskProcessor) formatTally(votingID int64) string {
+	counts, err := t.voteService.CountVotesByMovie(votingID)
+	if err != nil {
+		log.Printf("Error counting votes: %v", err)
+		return ""
+	}
+	poll, err := t.pollService.GetPollByVotingID(votingID)
+	if err != nil {
+		log.Printf("Error getting poll by voting ID: %v", err)
+		return ""
+	}
+	options, err := t.pollService.GetPollOptionsByPollID(poll.ID)
+	if err != nil {
+		log.Printf("Error getting poll options: %v", err)
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n🔒 Итоги тайного голосования:")
+	for _, option := range options {
+		sb.WriteString(fmt.Sprintf("\n• %s — %d", option.Movie.Title, counts[option.MovieID]))
+	}
+	return sb.String()
+}
+
+func (t *CloseSelectionVotingTaskProcessor) formatRunoffRounds(result *runoff.Result) string {
+	titles := make(map[int64]string)
+	title := func(movieID int64) string {
+		if _, ok := titles[movieID]; !ok {
+			titles[movieID] = strconv.FormatInt(movieID, 10)
+			movie, err := t.movieService.GetMovieByID(movieID)
+			if err != nil {
+				log.Printf("Error getting movie by ID: %v", err)
+			} else {
+				titles[movieID] = movie.Title
+			}
+		}
+		return titles[movieID]
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n🗳️ Раунды подсчёта:")
+	for i, round := range result.Rounds {
+		tallies := make([]string, 0, len(round.Tallies))
+		for _, tally := range round.Tallies {
+			tallies = append(tallies, fmt.Sprintf("%s — %d", title(tally.MovieID), tally.Votes))
+		}
+		sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, strings.Join(tallies, ", ")))
+		if len(round.Eliminated) > 0 {
+			eliminated := make([]string, 0, len(round.Eliminated))
+			for _, movieID := range round.Eliminated {
+				eliminated = append(eliminated, title(movieID))
+			}
+			sb.WriteString(fmt.Sprintf(". Выбывает: %s", strings.Join(eliminated, ", ")))
+		}
+	}
+	return sb.String()
+}
+
+// closeBracketGroup saves the results of the group poll and starts the final
+// once every group of the bracket is closed.
+func (t *CloseSelectionVotingTaskProcessor) closeBracketGroup(ctx context.Context, p *CloseSelectionVotingPayload, voting *model.Voting) error {
+	ok, err := t.b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    p.ChatID,
+		MessageID: p.MessageID,
+	})
+	if err != nil || !ok {
+		log.Println("Message doesn't exist or couldn't be deleted")
+	}
+	counts, err := t.voteService.CountVotesByMovie(voting.ID)
+	if err != nil {
+		log.Printf("Error counting votes: %v", err)
+		return err
+	}
+	poll, err := t.pollService.GetPollByVotingID(voting.ID)
+	if err != nil {
+		log.Printf("Error getting poll by voting ID: %v", err)
+		return err
+	}
+	options, err := t.pollService.GetPollOptionsByPollID(poll.ID)
+	if err != nil {
+		log.Printf("Error getting poll options: %v", err)
+		return err
+	}
+	tallies := make([]bracket.Tally, 0, len(options))
+	for _, option := range options {
+		tallies = append(tallies, bracket.Tally{MovieID: option.MovieID, Votes: counts[option.MovieID]})
+	}
+	final, err := t.votingService.AdvanceBracket(&service.AdvanceBracketParams{
+		BracketID: *voting.BracketID,
+		VotingID:  voting.ID,
+		PollID:    p.PollID,
+		Group:     voting.BracketGroup,
+		Tallies:   tallies,
+	})
+	if err != nil {
+		log.Printf("Error advancing bracket: %v", err)
+		return err
+	}
+	entries, err := t.votingService.GetBracketEntries(*voting.BracketID)
+	if err != nil {
+		log.Printf("Error getting bracket entries: %v", err)
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🏁 Голосование \"%s\" завершено:", voting.Title))
+	for _, entry := range entries {
+		if entry.VotingID != voting.ID {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n• %s — %d", entry.Movie.Title, entry.Votes))
+		if entry.Advanced {
+			sb.WriteString(" ✅ в финале")
 		}
 	}
-	taskId := fmt.Sprintf("%s-%d-%d", OpenRatingVotingTaskType, session.ID, movie.ID)
-	taskInfo, err := t.inspector.GetTaskInfo(QUEUE, taskId)
+	_, err = t.b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: p.ChatID,
+		Text:   sb.String(),
+	})
 	if err != nil {
-		log.Printf("Error getting task info: %v", err)
+		log.Printf("Error sending group results message: %v", err)
 	}
-	if taskInfo == nil {
-		err = EnqueueOpenRatingVotingTask(t.client, &EnqueueOpenRatingVotingParams{
-			ChatID:    p.ChatID,
-			SessionID: session.ID,
-			Movie:     *movie,
-			UserID:    p.UserID,
-			TaskID:    taskId,
-			Duration:  duration,
+	if final == nil {
+		return nil
+	}
+	return t.startBracketFinal(ctx, p, voting, final)
+}
+
+// startBracketFinal opens the final poll with the movies advanced from the groups.
+// It lasts as long as the group polls and keeps their quorum settings.
+func (t *CloseSelectionVotingTaskProcessor) startBracketFinal(ctx context.Context, p *CloseSelectionVotingPayload, group *model.Voting, final *model.Bracket) error {
+	var titles []string
+	var pollOpts []models.InputPollOption
+	for _, entry := range final.Entries {
+		title := fmt.Sprintf("%s (%d)", entry.Movie.Title, entry.Movie.Year)
+		titles = append(titles, title)
+		pollOpts = append(pollOpts, models.InputPollOption{Text: bot.EscapeMarkdownUnescaped(title), TextParseMode: models.ParseModeMarkdown})
+	}
+	duration := t.cfg.RunoffDuration
+	if group.FinishedAt != nil && time.Unix(*group.FinishedAt, 0).After(group.CreatedAt) {
+		duration = time.Unix(*group.FinishedAt, 0).Sub(group.CreatedAt)
+	}
+	finishedAt := time.Now().Add(duration).Unix()
+	title := fmt.Sprintf("Финал: %s", final.Title)
+	finalists := make([]int64, len(final.Entries))
+	for i, entry := range final.Entries {
+		finalists[i] = entry.MovieID
+	}
+	AnnounceMovies(ctx, t.b, t.movieService, p.ChatID, fmt.Sprintf("🏆 %s:", html.EscapeString(title)), finalists)
+	multi := true
+	poll, err := t.votingService.StartVoting(&service.StartRatingVotingParams{
+		Bot:     t.b,
+		Context: ctx,
+		ChatID:  p.ChatID,
+		Options: service.VotingOptions{
+			Title:        title,
+			Type:         model.VOTING_SELECTION_TYPE,
+			Method:       model.VOTING_METHOD_PLURALITY,
+			CreatedBy:    final.CreatedBy,
+			FinishedAt:   &finishedAt,
+			QuorumType:   group.QuorumType,
+			QuorumValue:  group.QuorumValue,
+			QuorumPolicy: group.QuorumPolicy,
+			HideTally:    group.HideTally,
+			SessionID:    group.SessionID,
+			BracketID:    &final.ID,
+		},
+		Multi:       &multi,
+		PollOptions: pollOpts,
+		Question:    title,
+	})
+	if err != nil {
+		log.Printf("Error starting bracket final: %v", err)
+		return err
+	}
+	for optionIndex, entry := range final.Entries {
+		err := t.pollService.CreatePollOption(&model.PollOption{
+			PollID:      poll.ID,
+			OptionIndex: optionIndex,
+			MovieID:     entry.MovieID,
 		})
 		if err != nil {
-			log.Printf("Error scheduling new open rating voting task: %v", err)
-		} else {
-			log.Printf("Scheduled new open rating voting task for session: %d", session.ID)
+			log.Printf("Error saving poll option: %v", err)
+			return err
 		}
 	}
+	err = EnqueueCloseSelectionVotingTask(t.client, duration, &CloseSelectionVotingPayload{
+		PollID:    poll.PollID,
+		MessageID: poll.MessageID,
+		ChatID:    p.ChatID,
+		VotingID:  poll.VotingID,
+		UserID:    p.UserID,
+	})
+	if err != nil {
+		log.Printf("Error scheduling close bracket final task: %v", err)
+		return err
+	}
+	if err := t.enqueueReminder(p.ChatID, poll.VotingID, duration); err != nil {
+		log.Printf("Error scheduling remind voting task: %v", err)
+	}
+	_, err = t.b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: p.ChatID,
+		Text:   fmt.Sprintf("🏆 Все группы завершены! В финал вышли: %s", strings.Join(titles, ", ")),
+	})
+	if err != nil {
+		log.Printf("Error sending bracket final message: %v", err)
+	}
 	return nil
 }
+
+// formatBracketPath shows how the winner of the final got through its group.
+func (t *CloseSelectionVotingTaskProcessor) formatBracketPath(bracketID int64, movieID int64, finalVotes int64) string {
+	entries, err := t.votingService.GetBracketEntries(bracketID)
+	if err != nil {
+		log.Printf("Error getting bracket entries: %v", err)
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.MovieID != movieID || !entry.Advanced {
+			continue
+		}
+		place, size := 1, 0
+		for _, other := range entries {
+			if other.Group != entry.Group {
+				continue
+			}
+			size++
+			if other.Votes > entry.Votes {
+				place++
+			}
+		}
+		return fmt.Sprintf("\n\n🏟️ Путь к победе: группа %d — %d гол. (%d место из %d) → финал — %d гол.", entry.Group, entry.Votes, place, size, finalVotes)
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/media"
+	"github.com/go-telegram/bot"
+	"github.com/hibiken/asynq"
+)
+
+// CloseVotingTaskID returns the ID of the task closing the voting.
+// An extended voting gets a separate task, because the original one is still running
+// when the extension is scheduled.
+func CloseVotingTaskID(votingType string, votingID int64, extended bool) string {
+	taskType := CloseSelectionVotingTaskType
+	if votingType == model.VOTING_RATING_TYPE {
+		taskType = CloseRatingVotingTaskType
+	}
+	taskID := fmt.Sprintf("%s-%d", taskType, votingID)
+	if extended {
+		taskID += "-ext"
+	}
+	return taskID
+}
+
+// DeleteCloseVotingTask removes the pending close task and the reminder of the voting and returns
+// the close task info, so the caller can clean up the poll message from the payload.
+func DeleteCloseVotingTask(inspector *asynq.Inspector, voting *model.Voting) (*asynq.TaskInfo, error) {
+	if err := DeleteRemindVotingTask(inspector, voting.ID); err != nil {
+		log.Printf("Error deleting remind voting task: %v", err)
+	}
+	var lastErr error
+	for _, extended := range []bool{true, false} {
+		taskInfo, err := inspector.GetTaskInfo(QUEUE, CloseVotingTaskID(voting.Type, voting.ID, extended))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if err := inspector.DeleteTask(taskInfo.Queue, taskInfo.ID); err != nil {
+			return nil, err
+		}
+		return taskInfo, nil
+	}
+	return nil, lastErr
+}
+
+// RunCloseVotingTask moves the scheduled close task of the voting to the pending state,
+// so the worker closes the voting right away. The reminder is not needed anymore.
+func RunCloseVotingTask(inspector *asynq.Inspector, voting *model.Voting) error {
+	if err := DeleteRemindVotingTask(inspector, voting.ID); err != nil {
+		log.Printf("Error deleting remind voting task: %v", err)
+	}
+	var lastErr error
+	for _, extended := range []bool{true, false} {
+		err := inspector.RunTask(QUEUE, CloseVotingTaskID(voting.Type, voting.ID, extended))
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// RescheduleCloseVotingTask moves the close task of the voting to the given time.
+// Asynq can't change the time of a scheduled task, so it is enqueued again with the same ID and payload.
+// The reminder is deleted as well and has to be enqueued again by the caller.
+func RescheduleCloseVotingTask(inspector *asynq.Inspector, client *asynq.Client, voting *model.Voting, processAt time.Time) error {
+	taskInfo, err := DeleteCloseVotingTask(inspector, voting)
+	if err != nil {
+		return err
+	}
+	task := asynq.NewTask(taskInfo.Type, taskInfo.Payload)
+	scheduleOpts := []asynq.Option{asynq.MaxRetry(1), asynq.ProcessAt(processAt), asynq.TaskID(taskInfo.ID), asynq.Queue(QUEUE)}
+	newTaskInfo, err := client.Enqueue(task, scheduleOpts...)
+	if err != nil {
+		return err
+	}
+	log.Printf("Rescheduled voting end task: %s", newTaskInfo.ID)
+	return nil
+}
+
+// extendVoting keeps the poll open once more when the quorum is not met.
+func extendVoting(ctx context.Context, b *bot.Bot, votingService service.IVotingService, cfg *config.VotingConfig, chatID int64, voting *model.Voting, quorum *service.QuorumResult, enqueue func() error) error {
+	finishedAt := time.Now().Add(cfg.QuorumExtension).Unix()
+	if err := votingService.ExtendVoting(voting.ID, finishedAt); err != nil {
+		log.Printf("Error extending voting: %v", err)
+		return err
+	}
+	if err := enqueue(); err != nil {
+		log.Printf("Error scheduling extended close voting task: %v", err)
+		return err
+	}
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   fmt.Sprintf("⏳ Голосование \"%s\" продлено на %d мин.: кворум не набран (%d из %d).", voting.Title, int(cfg.QuorumExtension.Minutes()), quorum.Voters, quorum.Required),
+	})
+	if err != nil {
+		log.Printf("Error sending voting extension message: %v", err)
+	}
+	return nil
+}
+
+func cancelVotingByQuorum(ctx context.Context, b *bot.Bot, votingService service.IVotingService, chatID int64, pollID string, voting *model.Voting, quorum *service.QuorumResult) error {
+	if err := votingService.CancelVotingByQuorum(voting.ID, pollID); err != nil {
+		log.Printf("Error cancelling voting: %v", err)
+		return err
+	}
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   fmt.Sprintf("🚫 Голосование \"%s\" отменено: кворум не набран (%d из %d).", voting.Title, quorum.Voters, quorum.Required),
+	})
+	if err != nil {
+		log.Printf("Error sending voting cancellation message: %v", err)
+	}
+	return nil
+}
+
+func quorumOutcome(result *service.QuorumResult) *string {
+	if result.Outcome == "" {
+		return nil
+	}
+	return &result.Outcome
+}
+
+func formatQuorum(result *service.QuorumResult) string {
+	switch result.Outcome {
+	case model.QUORUM_MET_OUTCOME:
+		return fmt.Sprintf("\n👥 Кворум набран: %d из %d", result.Voters, result.Required)
+	case model.QUORUM_LOW_CONFIDENCE_OUTCOME:
+		return fmt.Sprintf("\n⚠️ Кворум не набран (%d из %d), результат принят с низкой достоверностью", result.Voters, result.Required)
+	}
+	return ""
+}
+
+// AnnounceMovies shows the cards of the movies before the poll about them.
+func AnnounceMovies(ctx context.Context, b *bot.Bot, movieService service.IMovieService, chatID int64, header string, movieIDs []int64) {
+	cards, err := movieService.GetMovieCards(movieIDs)
+	if err != nil {
+		log.Printf("Error getting movie cards: %v", err)
+		return
+	}
+	if err := media.Send(ctx, b, chatID, header, cards); err != nil {
+		log.Printf("Error sending movie cards: %v", err)
+	}
+}
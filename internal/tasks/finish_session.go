@@ -3,6 +3,7 @@ package tasks
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -36,7 +37,7 @@ func EnqueueFinishSessionTask(client *asynq.Client, params *EnqueueFinishSession
 		log.Printf("Error creating finish session task: %v", err)
 		return err
 	}
-	scheduleOpts := []asynq.Option{asynq.MaxRetry(1), asynq.ProcessIn(params.Duration), asynq.TaskID(fmt.Sprintf("%s-%d", FinishSessionTaskType, params.SessionID)), asynq.Queue(QUEUE)}
+	scheduleOpts := []asynq.Option{asynq.MaxRetry(1), asynq.ProcessIn(params.Duration), asynq.TaskID(FinishSessionTaskID(params.SessionID)), asynq.Queue(QUEUE)}
 	taskInfo, err := client.Enqueue(task, scheduleOpts...)
 	if err != nil {
 		log.Printf("Error scheduling session finish task: %v", err)
@@ -46,6 +47,38 @@ func EnqueueFinishSessionTask(client *asynq.Client, params *EnqueueFinishSession
 	return nil
 }
 
+type ScheduleFinishSessionParams struct {
+	SessionID int64
+	StartsAt  int64
+	Lineup    []service.LineupSlot
+}
+
+// ScheduleFinishSessionTask (re)schedules the finish of the session to the end of its last movie,
+// so the session stays upcoming while the lineup is screened.
+func ScheduleFinishSessionTask(client *asynq.Client, inspector *asynq.Inspector, params *ScheduleFinishSessionParams) error {
+	if err := DeleteFinishSessionTask(inspector, params.SessionID); err != nil {
+		return err
+	}
+	return EnqueueFinishSessionTask(client, &EnqueueFinishSessionParams{
+		SessionID: params.SessionID,
+		Duration:  time.Until(service.LineupEnd(time.Unix(params.StartsAt, 0), params.Lineup)),
+	})
+}
+
+// FinishSessionTaskID returns the ID of the task finishing the session.
+func FinishSessionTaskID(sessionID int64) string {
+	return fmt.Sprintf("%s-%d", FinishSessionTaskType, sessionID)
+}
+
+// DeleteFinishSessionTask removes the pending finish task of the session, if there is one.
+func DeleteFinishSessionTask(inspector *asynq.Inspector, sessionID int64) error {
+	err := inspector.DeleteTask(QUEUE, FinishSessionTaskID(sessionID))
+	if err != nil && !errors.Is(err, asynq.ErrTaskNotFound) {
+		return err
+	}
+	return nil
+}
+
 type FinishSessionTaskProcessor struct {
 	sessionService service.ISessionService
 }
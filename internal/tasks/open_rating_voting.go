@@ -3,10 +3,12 @@ package tasks
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
 	"github.com/go-telegram/bot"
@@ -27,8 +29,12 @@ var RATING_VOTING_OPTIONS = []models.InputPollOption{
 	{Text: "8"},
 	{Text: "9"},
 	{Text: "10"},
+	{Text: "Не смотрел(а)"},
 }
 
+// RATING_ABSTAIN_OPTION_ID is the "didn't watch" option, it is counted as attendance only.
+const RATING_ABSTAIN_OPTION_ID = 10
+
 type OpenRatingVotingPayload struct {
 	ChatID    int64       `json:"chat_id"`
 	SessionID int64       `json:"session_id"`
@@ -69,23 +75,79 @@ func EnqueueOpenRatingVotingTask(client *asynq.Client, params *EnqueueOpenRating
 	return nil
 }
 
+func OpenRatingVotingTaskID(sessionID int64, movieID int64) string {
+	return fmt.Sprintf("%s-%d-%d", OpenRatingVotingTaskType, sessionID, movieID)
+}
+
+type ScheduleOpenRatingVotingsParams struct {
+	ChatID    int64
+	SessionID int64
+	UserID    int64
+	Lineup    []service.LineupSlot
+}
+
+// ScheduleOpenRatingVotingTasks (re)schedules the rating voting of every movie of the lineup to open
+// when the movie ends. Movies that have already ended keep their tasks.
+func ScheduleOpenRatingVotingTasks(client *asynq.Client, inspector *asynq.Inspector, params *ScheduleOpenRatingVotingsParams) error {
+	var lastErr error
+	for _, slot := range params.Lineup {
+		duration := time.Until(slot.End)
+		if duration <= 0 {
+			continue
+		}
+		taskID := OpenRatingVotingTaskID(params.SessionID, slot.Movie.ID)
+		err := inspector.DeleteTask(QUEUE, taskID)
+		if err != nil && !errors.Is(err, asynq.ErrTaskNotFound) {
+			log.Printf("Error deleting open rating voting task %s: %v", taskID, err)
+		}
+		err = EnqueueOpenRatingVotingTask(client, &EnqueueOpenRatingVotingParams{
+			ChatID:    params.ChatID,
+			SessionID: params.SessionID,
+			Movie:     *slot.Movie,
+			UserID:    params.UserID,
+			TaskID:    taskID,
+			Duration:  duration,
+		})
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// DeleteOpenRatingVotingTasks removes the pending rating votings of the movies taken out of the session.
+func DeleteOpenRatingVotingTasks(inspector *asynq.Inspector, sessionID int64, movieIDs []int64) error {
+	var lastErr error
+	for _, movieID := range movieIDs {
+		err := inspector.DeleteTask(QUEUE, OpenRatingVotingTaskID(sessionID, movieID))
+		if err != nil && !errors.Is(err, asynq.ErrTaskNotFound) {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
 type OpenRatingVotingTaskProcessor struct {
-	b             *bot.Bot
-	votingService service.IVotingService
-	movieService  service.IMovieService
-	asynqClient   *asynq.Client
+	b              *bot.Bot
+	votingService  service.IVotingService
+	movieService   service.IMovieService
+	sessionService service.ISessionService
+	asynqClient    *asynq.Client
+	cfg            *config.VotingConfig
 }
 
 type IOpenRatingVotingTaskProcessor interface {
 	Process() error
 }
 
-func NewOpenRatingVotingTaskProcessor(b *bot.Bot, votingService service.IVotingService, movieService service.IMovieService, asynqClient *asynq.Client) *OpenRatingVotingTaskProcessor {
+func NewOpenRatingVotingTaskProcessor(b *bot.Bot, votingService service.IVotingService, movieService service.IMovieService, sessionService service.ISessionService, asynqClient *asynq.Client, cfg *config.VotingConfig) *OpenRatingVotingTaskProcessor {
 	return &OpenRatingVotingTaskProcessor{
-		b:             b,
-		asynqClient:   asynqClient,
-		votingService: votingService,
-		movieService:  movieService,
+		b:              b,
+		asynqClient:    asynqClient,
+		votingService:  votingService,
+		movieService:   movieService,
+		sessionService: sessionService,
+		cfg:            cfg,
 	}
 }
 
@@ -94,20 +156,38 @@ func (t *OpenRatingVotingTaskProcessor) Process(ctx context.Context, task *asynq
 	if err := json.Unmarshal(task.Payload(), &p); err != nil {
 		return err
 	}
-	duration := time.Duration(2) * time.Hour
+	duration := t.cfg.RatingDuration
+	session, err := t.sessionService.FindSessionByID(p.SessionID)
+	if err != nil {
+		log.Printf("Error finding session %d: %v", p.SessionID, err)
+		return err
+	}
+	if session.Status == model.SESSION_CANCELLED_STATUS {
+		log.Printf("Session %d is cancelled, rating voting of movie %d is not opened", session.ID, p.Movie.ID)
+		return nil
+	}
+	if session.RatingWindow > 0 {
+		duration = time.Duration(session.RatingWindow) * time.Minute
+	}
 	finishedAt := time.Now().Add(duration).Unix()
 	title := fmt.Sprintf("Оцените фильм: %s", p.Movie.Title)
+	AnnounceMovies(ctx, t.b, t.movieService, p.ChatID, "", []int64{p.Movie.ID})
 	poll, err := t.votingService.StartVoting(&service.StartRatingVotingParams{
 		Bot:     t.b,
 		Context: ctx,
 		ChatID:  p.ChatID,
 		Options: service.VotingOptions{
-			Title:      title,
-			Type:       model.VOTING_RATING_TYPE,
-			CreatedBy:  p.UserID,
-			FinishedAt: &finishedAt,
-			MovieID:    &p.Movie.ID,
-			SessionID:  &p.SessionID,
+			Title:        title,
+			Type:         model.VOTING_RATING_TYPE,
+			CreatedBy:    p.UserID,
+			FinishedAt:   &finishedAt,
+			MovieID:      &p.Movie.ID,
+			SessionID:    &p.SessionID,
+			QuorumType:   t.cfg.QuorumType,
+			QuorumValue:  t.cfg.QuorumValue,
+			QuorumPolicy: t.cfg.QuorumPolicy,
+			HideTally:    t.cfg.HideTally,
+			Secret:       t.cfg.SecretBallot,
 		},
 		PollOptions: RATING_VOTING_OPTIONS,
 		Question:    title,
@@ -126,5 +206,14 @@ func (t *OpenRatingVotingTaskProcessor) Process(ctx context.Context, task *asynq
 	if err != nil {
 		log.Printf("Error scheduling close rating voting task: %v", err)
 	}
+	err = EnqueueRemindVotingTask(t.asynqClient, &EnqueueRemindVotingParams{
+		ChatID:   p.ChatID,
+		VotingID: poll.VotingID,
+		Duration: duration,
+		Offset:   t.cfg.ReminderOffset,
+	})
+	if err != nil {
+		log.Printf("Error scheduling remind voting task: %v", err)
+	}
 	return nil
 }
@@ -0,0 +1,143 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/hibiken/asynq"
+)
+
+const RemindSessionTaskType = "remind_session"
+
+type RemindSessionPayload struct {
+	ChatID     int64         `json:"chat_id"`
+	SessionID  int64         `json:"session_id"`
+	FinishedAt int64         `json:"finished_at"` // time of the session the reminder was scheduled for
+	Offset     time.Duration `json:"offset"`
+}
+
+func NewRemindSessionTask(payload RemindSessionPayload) (*asynq.Task, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(RemindSessionTaskType, data), nil
+}
+
+type EnqueueRemindSessionParams struct {
+	ChatID     int64
+	SessionID  int64
+	FinishedAt int64
+	Offsets    []time.Duration // how long before the session to remind
+}
+
+func RemindSessionTaskID(sessionID int64, offset time.Duration) string {
+	return fmt.Sprintf("%s-%d-%d", RemindSessionTaskType, sessionID, int64(offset.Minutes()))
+}
+
+// EnqueueRemindSessionTasks schedules a reminder for every offset that is still ahead.
+func EnqueueRemindSessionTasks(client *asynq.Client, params *EnqueueRemindSessionParams) error {
+	until := time.Until(time.Unix(params.FinishedAt, 0))
+	for _, offset := range params.Offsets {
+		if offset <= 0 || until <= offset {
+			continue
+		}
+		task, err := NewRemindSessionTask(RemindSessionPayload{
+			ChatID:     params.ChatID,
+			SessionID:  params.SessionID,
+			FinishedAt: params.FinishedAt,
+			Offset:     offset,
+		})
+		if err != nil {
+			log.Printf("Error creating remind session task: %v", err)
+			return err
+		}
+		scheduleOpts := []asynq.Option{asynq.MaxRetry(1), asynq.ProcessIn(until - offset), asynq.TaskID(RemindSessionTaskID(params.SessionID, offset)), asynq.Queue(QUEUE)}
+		taskInfo, err := client.Enqueue(task, scheduleOpts...)
+		if err != nil {
+			log.Printf("Error scheduling remind session task: %v", err)
+			return err
+		}
+		log.Printf("Scheduled remind session task: %s", taskInfo.ID)
+	}
+	return nil
+}
+
+// DeleteRemindSessionTasks removes the pending reminders of the session.
+func DeleteRemindSessionTasks(inspector *asynq.Inspector, sessionID int64, offsets []time.Duration) error {
+	var lastErr error
+	for _, offset := range offsets {
+		err := inspector.DeleteTask(QUEUE, RemindSessionTaskID(sessionID, offset))
+		if err != nil && !errors.Is(err, asynq.ErrTaskNotFound) {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+type RemindSessionTaskProcessor struct {
+	b              *bot.Bot
+	sessionService service.ISessionService
+	movieService   service.IMovieService
+}
+
+type IRemindSessionTaskProcessor interface {
+	Process(ctx context.Context, task *asynq.Task) error
+}
+
+func NewRemindSessionTaskProcessor(b *bot.Bot, sessionService service.ISessionService, movieService service.IMovieService) *RemindSessionTaskProcessor {
+	return &RemindSessionTaskProcessor{
+		b:              b,
+		sessionService: sessionService,
+		movieService:   movieService,
+	}
+}
+
+// Process posts the lineup of the session. Reminders of cancelled or moved sessions are skipped.
+func (t *RemindSessionTaskProcessor) Process(ctx context.Context, task *asynq.Task) error {
+	var p RemindSessionPayload
+	if err := json.Unmarshal(task.Payload(), &p); err != nil {
+		return err
+	}
+	session, err := t.sessionService.FindSessionByID(p.SessionID)
+	if err != nil {
+		log.Printf("Error finding session %d: %v", p.SessionID, err)
+		return err
+	}
+	if !slices.Contains(model.SESSION_UPCOMING_STATUSES, session.Status) || session.FinishedAt != p.FinishedAt {
+		log.Printf("Reminder of session %d is outdated", session.ID)
+		return nil
+	}
+	text := fmt.Sprintf("⏰ До сеанса осталось %s!", formatOffset(p.Offset))
+	lineup, err := t.movieService.GetSessionMovies(session.ID)
+	if err != nil {
+		log.Printf("Error formatting lineup of session %d: %v", session.ID, err)
+	} else {
+		text += "\n\n" + *lineup
+	}
+	_, err = t.b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    p.ChatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		log.Printf("Error sending session reminder: %v", err)
+	}
+	return nil
+}
+
+func formatOffset(offset time.Duration) string {
+	if offset >= time.Hour && offset%time.Hour == 0 {
+		return fmt.Sprintf("%d ч.", int(offset.Hours()))
+	}
+	return fmt.Sprintf("%d мин.", int(offset.Round(time.Minute).Minutes()))
+}
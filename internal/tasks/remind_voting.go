@@ -0,0 +1,149 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/hibiken/asynq"
+)
+
+const RemindVotingTaskType = "remind_voting"
+
+type RemindVotingPayload struct {
+	ChatID   int64 `json:"chat_id"`
+	VotingID int64 `json:"voting_id"`
+}
+
+func NewRemindVotingTask(chatID int64, votingID int64) (*asynq.Task, error) {
+	payload, err := json.Marshal(RemindVotingPayload{ChatID: chatID, VotingID: votingID})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(RemindVotingTaskType, payload), nil
+}
+
+type EnqueueRemindVotingParams struct {
+	ChatID   int64
+	VotingID int64
+	Duration time.Duration // time left until the voting is closed
+	Offset   time.Duration // how long before the close to remind
+}
+
+func RemindVotingTaskID(votingID int64) string {
+	return fmt.Sprintf("%s-%d", RemindVotingTaskType, votingID)
+}
+
+// EnqueueRemindVotingTask schedules the reminder of the voting. Nothing is scheduled when
+// reminders are disabled or the voting is shorter than the offset.
+func EnqueueRemindVotingTask(client *asynq.Client, params *EnqueueRemindVotingParams) error {
+	if params.Offset <= 0 || params.Duration <= params.Offset {
+		return nil
+	}
+	task, err := NewRemindVotingTask(params.ChatID, params.VotingID)
+	if err != nil {
+		log.Printf("Error creating remind voting task: %v", err)
+		return err
+	}
+	scheduleOpts := []asynq.Option{asynq.MaxRetry(1), asynq.ProcessIn(params.Duration - params.Offset), asynq.TaskID(RemindVotingTaskID(params.VotingID)), asynq.Queue(QUEUE)}
+	taskInfo, err := client.Enqueue(task, scheduleOpts...)
+	if err != nil {
+		log.Printf("Error scheduling remind voting task: %v", err)
+		return err
+	}
+	log.Printf("Scheduled remind voting task: %s", taskInfo.ID)
+	return nil
+}
+
+// DeleteRemindVotingTask removes the pending reminder of the voting, if there is one.
+func DeleteRemindVotingTask(inspector *asynq.Inspector, votingID int64) error {
+	err := inspector.DeleteTask(QUEUE, RemindVotingTaskID(votingID))
+	if err != nil && !errors.Is(err, asynq.ErrTaskNotFound) {
+		return err
+	}
+	return nil
+}
+
+type RemindVotingTaskProcessor struct {
+	b             *bot.Bot
+	votingService service.IVotingService
+	userService   service.IUserService
+}
+
+type IRemindVotingTaskProcessor interface {
+	Process(ctx context.Context, task *asynq.Task) error
+}
+
+func NewRemindVotingTaskProcessor(b *bot.Bot, votingService service.IVotingService, userService service.IUserService) *RemindVotingTaskProcessor {
+	return &RemindVotingTaskProcessor{
+		b:             b,
+		votingService: votingService,
+		userService:   userService,
+	}
+}
+
+// Process sends a private message to the members who opted in and mentions the rest
+// of the members who haven't voted in a single group message.
+func (t *RemindVotingTaskProcessor) Process(ctx context.Context, task *asynq.Task) error {
+	var p RemindVotingPayload
+	if err := json.Unmarshal(task.Payload(), &p); err != nil {
+		return err
+	}
+	voting, err := t.votingService.GetVotingByID(p.VotingID)
+	if err != nil {
+		log.Printf("Error getting voting by ID: %v", err)
+		return err
+	}
+	if voting.Status != model.VOTING_ACTIVE_STATUS {
+		log.Printf("Voting %d is not active anymore", voting.ID)
+		return nil
+	}
+	users, err := t.userService.FindNonVoters(voting.ID)
+	if err != nil {
+		log.Printf("Error finding users who haven't voted: %v", err)
+		return err
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	left := "скоро"
+	if voting.FinishedAt != nil {
+		left = fmt.Sprintf("через %d мин.", int(time.Until(time.Unix(*voting.FinishedAt, 0)).Round(time.Minute).Minutes()))
+	}
+	var mentions []string
+	for _, user := range users {
+		if user.VotingReminders {
+			_, err := t.b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: user.ID,
+				Text:   fmt.Sprintf("⏰ Голосование \"%s\" закроется %s, а ты ещё не проголосовал(а)!", voting.Title, left),
+			})
+			if err == nil {
+				continue
+			}
+			// The user hasn't started a private chat with the bot, so the group mention is used
+			log.Printf("Error sending reminder to user %d: %v", user.ID, err)
+		}
+		mentions = append(mentions, service.Mention(user))
+	}
+	if len(mentions) == 0 {
+		return nil
+	}
+	_, err = t.b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    p.ChatID,
+		Text:      fmt.Sprintf("⏰ Голосование \"%s\" закроется %s. Ещё не проголосовали: %s", html.EscapeString(voting.Title), left, strings.Join(mentions, ", ")),
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		log.Printf("Error sending voting reminder: %v", err)
+	}
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/tasks"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/kinopoisk"
@@ -19,12 +21,14 @@ import (
 )
 
 type AddMovieToSessionHandler struct {
-	movieService     service.IMovieService
-	kinopoiskService service.IKinopoiskService
-	sessionService   service.ISessionService
-	pollService      service.IPollService
-	asynqClient      *asynq.Client
-	inspector        *asynq.Inspector
+	movieService      service.IMovieService
+	kinopoiskService  service.IKinopoiskService
+	sessionService    service.ISessionService
+	pollService       service.IPollService
+	attendanceService service.IAttendanceService
+	asynqClient       *asynq.Client
+	inspector         *asynq.Inspector
+	cfg               *config.SessionConfig
 }
 
 type IAddMovieToSessionHandler interface {
@@ -36,16 +40,20 @@ func NewAddMovieToSessionHandler(
 	kinopoiskService service.IKinopoiskService,
 	sessionService service.ISessionService,
 	pollService service.IPollService,
+	attendanceService service.IAttendanceService,
 	asynqClient *asynq.Client,
 	inspector *asynq.Inspector,
+	cfg *config.SessionConfig,
 ) IAddMovieToSessionHandler {
 	return &AddMovieToSessionHandler{
-		movieService:     movieService,
-		kinopoiskService: kinopoiskService,
-		sessionService:   sessionService,
-		pollService:      pollService,
-		asynqClient:      asynqClient,
-		inspector:        inspector,
+		movieService:      movieService,
+		kinopoiskService:  kinopoiskService,
+		sessionService:    sessionService,
+		pollService:       pollService,
+		attendanceService: attendanceService,
+		asynqClient:       asynqClient,
+		inspector:         inspector,
+		cfg:               cfg,
 	}
 }
 
@@ -159,11 +167,28 @@ func (h *AddMovieToSessionHandler) Handle(ctx context.Context, b *bot.Bot, updat
 		return
 	}
 
-	session, newSessionMovieIDs, sessionCreated, err := h.sessionService.AddMoviesToSession(update.Message.From.ID, targetIDs)
+	chatID := update.Message.Chat.ID
+	userID := update.Message.From.ID
+	pickSession(ctx, b, update, h.sessionService, "📅 В какой сеанс добавить фильмы?", true, func(ctx context.Context, b *bot.Bot, _ *models.Update, session *model.Session) {
+		var sessionID *int64
+		if session != nil {
+			sessionID = &session.ID
+		}
+		h.addToSession(ctx, b, chatID, userID, sessionID, targetIDs, existingIDs, createdIDs)
+	})
+}
+
+// addToSession attaches the movies to the chosen session, nil sessionID plans a new one.
+func (h *AddMovieToSessionHandler) addToSession(ctx context.Context, b *bot.Bot, chatID int64, userID int64, sessionID *int64, targetIDs []int64, existingIDs []int64, createdIDs []int64) {
+	session, newSessionMovieIDs, sessionCreated, err := h.sessionService.AddMoviesToSession(userID, sessionID, targetIDs)
+	if errors.Is(err, service.ErrSessionNotUpcoming) {
+		sendText(ctx, b, chatID, "⚠️ Выбранный сеанс уже отменен или прошел, фильмы не добавлены. Выберите другой сеанс.")
+		return
+	}
 	if err != nil {
 		log.Printf("failed to add movies to session: %v", err)
 		_, err = b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
+			ChatID: chatID,
 			Text:   "❌ Не удалось добавить фильмы в сессию.",
 		})
 		if err != nil {
@@ -172,62 +197,45 @@ func (h *AddMovieToSessionHandler) Handle(ctx context.Context, b *bot.Bot, updat
 		return
 	}
 
-	if sessionCreated && session.FinishedAt > 0 {
-		finishTime := time.Unix(session.FinishedAt, 0)
-		duration := time.Until(finishTime)
+	if sessionCreated && session.FinishedAt > time.Now().Unix() {
+		err := tasks.EnqueueRemindSessionTasks(h.asynqClient, &tasks.EnqueueRemindSessionParams{
+			ChatID:     chatID,
+			SessionID:  session.ID,
+			FinishedAt: session.FinishedAt,
+			Offsets:    h.cfg.ReminderOffsets,
+		})
+		if err != nil {
+			log.Printf("failed to enqueue remind session tasks: %v", err)
+		}
+	}
 
-		if duration > 0 {
-			err := tasks.EnqueueFinishSessionTask(h.asynqClient, &tasks.EnqueueFinishSessionParams{
+	var lineup []service.LineupSlot
+	if len(newSessionMovieIDs) > 0 && session.FinishedAt > 0 {
+		lineup, err = h.movieService.GetLineup(session.ID)
+		if err != nil {
+			log.Printf("failed to get lineup of session %d: %v", session.ID, err)
+		} else {
+			// The added movies lengthen the lineup, the session finishes after the last one
+			err = tasks.ScheduleFinishSessionTask(h.asynqClient, h.inspector, &tasks.ScheduleFinishSessionParams{
 				SessionID: session.ID,
-				Duration:  duration,
+				StartsAt:  session.FinishedAt,
+				Lineup:    lineup,
 			})
 			if err != nil {
 				log.Printf("failed to enqueue finish session task: %v", err)
 			} else {
-				log.Printf("Scheduled finish session task for session %d at %s", session.ID, finishTime.Format(time.RFC3339))
+				log.Printf("Scheduled finish session task for session %d", session.ID)
 			}
-		}
-	}
-
-	if len(newSessionMovieIDs) > 0 && session.FinishedAt > 0 {
-		finishTime := time.Unix(session.FinishedAt, 0)
-		duration := time.Until(finishTime)
-
-		if duration > 0 {
-			for _, movieID := range newSessionMovieIDs {
-				movie, err := h.movieService.GetMovieByID(movieID)
-				if err != nil {
-					log.Printf("failed to get movie %d for rating task: %v", movieID, err)
-					continue
-				}
-
-				taskID := fmt.Sprintf("%s-%d-%d", tasks.OpenRatingVotingTaskType, session.ID, movieID)
-
-				taskExists := false
-				if h.inspector != nil {
-					_, err := h.inspector.GetTaskInfo(tasks.QUEUE, taskID)
-					if err == nil {
-						taskExists = true
-						log.Printf("Rating voting task already exists for movie %d in session %d", movieID, session.ID)
-					}
-				}
-
-				if !taskExists {
-					err = tasks.EnqueueOpenRatingVotingTask(h.asynqClient, &tasks.EnqueueOpenRatingVotingParams{
-						SessionID: session.ID,
-						ChatID:    update.Message.Chat.ID,
-						Movie:     *movie,
-						UserID:    update.Message.From.ID,
-						TaskID:    taskID,
-						Duration:  duration,
-					})
-					if err != nil {
-						log.Printf("failed to enqueue open rating voting task for movie %d: %v", movieID, err)
-					} else {
-						log.Printf("Scheduled open rating voting task for movie %d in session %d at %s",
-							movieID, session.ID, finishTime.Format(time.RFC3339))
-					}
-				}
+			err = tasks.ScheduleOpenRatingVotingTasks(h.asynqClient, h.inspector, &tasks.ScheduleOpenRatingVotingsParams{
+				ChatID:    chatID,
+				SessionID: session.ID,
+				UserID:    userID,
+				Lineup:    lineup,
+			})
+			if err != nil {
+				log.Printf("failed to enqueue open rating voting tasks: %v", err)
+			} else {
+				log.Printf("Scheduled open rating voting tasks for session %d", session.ID)
 			}
 		}
 	}
@@ -236,7 +244,7 @@ func (h *AddMovieToSessionHandler) Handle(ctx context.Context, b *bot.Bot, updat
 	if sessionCreated {
 		responseText = fmt.Sprintf("✅ Создана новая сессия с %d фильмом(ами).\n", len(targetIDs))
 	} else {
-		responseText = fmt.Sprintf("✅ Добавлено %d новый(х) фильм(ов) в текущую сессию.\n", len(newSessionMovieIDs))
+		responseText = fmt.Sprintf("✅ Добавлено %d новый(х) фильм(ов) в выбранную сессию.\n", len(newSessionMovieIDs))
 	}
 
 	if len(existingIDs) > 0 {
@@ -247,17 +255,30 @@ func (h *AddMovieToSessionHandler) Handle(ctx context.Context, b *bot.Bot, updat
 	}
 
 	if session.FinishedAt > 0 {
-		finishTime := time.Unix(session.FinishedAt, 0)
-		responseText += fmt.Sprintf("\n📅 Дата окончания просмотра: %s", finishTime.Format("02.01.2006 15:04"))
+		start := time.Unix(session.FinishedAt, 0)
+		responseText += fmt.Sprintf("\n📅 Начало просмотра: %s", start.Format("02.01.2006 15:04"))
+		if end := service.LineupEnd(start, lineup); end.After(start) {
+			responseText += fmt.Sprintf("\n🏁 Окончание просмотра: %s", end.Format("02.01.2006 15:04"))
+		}
 	}
 
 	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
+		ChatID: chatID,
 		Text:   responseText,
 	})
 	if err != nil {
 		log.Printf("failed to send adds movie response: %v", err)
 	}
+
+	if sessionCreated {
+		// A new session gets its RSVP message right away, /rsvp refreshes it later
+		planned, err := h.sessionService.FindSessionByID(session.ID)
+		if err != nil {
+			log.Printf("failed to find session %d for RSVP: %v", session.ID, err)
+			return
+		}
+		sendRsvp(ctx, b, h.sessionService, h.attendanceService, chatID, planned)
+	}
 }
 
 func parseMovieIDs(raw string) ([]int64, []string) {
@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type CalendarHandler struct {
+	calendarService service.ICalendarService
+	domain          string
+}
+
+type ICalendarHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewCalendarHandler(calendarService service.ICalendarService, domain string) *CalendarHandler {
+	return &CalendarHandler{calendarService: calendarService, domain: domain}
+}
+
+// Handle sends the personal link of the calendar feed to a private chat, the link must not leak to the group.
+func (h *CalendarHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if !h.calendarService.Enabled() {
+		sendText(ctx, b, update.Message.Chat.ID, "ℹ️ Календарь не настроен.")
+		return
+	}
+	userID := update.Message.From.ID
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: userID,
+		Text:   fmt.Sprintf("🗓️ Подпишись на календарь сеансов киноклуба:\n\n%s\n\nℹ️ Ссылка личная, не делись ей.", h.calendarService.FeedURL(h.domain, userID)),
+	})
+	if err != nil {
+		log.Printf("Error sending calendar link to %d: %v", userID, err)
+		sendText(ctx, b, update.Message.Chat.ID, "❌ Не удалось отправить ссылку. Сначала напиши боту /start в личке!")
+		return
+	}
+	if update.Message.Chat.Type != models.ChatTypePrivate {
+		sendText(ctx, b, update.Message.Chat.ID, "📬 Ссылка на календарь отправлена в личные сообщения.")
+	}
+}
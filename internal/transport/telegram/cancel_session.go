@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/tasks"
@@ -18,21 +20,28 @@ type CancelSessionHandler struct {
 	service       service.ISessionService
 	votingService service.IVotingService
 	inspector     *asynq.Inspector
+	cfg           *config.SessionConfig
 }
 
 type ICancelSessionHandler interface {
 	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
 }
 
-func NewCancelSessionHandler(service service.ISessionService, votingService service.IVotingService, inspector *asynq.Inspector) ICancelSessionHandler {
-	return &CancelSessionHandler{service: service, votingService: votingService, inspector: inspector}
+func NewCancelSessionHandler(service service.ISessionService, votingService service.IVotingService, inspector *asynq.Inspector, cfg *config.SessionConfig) ICancelSessionHandler {
+	return &CancelSessionHandler{service: service, votingService: votingService, inspector: inspector, cfg: cfg}
 }
 
 func (h *CancelSessionHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
-	session, votings, err := h.service.CancelSession()
+	pickSession(ctx, b, update, h.service, "📅 Какой сеанс отменить?", false, func(ctx context.Context, b *bot.Bot, update *models.Update, session *model.Session) {
+		h.cancel(ctx, b, updateChatID(update), session.ID)
+	})
+}
+
+func (h *CancelSessionHandler) cancel(ctx context.Context, b *bot.Bot, chatID int64, sessionID int64) {
+	session, votings, err := h.service.CancelSession(sessionID)
 	if err != nil {
 		_, err = b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
+			ChatID: chatID,
 			Text:   "❌ Ошибка при отмене сессии.",
 		})
 		if err != nil {
@@ -49,17 +58,16 @@ func (h *CancelSessionHandler) Handle(ctx context.Context, b *bot.Bot, update *m
 	if err != nil {
 		log.Printf("Error deleting finish session task: %v", err)
 	}
+	if err := tasks.DeleteRemindSessionTasks(h.inspector, session.ID, h.cfg.ReminderOffsets); err != nil {
+		log.Printf("Error deleting remind session tasks: %v", err)
+	}
 	for _, voting := range votings {
 		if voting.Status == model.VOTING_ACTIVE_STATUS {
-			taskInfo, err := h.inspector.GetTaskInfo(tasks.QUEUE, fmt.Sprintf("%s-%d", tasks.CloseRatingVotingTaskType, voting.ID))
+			taskInfo, err := tasks.DeleteCloseVotingTask(h.inspector, voting)
 			if err != nil {
-				log.Printf("Error getting task with id: %s, %v", taskInfo.ID, err)
+				log.Printf("Error deleting close voting task for voting %d: %v", voting.ID, err)
 				continue
 			}
-			err = h.inspector.DeleteTask(tasks.QUEUE, taskInfo.ID)
-			if err != nil {
-				log.Printf("Error deleting close voting task: %v", err)
-			}
 			var payload tasks.CloseRatingVotingPayload
 			err = json.Unmarshal([]byte(taskInfo.Payload), &payload)
 			if err != nil {
@@ -76,8 +84,8 @@ func (h *CancelSessionHandler) Handle(ctx context.Context, b *bot.Bot, update *m
 		}
 	}
 	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
-		Text:   "✅ Сессия успешно отменена.",
+		ChatID: chatID,
+		Text:   fmt.Sprintf("✅ Сессия %s успешно отменена.", time.Unix(session.FinishedAt, 0).Format("02.01.2006 15:04")),
 	})
 	if err != nil {
 		log.Printf("Error sending message: %v", err)
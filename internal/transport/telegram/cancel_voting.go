@@ -133,18 +133,7 @@ func (h *CancelVotingHandler) Cancel(f *fsm.FSM, args ...any) {
 	}
 
 	for _, voting := range votings {
-		var taskId string
-		switch voting.Type {
-		case model.VOTING_RATING_TYPE:
-			taskId = fmt.Sprintf("%s-%d", tasks.CloseRatingVotingTaskType, voting.ID)
-		case model.VOTING_SELECTION_TYPE:
-			taskId = fmt.Sprintf("%s-%d", tasks.CloseSelectionVotingTaskType, voting.ID)
-		}
-		taskInfo, err := h.inspector.GetTaskInfo(tasks.QUEUE, taskId)
-		if err != nil {
-			continue
-		}
-		err = h.inspector.DeleteTask(taskInfo.Queue, taskInfo.ID)
+		taskInfo, err := tasks.DeleteCloseVotingTask(h.inspector, voting)
 		if err != nil {
 			continue
 		}
@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/tasks"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/keyboard"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/hibiken/asynq"
+)
+
+type OnVotingSelect func(ctx context.Context, b *bot.Bot, update *models.Update, voting *model.Voting)
+
+type CloseVotingHandler struct {
+	votingService service.IVotingService
+	inspector     *asynq.Inspector
+}
+
+type ICloseVotingHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewCloseVotingHandler(votingService service.IVotingService, inspector *asynq.Inspector) *CloseVotingHandler {
+	return &CloseVotingHandler{votingService: votingService, inspector: inspector}
+}
+
+func (h *CloseVotingHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	votings, err := h.votingService.FindVotingByStatus(model.VOTING_ACTIVE_STATUS)
+	if err != nil || len(votings) == 0 {
+		sendText(ctx, b, update.Message.Chat.ID, "ℹ️ Нет активных голосований.")
+		return
+	}
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        "⏩ Выберите голосование, которое нужно завершить досрочно",
+		ReplyMarkup: votingsKeyboard(b, update.Message.From.ID, votings, h.onVotingSelect),
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+func (h *CloseVotingHandler) onVotingSelect(ctx context.Context, b *bot.Bot, update *models.Update, voting *model.Voting) {
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	// The close processor does the rest, including the quorum check
+	if err := tasks.RunCloseVotingTask(h.inspector, voting); err != nil {
+		log.Printf("Error running close voting task: %v", err)
+		sendText(ctx, b, chatID, "❌ Не удалось найти задачу завершения голосования.")
+		return
+	}
+	sendText(ctx, b, chatID, fmt.Sprintf("⏩ Голосование \"%s\" завершается досрочно.", voting.Title))
+}
+
+// votingsKeyboard shows a button per voting, only the admin who called the command can choose.
+// The clicks of the others are ignored and leave the keyboard in place.
+func votingsKeyboard(b *bot.Bot, adminID int64, votings []*model.Voting, onSelect OnVotingSelect) *keyboard.Keyboard {
+	byID := make(map[int64]*model.Voting, len(votings))
+	kb := keyboard.New(b, keyboard.NoDeleteAfterClick())
+	for _, voting := range votings {
+		byID[voting.ID] = voting
+		kb.Row().Button(voting.Title, []byte(strconv.FormatInt(voting.ID, 10)), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+			if update.CallbackQuery.From.ID != adminID {
+				return
+			}
+			kb.Close(ctx, b, update)
+			votingID, err := strconv.ParseInt(string(data), 10, 64)
+			if err != nil {
+				return
+			}
+			onSelect(ctx, b, update, byID[votingID])
+		})
+	}
+	return kb.Row().Button("Отменить", []byte("cancel"), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+		if update.CallbackQuery.From.ID == adminID {
+			kb.Close(ctx, b, update)
+		}
+	})
+}
+
+func sendText(ctx context.Context, b *bot.Bot, chatID int64, text string) {
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 
 	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/media"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 )
@@ -22,7 +23,7 @@ func NewCurrentMoviesHandler(movieService service.IMovieService) *CurrentMoviesH
 }
 
 func (h *CurrentMoviesHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
-	movies, err := h.movieService.GetCurrentMovies()
+	header, cards, err := h.movieService.GetCurrentMovieCards()
 	if err != nil {
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
@@ -33,12 +34,7 @@ func (h *CurrentMoviesHandler) Handle(ctx context.Context, b *bot.Bot, update *m
 		}
 		return
 	}
-	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID:    update.Message.Chat.ID,
-		Text:      *movies,
-		ParseMode: models.ParseModeHTML,
-	})
-	if err != nil {
+	if err := media.Send(ctx, b, update.Message.Chat.ID, header, cards); err != nil {
 		log.Printf("Error sending the message: %v", err)
 	}
 }
@@ -40,20 +40,14 @@ func (h *CustomSessionDescriptionHandler) Handle(ctx context.Context, b *bot.Bot
 		return
 	}
 
-	session, err := h.sessionService.FindOngoingSession()
-	if err != nil {
-		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "❌ Нет активной сессии для установки описания.",
-		})
-		if err != nil {
-			fmt.Printf("failed to send message: %v\n", err)
+	pickSession(ctx, b, update, h.sessionService, "📅 Для какого сеанса установить описание?", false, func(ctx context.Context, b *bot.Bot, update *models.Update, session *model.Session) {
+		if h.fsm.Current(userID) != stateDefault {
+			return
 		}
-		return
-	}
-	h.fsm.Set(userID, "session_id", session.ID)
-	h.fsm.Set(userID, "chat_id", update.Message.Chat.ID)
-	h.fsm.Transition(userID, stateDescription, userID, ctx, b, update, session)
+		h.fsm.Set(userID, "session_id", session.ID)
+		h.fsm.Set(userID, "chat_id", updateChatID(update))
+		h.fsm.Transition(userID, stateDescription, userID, ctx, b, update, session)
+	})
 }
 
 func (h *CustomSessionDescriptionHandler) HandleDescriptionInput(f *fsm.FSM, args ...any) {
@@ -67,13 +61,13 @@ func (h *CustomSessionDescriptionHandler) HandleDescriptionInput(f *fsm.FSM, arg
 	update := args[3].(*models.Update)
 	session := args[4].(*model.Session)
 	currentDesc := session.Description
-	promptText := "📝 Отправьте описание для текущей сессии."
+	promptText := "📝 Отправьте описание для выбранной сессии."
 	if currentDesc != "" {
 		promptText += fmt.Sprintf("\n\n💡 Текущее описание:\n%s", currentDesc)
 	}
 	promptText += "\n\nℹ️ Отправьте /cancel для отмены."
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
+		ChatID: updateChatID(update),
 		Text:   promptText,
 	})
 	if err != nil {
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/date"
 	fsmutils "github.com/Forceres/tg-bot-movieclub-go/internal/utils/fsm"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -37,16 +39,34 @@ func (h *DefaultHandler) Handle(ctx context.Context, b *bot.Bot, update *models.
 		return
 	case statePrepareVotingType:
 		return
+	case statePrepareVotingMethod:
+		return
+	case statePrepareQuorumPolicy:
+		return
+	case statePrepareHideTally:
+		return
+	case statePrepareBallotMode:
+		return
+	case statePrepareVotingSession:
+		return
 	case stateSaveSchedule:
 		return
 	case stateDate:
 		return
+	case stateScheduleInterval:
+		return
+	case stateSkipDate:
+		return
+	case stateToggleException:
+		return
 	case stateRescheduleSession:
 		return
 	case stateRemove:
 		return
 	case stateSaveDescription:
 		return
+	case stateSaveVenue:
+		return
 	case stateDescription:
 		_, exists := h.f.Get(userID, "session_id")
 		if !exists {
@@ -99,7 +119,35 @@ func (h *DefaultHandler) Handle(ctx context.Context, b *bot.Bot, update *models.
 			return
 		}
 		h.f.Set(userID, "duration", duration)
-		h.f.Transition(userID, stateStartVoting, userID, ctx, b, update)
+		h.f.Transition(userID, statePrepareVotingQuorum, userID, ctx, b, update)
+	case statePrepareVotingQuorum:
+		fsmutils.AppendMessageID(h.f, userID, update.Message.ID)
+		text := strings.TrimSpace(update.Message.Text)
+		quorumType := model.QUORUM_ABSOLUTE_TYPE
+		if strings.HasSuffix(text, "%") {
+			quorumType = model.QUORUM_PERCENT_TYPE
+			text = strings.TrimSpace(strings.TrimSuffix(text, "%"))
+		}
+		quorum, errQuorum := strconv.Atoi(text)
+		if errQuorum != nil || quorum < 0 || (quorumType == model.QUORUM_PERCENT_TYPE && quorum > 100) {
+			msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "⚠️ Введите целое число >= 0 или процент от 0% до 100%",
+			})
+			if err != nil {
+				log.Printf("Error sending message: %v", err)
+				return
+			}
+			fsmutils.AppendMessageID(h.f, userID, msg.ID)
+			return
+		}
+		if quorum == 0 {
+			h.f.Transition(userID, statePrepareHideTally, userID, ctx, b, update)
+			return
+		}
+		h.f.Set(userID, "quorumType", quorumType)
+		h.f.Set(userID, "quorumValue", quorum)
+		h.f.Transition(userID, statePrepareQuorumPolicy, userID, ctx, b, update)
 	case statePrepareMovies:
 		indexes := update.Message.Text
 		fsmutils.AppendMessageID(h.f, userID, update.Message.ID)
@@ -309,10 +357,89 @@ func (h *DefaultHandler) Handle(ctx context.Context, b *bot.Bot, update *models.
 		if value == "session" {
 			state = stateRescheduleSession
 		} else {
-			state = stateSaveSchedule
+			state = stateScheduleWeekdays
 		}
 		h.f.Transition(userID, state, userID, ctx, b, update)
+	case stateScheduleWeekdays:
+		fsmutils.AppendMessageID(h.f, userID, update.Message.ID)
+		text := strings.TrimSpace(update.Message.Text)
+		var weekdays []int
+		if text == SCHEDULE_SAME_WEEKDAY_TEXT {
+			selected, _ := h.f.Get(userID, "date")
+			weekday := int(selected.(time.Time).Weekday())
+			if weekday == 0 {
+				weekday = 7
+			}
+			weekdays = []int{weekday}
+		} else {
+			parsed, err := date.ParseWeekdays(text)
+			if err != nil {
+				msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID: chatID,
+					Text:   "⚠️ Введите дни недели через запятую, например: пн, ср, пт",
+				})
+				if err != nil {
+					log.Printf("Error sending message: %v", err)
+					return
+				}
+				fsmutils.AppendMessageID(h.f, userID, msg.ID)
+				return
+			}
+			weekdays = parsed
+		}
+		h.f.Set(userID, "weekdays", weekdays)
+		h.f.Transition(userID, stateScheduleInterval, userID, ctx, b, update)
+	case stateVenueAddress:
+		fsmutils.AppendMessageID(h.f, userID, update.Message.ID)
+		h.f.Set(userID, "venue_address", venueInput(update.Message.Text))
+		h.f.Transition(userID, stateVenueRoom, userID, ctx, b, update)
+	case stateVenueRoom:
+		fsmutils.AppendMessageID(h.f, userID, update.Message.ID)
+		room := venueInput(update.Message.Text)
+		if room != "" {
+			link, err := url.ParseRequestURI(room)
+			if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+				msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID: chatID,
+					Text:   "⚠️ Введите корректную ссылку, например https://example.com/room",
+				})
+				if err != nil {
+					log.Printf("Error sending message: %v", err)
+					return
+				}
+				fsmutils.AppendMessageID(h.f, userID, msg.ID)
+				return
+			}
+		}
+		h.f.Set(userID, "venue_room", room)
+		h.f.Transition(userID, stateVenueNotes, userID, ctx, b, update)
+	case stateVenueNotes:
+		fsmutils.AppendMessageID(h.f, userID, update.Message.ID)
+		notes := venueInput(update.Message.Text)
+		if len([]rune(notes)) > 500 {
+			msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "❌ Заметки слишком длинные (максимум 500 символов). Попробуйте сократить.",
+			})
+			if err != nil {
+				log.Printf("Error sending message: %v", err)
+				return
+			}
+			fsmutils.AppendMessageID(h.f, userID, msg.ID)
+			return
+		}
+		h.f.Set(userID, "venue_notes", notes)
+		h.f.Transition(userID, stateSaveVenue, userID, ctx, b, update)
 	default:
 		fmt.Printf("unexpected state %s\n", currentState)
 	}
 }
+
+// venueInput trims the answer of the venue wizard, the skip text clears the field.
+func venueInput(text string) string {
+	text = strings.TrimSpace(text)
+	if text == VENUE_SKIP_TEXT {
+		return ""
+	}
+	return text
+}
@@ -0,0 +1,122 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/tasks"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/keyboard"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/hibiken/asynq"
+)
+
+type OnDurationSelect func(ctx context.Context, b *bot.Bot, update *models.Update, duration time.Duration)
+
+var VOTING_DURATION_OPTIONS = []int{1, 3, 12, 24}
+
+type ExtendVotingHandler struct {
+	votingService service.IVotingService
+	inspector     *asynq.Inspector
+	client        *asynq.Client
+	cfg           *config.VotingConfig
+}
+
+type IExtendVotingHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewExtendVotingHandler(votingService service.IVotingService, inspector *asynq.Inspector, client *asynq.Client, cfg *config.VotingConfig) *ExtendVotingHandler {
+	return &ExtendVotingHandler{votingService: votingService, inspector: inspector, client: client, cfg: cfg}
+}
+
+func (h *ExtendVotingHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	votings, err := h.votingService.FindVotingByStatus(model.VOTING_ACTIVE_STATUS)
+	if err != nil || len(votings) == 0 {
+		sendText(ctx, b, update.Message.Chat.ID, "ℹ️ Нет активных голосований.")
+		return
+	}
+	adminID := update.Message.From.ID
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   "⏳ Выберите голосование, которое нужно продлить",
+		ReplyMarkup: votingsKeyboard(b, adminID, votings, func(ctx context.Context, b *bot.Bot, update *models.Update, voting *model.Voting) {
+			h.onVotingSelect(ctx, b, update, adminID, voting)
+		}),
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+func (h *ExtendVotingHandler) onVotingSelect(ctx context.Context, b *bot.Bot, update *models.Update, adminID int64, voting *model.Voting) {
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.CallbackQuery.Message.Message.Chat.ID,
+		Text:   fmt.Sprintf("⏱️ На сколько часов продлить голосование \"%s\"?", voting.Title),
+		ReplyMarkup: durationKeyboard(b, adminID, func(ctx context.Context, b *bot.Bot, update *models.Update, duration time.Duration) {
+			h.extend(ctx, b, update, voting, duration)
+		}),
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+func (h *ExtendVotingHandler) extend(ctx context.Context, b *bot.Bot, update *models.Update, voting *model.Voting, duration time.Duration) {
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	finishedAt := time.Now()
+	if voting.FinishedAt != nil && time.Unix(*voting.FinishedAt, 0).After(finishedAt) {
+		finishedAt = time.Unix(*voting.FinishedAt, 0)
+	}
+	finishedAt = finishedAt.Add(duration)
+	if err := tasks.RescheduleCloseVotingTask(h.inspector, h.client, voting, finishedAt); err != nil {
+		log.Printf("Error rescheduling close voting task: %v", err)
+		sendText(ctx, b, chatID, "❌ Не удалось перенести завершение голосования.")
+		return
+	}
+	if err := h.votingService.RescheduleVoting(voting.ID, finishedAt.Unix()); err != nil {
+		log.Printf("Error rescheduling voting: %v", err)
+		sendText(ctx, b, chatID, "❌ Не удалось перенести завершение голосования.")
+		return
+	}
+	// The reminder was deleted together with the close task
+	err := tasks.EnqueueRemindVotingTask(h.client, &tasks.EnqueueRemindVotingParams{
+		ChatID:   chatID,
+		VotingID: voting.ID,
+		Duration: time.Until(finishedAt),
+		Offset:   h.cfg.ReminderOffset,
+	})
+	if err != nil {
+		log.Printf("Error scheduling remind voting task: %v", err)
+	}
+	sendText(ctx, b, chatID, fmt.Sprintf("⏳ Голосование \"%s\" продлено до %s.", voting.Title, finishedAt.Format("02.01.2006 15:04")))
+}
+
+// durationKeyboard offers the usual voting durations in hours, only the admin who called the command can choose.
+func durationKeyboard(b *bot.Bot, adminID int64, onSelect OnDurationSelect) *keyboard.Keyboard {
+	kb := keyboard.New(b, keyboard.NoDeleteAfterClick()).Row()
+	for _, hours := range VOTING_DURATION_OPTIONS {
+		kb.Button(fmt.Sprintf("%d ч.", hours), []byte(strconv.Itoa(hours)), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+			if update.CallbackQuery.From.ID != adminID {
+				return
+			}
+			kb.Close(ctx, b, update)
+			hours, err := strconv.Atoi(string(data))
+			if err != nil {
+				return
+			}
+			onSelect(ctx, b, update, time.Duration(hours)*time.Hour)
+		})
+	}
+	return kb.Row().Button("Отменить", []byte("cancel"), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+		if update.CallbackQuery.From.ID == adminID {
+			kb.Close(ctx, b, update)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// GRANT_SUGGESTIONS_MAX_SLOTS caps the extra slots of a single grant.
+const GRANT_SUGGESTIONS_MAX_SLOTS = 20
+
+type GrantSuggestionsHandler struct {
+	suggestionService service.ISuggestionService
+	userService       service.IUserService
+}
+
+type IGrantSuggestionsHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewGrantSuggestionsHandler(suggestionService service.ISuggestionService, userService service.IUserService) *GrantSuggestionsHandler {
+	return &GrantSuggestionsHandler{suggestionService: suggestionService, userService: userService}
+}
+
+// Handle gives extra suggestion slots to the author of the replied message, "/grant_suggestions 3" gives three.
+func (h *GrantSuggestionsHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	reply := update.Message.ReplyToMessage
+	if reply == nil || reply.From == nil || reply.From.IsBot {
+		sendText(ctx, b, chatID, "ℹ️ Ответь командой /grant_suggestions на сообщение участника, например: /grant_suggestions 3")
+		return
+	}
+	slots := 1
+	if fields := strings.Fields(update.Message.Text); len(fields) > 1 {
+		parsed, err := strconv.Atoi(fields[1])
+		if err != nil || parsed < 1 || parsed > GRANT_SUGGESTIONS_MAX_SLOTS {
+			sendText(ctx, b, chatID, fmt.Sprintf("⚠️ Укажите число слотов от 1 до %d.", GRANT_SUGGESTIONS_MAX_SLOTS))
+			return
+		}
+		slots = parsed
+	}
+	user, err := h.userService.FindByID(reply.From.ID)
+	if err != nil {
+		sendText(ctx, b, chatID, "❌ Участник не зарегистрирован в клубе.")
+		return
+	}
+	grant, err := h.suggestionService.GrantSlots(user.ID, slots, update.Message.From.ID)
+	if err != nil {
+		log.Printf("Error granting suggestion slots to %d: %v", user.ID, err)
+		sendText(ctx, b, chatID, "❌ Ошибка при выдаче дополнительных слотов.")
+		return
+	}
+	sendText(ctx, b, chatID, fmt.Sprintf("🎁 %s может предложить еще %d фильм(а/ов) до %s.", displayName(user), grant.Slots, time.Unix(grant.ExpiresAt, 0).Format("02.01.2006 15:04")))
+}
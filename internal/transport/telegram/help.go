@@ -18,28 +18,51 @@ _Добавить фильм в предложку можно с помощью
 Если хотите предложить несколько фильмов,  
 тогда располагайте ссылки через запятую или пробелы\!
 
+Можно обойтись и без ссылки: *\#предлагаю* название фильма
+Бот найдет фильм на Кинопоиске и предложит выбрать подходящий\.
+
 В случае, если была передана ссылка на фильм,  
 который уже был просмотрен ранее, тогда он будет внесен в предложку,  
 то есть, его дата предложения изменится на текущую\!
 
+У каждого участника есть лимит фильмов в предложке и новых предложений за неделю\.
+
 *Список возможных команд:*
 \#предлагаю \- добавить фильм в предложку
 \#перенос \- перенести дату обсуждения фильма \(только админ\)
-\#расписание \- вывести текущее расписание сеансов
+\#расписание \- вывести текущее расписание и ближайшие сеансы по нему
 \#предложка \- вывести список предложенных фильмов
 /start \- как и /register, зарегистрироваться в клубе \(только если находитесь в группе\)
 /help \- вывести справку о командах
-/schedule \- изменить расписание сеансов \(только админ\), влияет на день недели и время \(только админ и только для новых сеансов\)
+/schedule \- изменить расписание сеансов \(только админ\), влияет на дни недели, периодичность и время \(только админ и только для новых сеансов\)
+/grant\_suggestions \- в ответ на сообщение участника дать ему дополнительные слоты для предложений, например /grant\_suggestions 3 \(только админ\)
+/skip \- пропустить дату в расписании или вернуть пропущенную \(только админ\)
 /register \- зарегистрироваться в клубе \(только если находитесь в группе\)
-/now \- вывести список фильмов текущего сеанса  
-/custom \- добавить свое произвольное описание к фильмам \(только админ\)
+/now \- вывести список фильмов ближайшего сеанса  
+/sessions \- вывести запланированные сеансы
+/rsvp \- отметиться, придете ли вы на выбранный сеанс
+/history \- история прошедших сеансов с оценками фильмов
+/order \- изменить порядок показа фильмов выбранного сеанса \(только админ\)
+/venue \- указать адрес, ссылку на онлайн\-комнату и заметки для сеанса или для всех новых сеансов \(только админ\)
+/rating\_window \- изменить, сколько часов открыты оценки фильмов выбранного сеанса \(только админ\)
+/custom \- добавить свое произвольное описание к выбранному сеансу \(только админ\)
 /cancel\_voting \- отменить голосование \(только админ\)
-/cancel\_session \- отменить текущий сеанс \(только админ\)  
+/close\_voting \- завершить голосование досрочно \(только админ\)
+/extend\_voting \- продлить активное голосование \(только админ\)
+/reopen\_voting \- открыть заново голосование, завершенное по ошибке \(только админ\)
+/cancel\_session \- отменить выбранный сеанс \(только админ\)  
+/restore\_session \- восстановить недавно отмененный сеанс \(только админ\)
 /cancel \- _РАБОТАЕТ ТОЛЬКО ВО ВРЕМЯ СОЗДАНИЯ ГОЛОСОВАНИЯ_ \(только админ\)
 /already \- получить ссылки со списком просмотренных фильмов
 /voting \- создать голосование \(только админ\)  
-/add \- добавить фильм без голосования \(только админ\)
-/rm \- удалить фильм из активной сессии \(только админ\)`
+/results \- вывести промежуточные результаты активных голосований
+/top \- вывести лучшие фильмы клуба по скорректированному рейтингу
+@бот запрос \- в любом чате найти фильм клуба по названию, режиссеру или году и поделиться карточкой
+/unsuggest \- убрать свой фильм из предложки, админ может убрать любой
+/reminders \- включить или выключить напоминания о голосованиях в личных сообщениях
+/calendar \- получить в личных сообщениях ссылку на календарь сеансов
+/add \- добавить фильм без голосования в выбранный или новый сеанс \(только админ\)
+/rm \- удалить фильм из выбранного сеанса \(только админ\)`
 
 func HelpHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
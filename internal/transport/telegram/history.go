@@ -0,0 +1,193 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// HISTORY_PREFIX starts the callback data of the /history buttons, they keep working after a restart.
+const HISTORY_PREFIX = "history_"
+
+const HISTORY_PER_PAGE = 5
+
+type HistoryHandler struct {
+	sessionService service.ISessionService
+}
+
+type IHistoryHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+	HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewHistoryHandler(sessionService service.ISessionService) *HistoryHandler {
+	return &HistoryHandler{sessionService: sessionService}
+}
+
+func (h *HistoryHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	text, markup, err := h.page(0)
+	if err != nil {
+		log.Printf("Error getting session history: %v", err)
+		sendText(ctx, b, update.Message.Chat.ID, "❌ Ошибка при получении истории сеансов.")
+		return
+	}
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        text,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+// HandleCallback switches pages and opens the details of a session in the same message.
+func (h *HistoryHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+	if err != nil {
+		log.Printf("Error answering callback query: %v", err)
+	}
+	parts := strings.Split(strings.TrimPrefix(update.CallbackQuery.Data, HISTORY_PREFIX), "_")
+	var text string
+	var markup *models.InlineKeyboardMarkup
+	switch {
+	case len(parts) == 2 && parts[0] == "page":
+		page, convErr := strconv.Atoi(parts[1])
+		if convErr != nil {
+			return
+		}
+		text, markup, err = h.page(page)
+	case len(parts) == 3 && parts[0] == "session":
+		sessionID, convErr := strconv.ParseInt(parts[1], 10, 64)
+		if convErr != nil {
+			return
+		}
+		page, convErr := strconv.Atoi(parts[2])
+		if convErr != nil {
+			return
+		}
+		text, markup, err = h.details(sessionID, page)
+	default:
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting session history: %v", err)
+		return
+	}
+	message := update.CallbackQuery.Message.Message
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      message.Chat.ID,
+		MessageID:   message.ID,
+		Text:        text,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		log.Printf("Error editing history message: %v", err)
+	}
+}
+
+func (h *HistoryHandler) page(page int) (string, *models.InlineKeyboardMarkup, error) {
+	entries, total, err := h.sessionService.GetSessionHistory(page, HISTORY_PER_PAGE)
+	if err != nil {
+		return "", nil, err
+	}
+	if total == 0 {
+		return "📭 Прошедших сеансов пока нет.", nil, nil
+	}
+	pages := int((total + HISTORY_PER_PAGE - 1) / HISTORY_PER_PAGE)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📜 История сеансов (стр. %d/%d)\n", page+1, pages))
+	rows := make([][]models.InlineKeyboardButton, 0, len(entries)+1)
+	for _, entry := range entries {
+		sb.WriteString("\n")
+		sb.WriteString(formatHistoryEntry(entry))
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         historyLabel(entry.Session),
+			CallbackData: fmt.Sprintf("%ssession_%d_%d", HISTORY_PREFIX, entry.Session.ID, page),
+		}})
+	}
+	var nav []models.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, models.InlineKeyboardButton{Text: "◀️", CallbackData: fmt.Sprintf("%spage_%d", HISTORY_PREFIX, page-1)})
+	}
+	if page+1 < pages {
+		nav = append(nav, models.InlineKeyboardButton{Text: "▶️", CallbackData: fmt.Sprintf("%spage_%d", HISTORY_PREFIX, page+1)})
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	return sb.String(), &models.InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
+
+func (h *HistoryHandler) details(sessionID int64, page int) (string, *models.InlineKeyboardMarkup, error) {
+	entry, err := h.sessionService.GetSessionHistoryEntry(sessionID)
+	if err != nil {
+		return "", nil, err
+	}
+	var sb strings.Builder
+	sb.WriteString(formatHistoryEntry(entry))
+	for _, movie := range entry.Session.Movies {
+		summary, ok := entry.Ratings[movie.ID]
+		if !ok {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n📊 %s: среднее %.2f, медиана %.1f, отклонение %.2f, голосов: %d", movie.Title, summary.Mean, summary.Median, summary.StdDev, summary.Count))
+	}
+	titles := make(map[int64]string, len(entry.Session.Movies))
+	for _, movie := range entry.Session.Movies {
+		titles[movie.ID] = movie.Title
+	}
+	for _, voting := range entry.SelectionVotings {
+		sb.WriteString(fmt.Sprintf("\n🗳️ Выбран голосованием \"%s\"", voting.Title))
+		if voting.FinishedAt != nil {
+			sb.WriteString(fmt.Sprintf(" от %s", time.Unix(*voting.FinishedAt, 0).Format("02.01.2006 15:04")))
+		}
+		if title, ok := titles[*voting.WinnerMovieID]; ok {
+			sb.WriteString(fmt.Sprintf(", победитель: %s", title))
+		}
+	}
+	markup := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{
+		{Text: "⬅️ Назад", CallbackData: fmt.Sprintf("%spage_%d", HISTORY_PREFIX, page)},
+	}}}
+	return sb.String(), markup, nil
+}
+
+func formatHistoryEntry(entry *service.SessionHistoryEntry) string {
+	session := entry.Session
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🗓️ %s", time.Unix(session.FinishedAt, 0).Format("02.01.2006 15:04")))
+	if session.Status == model.SESSION_CANCELLED_STATUS {
+		sb.WriteString(" ❌ отменен")
+	}
+	sb.WriteString("\n")
+	if session.Description != "" {
+		sb.WriteString(fmt.Sprintf("📝 %s\n", session.Description))
+	}
+	for _, movie := range session.Movies {
+		sb.WriteString(fmt.Sprintf("• %s (%d)", movie.Title, movie.Year))
+		if summary, ok := entry.Ratings[movie.ID]; ok {
+			sb.WriteString(fmt.Sprintf(" — ⭐ %.2f (%d)", summary.Mean, summary.Count))
+		}
+		sb.WriteString("\n")
+	}
+	if name := displayName(&session.Creator); name != "" {
+		sb.WriteString(fmt.Sprintf("👤 Создал(а): %s\n", name))
+	}
+	return sb.String()
+}
+
+func historyLabel(session *model.Session) string {
+	label := fmt.Sprintf("🗓️ %s", time.Unix(session.FinishedAt, 0).Format("02.01.2006"))
+	if session.Status == model.SESSION_CANCELLED_STATUS {
+		label += " ❌"
+	}
+	return label
+}
@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// INLINE_SEARCH_PAGE_SIZE is how many movies are returned per page of the inline results.
+const INLINE_SEARCH_PAGE_SIZE = 20
+
+// INLINE_SEARCH_CACHE_TIME is how long Telegram caches the results, in seconds.
+const INLINE_SEARCH_CACHE_TIME = 60
+
+type InlineSearchHandler struct {
+	movieService service.IMovieService
+}
+
+type IInlineSearchHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewInlineSearchHandler(movieService service.IMovieService) *InlineSearchHandler {
+	return &InlineSearchHandler{movieService: movieService}
+}
+
+// Handle answers "@bot <query>" with the cards of the club movies, members can share them in any chat.
+// The authentication middleware lets only group members through.
+func (h *InlineSearchHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.InlineQuery
+	offset, err := strconv.Atoi(query.Offset)
+	if err != nil {
+		offset = 0
+	}
+	movies, err := h.movieService.FindMovies(query.Query, offset, INLINE_SEARCH_PAGE_SIZE)
+	if err != nil {
+		log.Printf("Error searching movies for inline query: %v", err)
+		return
+	}
+	results := make([]models.InlineQueryResult, 0, len(movies))
+	for _, movie := range movies {
+		results = append(results, &models.InlineQueryResultArticle{
+			ID:    strconv.FormatInt(movie.ID, 10),
+			Title: fmt.Sprintf("%s (%d)", movie.Title, movie.Year),
+			InputMessageContent: &models.InputTextMessageContent{
+				MessageText: service.FormatMovieCard(movie),
+				ParseMode:   models.ParseModeHTML,
+			},
+			URL:          movie.Link,
+			Description:  inlineDescription(movie),
+			ThumbnailURL: movie.PosterPreviewURL,
+		})
+	}
+	var nextOffset string
+	if len(movies) == INLINE_SEARCH_PAGE_SIZE {
+		nextOffset = strconv.Itoa(offset + INLINE_SEARCH_PAGE_SIZE)
+	}
+	_, err = b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+		InlineQueryID: query.ID,
+		Results:       results,
+		CacheTime:     INLINE_SEARCH_CACHE_TIME,
+		IsPersonal:    true,
+		NextOffset:    nextOffset,
+	})
+	if err != nil {
+		log.Printf("Error answering inline query: %v", err)
+	}
+}
+
+func inlineDescription(movie *model.Movie) string {
+	description := "📌 В предложке"
+	if movie.Status == model.MOVIE_WATCHED_STATUS {
+		description = "✅ Просмотрен"
+	}
+	if movie.IsRated() {
+		description += fmt.Sprintf(" · ⭐ %.1f", movie.Rating)
+	}
+	if movie.Directors != "" {
+		description += " · " + movie.Directors
+	}
+	return description
+}
@@ -0,0 +1,171 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/tasks"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/hibiken/asynq"
+)
+
+// ORDER_PREFIX starts the callback data of the lineup buttons, they keep working after a restart.
+const ORDER_PREFIX = "order_"
+
+const ORDER_DONE_DATA = ORDER_PREFIX + "done"
+
+type OrderHandler struct {
+	sessionService service.ISessionService
+	movieService   service.IMovieService
+	inspector      *asynq.Inspector
+	client         *asynq.Client
+}
+
+type IOrderHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+	HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewOrderHandler(sessionService service.ISessionService, movieService service.IMovieService, inspector *asynq.Inspector, client *asynq.Client) *OrderHandler {
+	return &OrderHandler{sessionService: sessionService, movieService: movieService, inspector: inspector, client: client}
+}
+
+// Handle shows the lineup of the chosen session with the buttons moving the movies up and down.
+func (h *OrderHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	pickSession(ctx, b, update, h.sessionService, "📅 Порядок какого сеанса изменить?", false, func(ctx context.Context, b *bot.Bot, update *models.Update, session *model.Session) {
+		chatID := updateChatID(update)
+		if len(session.Movies) == 0 {
+			sendText(ctx, b, chatID, "ℹ️ Нет фильмов в выбранной сессии.")
+			return
+		}
+		lineup, err := h.movieService.GetLineup(session.ID)
+		if err != nil {
+			log.Printf("Error getting lineup of session %d: %v", session.ID, err)
+			sendText(ctx, b, chatID, "❌ Ошибка при получении порядка показа.")
+			return
+		}
+		_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      chatID,
+			Text:        formatLineup(session, lineup),
+			ReplyMarkup: lineupKeyboard(session.ID, lineup),
+		})
+		if err != nil {
+			log.Printf("Error sending message: %v", err)
+		}
+	})
+}
+
+// HandleCallback moves the movie, updates the message and reschedules the rating votings of the session.
+func (h *OrderHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+	if err != nil {
+		log.Printf("Error answering callback query: %v", err)
+	}
+	message := update.CallbackQuery.Message.Message
+	if update.CallbackQuery.Data == ORDER_DONE_DATA {
+		_, err = b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+			ChatID:    message.Chat.ID,
+			MessageID: message.ID,
+		})
+		if err != nil {
+			log.Printf("Error removing lineup keyboard: %v", err)
+		}
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(update.CallbackQuery.Data, ORDER_PREFIX), "_")
+	if len(parts) != 3 {
+		return
+	}
+	sessionID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return
+	}
+	movieID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return
+	}
+	up := parts[2] == "up"
+	// A movie that has ended already has its rating voting, moving it or a movie over it would open another one
+	current, err := h.movieService.GetLineup(sessionID)
+	if err != nil {
+		log.Printf("Error getting lineup of session %d: %v", sessionID, err)
+		return
+	}
+	index := slices.IndexFunc(current, func(slot service.LineupSlot) bool { return slot.Movie.ID == movieID })
+	neighbour := index + 1
+	if up {
+		neighbour = index - 1
+	}
+	if index == -1 || neighbour < 0 || neighbour >= len(current) {
+		return
+	}
+	if now := time.Now(); !current[index].End.After(now) || !current[neighbour].End.After(now) {
+		sendText(ctx, b, message.Chat.ID, "⚠️ Уже показанные фильмы нельзя перемещать.")
+		return
+	}
+	session, moved, err := h.sessionService.MoveMovie(sessionID, movieID, up)
+	if err != nil {
+		log.Printf("Error moving movie %d in session %d: %v", movieID, sessionID, err)
+		return
+	}
+	if !moved {
+		// The message would stay the same and Telegram refuses to edit it
+		return
+	}
+	lineup, err := h.movieService.GetLineup(session.ID)
+	if err != nil {
+		log.Printf("Error getting lineup of session %d: %v", session.ID, err)
+		return
+	}
+	err = tasks.ScheduleOpenRatingVotingTasks(h.client, h.inspector, &tasks.ScheduleOpenRatingVotingsParams{
+		ChatID:    message.Chat.ID,
+		SessionID: session.ID,
+		UserID:    update.CallbackQuery.From.ID,
+		Lineup:    lineup,
+	})
+	if err != nil {
+		log.Printf("Error scheduling open rating voting tasks: %v", err)
+	}
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      message.Chat.ID,
+		MessageID:   message.ID,
+		Text:        formatLineup(session, lineup),
+		ReplyMarkup: lineupKeyboard(session.ID, lineup),
+	})
+	if err != nil {
+		log.Printf("Error editing lineup message: %v", err)
+	}
+}
+
+func formatLineup(session *model.Session, lineup []service.LineupSlot) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🎬 Порядок показа, сеанс %s:\n\n", time.Unix(session.FinishedAt, 0).Format("02.01.2006 15:04")))
+	for i, slot := range lineup {
+		sb.WriteString(fmt.Sprintf("%d. %s–%s %s (%d)\n", i+1, slot.Start.Format("15:04"), slot.End.Format("15:04"), slot.Movie.Title, slot.Movie.Year))
+	}
+	return sb.String()
+}
+
+func lineupKeyboard(sessionID int64, lineup []service.LineupSlot) *models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(lineup)+1)
+	for i, slot := range lineup {
+		title := slot.Movie.Title
+		if runes := []rune(title); len(runes) > 40 {
+			title = string(runes[:37]) + "..."
+		}
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: fmt.Sprintf("⬆️ %d. %s", i+1, title), CallbackData: fmt.Sprintf("%s%d_%d_up", ORDER_PREFIX, sessionID, slot.Movie.ID)},
+			{Text: "⬇️", CallbackData: fmt.Sprintf("%s%d_%d_down", ORDER_PREFIX, sessionID, slot.Movie.ID)},
+		})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{Text: "✅ Готово", CallbackData: ORDER_DONE_DATA}})
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/tasks"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 )
@@ -49,9 +50,13 @@ func (h *PollAnswerHandler) Handle(ctx context.Context, b *bot.Bot, update *mode
 		}
 
 		if poll.Type == model.VOTING_RATING_TYPE {
-			rating := optionID + 1
-			vote.Rating = &rating
 			vote.MovieID = poll.MovieID
+			if optionID == tasks.RATING_ABSTAIN_OPTION_ID {
+				vote.Abstained = true
+			} else {
+				rating := optionID + 1
+				vote.Rating = &rating
+			}
 		} else if poll.Type == model.VOTING_SELECTION_TYPE {
 			options, err := h.pollService.GetPollOptionsByPollID(poll.ID)
 			if err != nil {
@@ -0,0 +1,107 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type RankedBallotHandler struct {
+	pollService   service.IPollService
+	voteService   service.IVoteService
+	votingService service.IVotingService
+}
+
+type IRankedBallotHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewRankedBallotHandler(pollService service.IPollService, voteService service.IVoteService, votingService service.IVotingService) *RankedBallotHandler {
+	return &RankedBallotHandler{pollService: pollService, voteService: voteService, votingService: votingService}
+}
+
+func (h *RankedBallotHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID := update.CallbackQuery.From.ID
+	data := strings.TrimPrefix(update.CallbackQuery.Data, service.RANKED_BALLOT_PREFIX)
+	parts := strings.SplitN(data, "_", 2)
+	if len(parts) != 2 {
+		h.answer(ctx, b, update, "❓ Неизвестный выбор.")
+		return
+	}
+	votingID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		h.answer(ctx, b, update, "❓ Неизвестный выбор.")
+		return
+	}
+	voting, err := h.votingService.GetVotingByID(votingID)
+	if err != nil || voting.Status != model.VOTING_ACTIVE_STATUS {
+		h.answer(ctx, b, update, "🔒 Голосование уже завершено.")
+		return
+	}
+	poll, err := h.pollService.GetPollByVotingID(votingID)
+	if err != nil {
+		h.answer(ctx, b, update, "🔒 Голосование уже завершено.")
+		return
+	}
+	options, err := h.pollService.GetPollOptionsByPollID(poll.ID)
+	if err != nil {
+		log.Printf("Error getting poll options: %v", err)
+		h.answer(ctx, b, update, "❌ Ошибка при сохранении голоса.")
+		return
+	}
+
+	if parts[1] == "reset" {
+		if err := h.voteService.ResetVotes(votingID, userID); err != nil {
+			log.Printf("Error resetting votes: %v", err)
+			h.answer(ctx, b, update, "❌ Ошибка при сбросе голоса.")
+			return
+		}
+		h.answer(ctx, b, update, "🔄 Ваш выбор сброшен.")
+		return
+	}
+
+	optionIndex, err := strconv.Atoi(parts[1])
+	if err != nil || optionIndex < 0 || optionIndex >= len(options) {
+		log.Printf("Invalid option ID: %s", parts[1])
+		h.answer(ctx, b, update, "❓ Неизвестный выбор.")
+		return
+	}
+	ballot, err := h.voteService.AddRankedVote(votingID, userID, options[optionIndex].MovieID)
+	if err != nil {
+		log.Printf("Error saving ranked vote: %v", err)
+		h.answer(ctx, b, update, "❌ Ошибка при сохранении голоса.")
+		return
+	}
+
+	titles := make(map[int64]string, len(options))
+	for _, option := range options {
+		titles[option.MovieID] = option.Movie.Title
+	}
+	ranking := make([]string, 0, len(ballot))
+	for _, vote := range ballot {
+		ranking = append(ranking, fmt.Sprintf("%d. %s", *vote.Rank, titles[*vote.MovieID]))
+	}
+	h.answer(ctx, b, update, fmt.Sprintf("✅ Ваш порядок:\n%s", strings.Join(ranking, "\n")))
+}
+
+func (h *RankedBallotHandler) answer(ctx context.Context, b *bot.Bot, update *models.Update, text string) {
+	// Telegram limits callback alerts to 200 characters
+	if runes := []rune(text); len(runes) > 200 {
+		text = string(runes[:197]) + "..."
+	}
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		Text:            text,
+		ShowAlert:       true,
+	})
+	if err != nil {
+		log.Printf("Error answering callback query: %v", err)
+	}
+}
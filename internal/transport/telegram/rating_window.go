@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type RatingWindowHandler struct {
+	sessionService service.ISessionService
+	cfg            *config.VotingConfig
+}
+
+type IRatingWindowHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewRatingWindowHandler(sessionService service.ISessionService, cfg *config.VotingConfig) *RatingWindowHandler {
+	return &RatingWindowHandler{sessionService: sessionService, cfg: cfg}
+}
+
+// Handle sets how long the rating votings of the chosen session stay open.
+func (h *RatingWindowHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	adminID := update.Message.From.ID
+	pickSession(ctx, b, update, h.sessionService, "📅 Для какого сеанса изменить время оценки фильмов?", false, func(ctx context.Context, b *bot.Bot, update *models.Update, session *model.Session) {
+		window := h.cfg.RatingDuration
+		if session.RatingWindow > 0 {
+			window = time.Duration(session.RatingWindow) * time.Minute
+		}
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: updateChatID(update),
+			Text:   fmt.Sprintf("⏱️ Сколько часов будут открыты оценки фильмов сеанса %s?\n\n💡 Сейчас: %s", time.Unix(session.FinishedAt, 0).Format("02.01.2006 15:04"), formatWindow(window)),
+			ReplyMarkup: durationKeyboard(b, adminID, func(ctx context.Context, b *bot.Bot, update *models.Update, duration time.Duration) {
+				h.set(ctx, b, updateChatID(update), session, duration)
+			}),
+		})
+		if err != nil {
+			log.Printf("Error sending message: %v", err)
+		}
+	})
+}
+
+func (h *RatingWindowHandler) set(ctx context.Context, b *bot.Bot, chatID int64, session *model.Session, window time.Duration) {
+	if err := h.sessionService.SetRatingWindow(session.ID, window); err != nil {
+		log.Printf("Error setting rating window of session %d: %v", session.ID, err)
+		sendText(ctx, b, chatID, "❌ Ошибка при изменении времени оценки.")
+		return
+	}
+	sendText(ctx, b, chatID, fmt.Sprintf("✅ Оценки фильмов сеанса %s будут открыты %s", time.Unix(session.FinishedAt, 0).Format("02.01.2006 15:04"), formatWindow(window)))
+}
+
+func formatWindow(window time.Duration) string {
+	if window%time.Hour == 0 {
+		return fmt.Sprintf("%d ч.", int(window.Hours()))
+	}
+	return fmt.Sprintf("%d мин.", int(window.Minutes()))
+}
@@ -0,0 +1,42 @@
+package telegram
+
+import (
+	"context"
+	"log"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type RemindersHandler struct {
+	userService service.IUserService
+}
+
+type IRemindersHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewRemindersHandler(userService service.IUserService) *RemindersHandler {
+	return &RemindersHandler{userService: userService}
+}
+
+// Handle switches between reminders in a private chat with the bot and mentions in the group.
+func (h *RemindersHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, err := h.userService.FindByID(update.Message.From.ID)
+	if err != nil {
+		sendText(ctx, b, update.Message.Chat.ID, "❌ Сначала зарегистрируйся с помощью /register.")
+		return
+	}
+	enabled := !user.VotingReminders
+	if err := h.userService.SetVotingReminders(user.ID, enabled); err != nil {
+		log.Printf("Error updating voting reminders: %v", err)
+		sendText(ctx, b, update.Message.Chat.ID, "❌ Не удалось изменить настройки напоминаний.")
+		return
+	}
+	if enabled {
+		sendText(ctx, b, update.Message.Chat.ID, "🔔 Напоминания о голосованиях будут приходить в личные сообщения. Не забудь написать боту /start в личке!")
+		return
+	}
+	sendText(ctx, b, update.Message.Chat.ID, "🔕 Напоминания о голосованиях снова будут приходить упоминанием в группе.")
+}
@@ -25,7 +25,9 @@ const (
 type RemoveMovieFromSessionHandler struct {
 	f              *fsm.FSM
 	sessionService service.ISessionService
+	movieService   service.IMovieService
 	inspector      *asynq.Inspector
+	client         *asynq.Client
 }
 
 type IRemoveMovieFromSessionHandler interface {
@@ -34,11 +36,13 @@ type IRemoveMovieFromSessionHandler interface {
 	Remove(f *fsm.FSM, args ...any)
 }
 
-func NewRemoveMovieFromSessionHandler(sessionService service.ISessionService, inspector *asynq.Inspector, f *fsm.FSM) IRemoveMovieFromSessionHandler {
+func NewRemoveMovieFromSessionHandler(sessionService service.ISessionService, movieService service.IMovieService, inspector *asynq.Inspector, client *asynq.Client, f *fsm.FSM) IRemoveMovieFromSessionHandler {
 	return &RemoveMovieFromSessionHandler{
 		f:              f,
 		sessionService: sessionService,
+		movieService:   movieService,
 		inspector:      inspector,
+		client:         client,
 	}
 }
 
@@ -48,11 +52,22 @@ func (h *RemoveMovieFromSessionHandler) Handle(ctx context.Context, b *bot.Bot,
 	if currentState != stateDefault {
 		return
 	}
-	session, err := h.sessionService.FindOngoingSession()
-	if err != nil || len(session.Movies) == 0 {
+	messageID := update.Message.ID
+	pickSession(ctx, b, update, h.sessionService, "📅 Из какого сеанса удалить фильмы?", false, func(ctx context.Context, b *bot.Bot, update *models.Update, session *model.Session) {
+		if h.f.Current(userID) != stateDefault {
+			return
+		}
+		h.showMovies(ctx, b, update, userID, messageID, session)
+	})
+}
+
+// showMovies lists the movies of the chosen session, so the admin can pick the ones to remove.
+func (h *RemoveMovieFromSessionHandler) showMovies(ctx context.Context, b *bot.Bot, update *models.Update, userID int64, messageID int, session *model.Session) {
+	chatID := updateChatID(update)
+	if len(session.Movies) == 0 {
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "ℹ️ Нет фильмов в текущей сессии.",
+			ChatID: chatID,
+			Text:   "ℹ️ Нет фильмов в выбранной сессии.",
 		})
 		if err != nil {
 			log.Printf("Error sending message: %v", err)
@@ -67,12 +82,13 @@ func (h *RemoveMovieFromSessionHandler) Handle(ctx context.Context, b *bot.Bot,
 		formattedMovies = append(formattedMovies, bot.EscapeMarkdown(fmt.Sprintf("%d. %s", idx+1, movie.Title)))
 	}
 	h.f.Set(userID, "movies", session.Movies)
+	h.f.Set(userID, "session_id", session.ID)
 	p := paginator.New(b, formattedMovies, opts...)
 	showOpts := []paginator.ShowOption{}
-	paginatorMsg, err := p.Show(ctx, b, update.Message.Chat.ID, showOpts...)
+	paginatorMsg, err := p.Show(ctx, b, chatID, showOpts...)
 	if err != nil {
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
+			ChatID: chatID,
 			Text:   "❌ Ошибка при отображении фильмов.",
 		})
 		if err != nil {
@@ -80,7 +96,7 @@ func (h *RemoveMovieFromSessionHandler) Handle(ctx context.Context, b *bot.Bot,
 		}
 		return
 	}
-	fsmutils.AppendMessageID(h.f, userID, update.Message.ID)
+	fsmutils.AppendMessageID(h.f, userID, messageID)
 	fsmutils.AppendMessageID(h.f, userID, paginatorMsg.ID)
 	h.f.Transition(userID, statePrepareMoviesToDelete, userID, ctx, b, update, paginatorMsg.ID)
 }
@@ -97,7 +113,7 @@ func (h *RemoveMovieFromSessionHandler) PrepareMoviesToDelete(f *fsm.FSM, args .
 	paginatorMsgID := args[4].(int)
 	f.Set(userID, "paginatorMsgID", paginatorMsgID)
 	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
+		ChatID: updateChatID(update),
 		Text:   "📝 Перечислите номера фильмов, которые хотите удалить из сессии, через запятую.",
 	})
 	if err != nil {
@@ -121,11 +137,16 @@ func (h *RemoveMovieFromSessionHandler) Remove(f *fsm.FSM, args ...any) {
 		return
 	}
 
-	session, err := h.sessionService.FindOngoingSession()
+	sessionID, ok := f.Get(userID, "session_id")
+	if !ok {
+		f.Reset(userID)
+		return
+	}
+	session, err := h.sessionService.FindSessionByID(sessionID.(int64))
 	if err != nil {
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
-			Text:   "ℹ️ Нет активной сессии.",
+			Text:   "ℹ️ Выбранная сессия не найдена.",
 		})
 		if err != nil {
 			log.Printf("Error sending message: %v", err)
@@ -148,18 +169,7 @@ func (h *RemoveMovieFromSessionHandler) Remove(f *fsm.FSM, args ...any) {
 	}
 
 	for _, voting := range votings {
-		var taskId string
-		switch voting.Type {
-		case model.VOTING_RATING_TYPE:
-			taskId = fmt.Sprintf("%s-%d", tasks.CloseRatingVotingTaskType, voting.ID)
-		case model.VOTING_SELECTION_TYPE:
-			taskId = fmt.Sprintf("%s-%d", tasks.CloseSelectionVotingTaskType, voting.ID)
-		}
-		taskInfo, err := h.inspector.GetTaskInfo(tasks.QUEUE, taskId)
-		if err != nil {
-			continue
-		}
-		err = h.inspector.DeleteTask(taskInfo.Queue, taskInfo.ID)
+		taskInfo, err := tasks.DeleteCloseVotingTask(h.inspector, voting)
 		if err != nil {
 			continue
 		}
@@ -177,6 +187,32 @@ func (h *RemoveMovieFromSessionHandler) Remove(f *fsm.FSM, args ...any) {
 			log.Printf("Error deleting message: %v", err)
 		}
 	}
+	if err := tasks.DeleteOpenRatingVotingTasks(h.inspector, session.ID, ids.([]int64)); err != nil {
+		log.Printf("Error deleting open rating voting tasks: %v", err)
+	}
+	// the movies after the removed ones start earlier now
+	lineup, err := h.movieService.GetLineup(session.ID)
+	if err != nil {
+		log.Printf("Error getting lineup of session %d: %v", session.ID, err)
+	} else {
+		err = tasks.ScheduleFinishSessionTask(h.client, h.inspector, &tasks.ScheduleFinishSessionParams{
+			SessionID: session.ID,
+			StartsAt:  session.FinishedAt,
+			Lineup:    lineup,
+		})
+		if err != nil {
+			log.Printf("Error scheduling finish session task: %v", err)
+		}
+		err = tasks.ScheduleOpenRatingVotingTasks(h.client, h.inspector, &tasks.ScheduleOpenRatingVotingsParams{
+			ChatID:    update.Message.Chat.ID,
+			SessionID: session.ID,
+			UserID:    userID,
+			Lineup:    lineup,
+		})
+		if err != nil {
+			log.Printf("Error scheduling open rating voting tasks: %v", err)
+		}
+	}
 	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: update.Message.Chat.ID,
 		Text:   "✅ Выбранные фильмы были успешно удалены из сессии.",
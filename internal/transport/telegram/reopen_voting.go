@@ -0,0 +1,145 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/tasks"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/hibiken/asynq"
+)
+
+type ReopenVotingHandler struct {
+	votingService service.IVotingService
+	inspector     *asynq.Inspector
+	client        *asynq.Client
+	cfg           *config.VotingConfig
+	sessionCfg    *config.SessionConfig
+}
+
+type IReopenVotingHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewReopenVotingHandler(votingService service.IVotingService, inspector *asynq.Inspector, client *asynq.Client, cfg *config.VotingConfig, sessionCfg *config.SessionConfig) *ReopenVotingHandler {
+	return &ReopenVotingHandler{votingService: votingService, inspector: inspector, client: client, cfg: cfg, sessionCfg: sessionCfg}
+}
+
+func (h *ReopenVotingHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	since := time.Now().Add(-h.cfg.ReopenWindow).Unix()
+	votings, err := h.votingService.FindClosedVotingsSince(since)
+	if err != nil || len(votings) == 0 {
+		sendText(ctx, b, update.Message.Chat.ID, fmt.Sprintf("ℹ️ Нет голосований, завершенных за последние %d ч.", int(h.cfg.ReopenWindow.Hours())))
+		return
+	}
+	adminID := update.Message.From.ID
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   "🔄 Выберите голосование, которое нужно открыть заново. Отданные голоса сохранятся, результат будет отменен",
+		ReplyMarkup: votingsKeyboard(b, adminID, votings, func(ctx context.Context, b *bot.Bot, update *models.Update, voting *model.Voting) {
+			h.onVotingSelect(ctx, b, update, adminID, voting)
+		}),
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+func (h *ReopenVotingHandler) onVotingSelect(ctx context.Context, b *bot.Bot, update *models.Update, adminID int64, voting *model.Voting) {
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.CallbackQuery.Message.Message.Chat.ID,
+		Text:   fmt.Sprintf("⏱️ На сколько часов открыть голосование \"%s\"?", voting.Title),
+		ReplyMarkup: durationKeyboard(b, adminID, func(ctx context.Context, b *bot.Bot, update *models.Update, duration time.Duration) {
+			h.reopen(ctx, b, update, adminID, voting, duration)
+		}),
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+func (h *ReopenVotingHandler) reopen(ctx context.Context, b *bot.Bot, update *models.Update, adminID int64, voting *model.Voting, duration time.Duration) {
+	chatID := update.CallbackQuery.Message.Message.Chat.ID
+	finishedAt := time.Now().Add(duration)
+	var pollOptions []models.InputPollOption
+	if voting.Type == model.VOTING_RATING_TYPE {
+		pollOptions = tasks.RATING_VOTING_OPTIONS
+	}
+	poll, session, err := h.votingService.ReopenVoting(&service.ReopenVotingParams{
+		Bot:         b,
+		Context:     ctx,
+		ChatID:      chatID,
+		VotingID:    voting.ID,
+		FinishedAt:  finishedAt.Unix(),
+		PollOptions: pollOptions,
+		PriorWeight: h.cfg.RatingPriorWeight,
+	})
+	if err != nil {
+		log.Printf("Error reopening voting: %v", err)
+		sendText(ctx, b, chatID, "❌ Не удалось открыть голосование заново.")
+		return
+	}
+	if session != nil && voting.WinnerMovieID != nil {
+		// The winner left the session, so its rating voting must not be opened
+		if err := tasks.DeleteOpenRatingVotingTasks(h.inspector, session.ID, []int64{*voting.WinnerMovieID}); err != nil {
+			log.Printf("Error deleting open rating voting task: %v", err)
+		}
+	}
+	if session != nil && session.Status == model.SESSION_CANCELLED_STATUS {
+		// The session had only the winner and was cancelled, nothing is left to finish or remind about
+		if err := tasks.DeleteFinishSessionTask(h.inspector, session.ID); err != nil {
+			log.Printf("Error deleting finish session task: %v", err)
+		}
+		if err := tasks.DeleteRemindSessionTasks(h.inspector, session.ID, h.sessionCfg.ReminderOffsets); err != nil {
+			log.Printf("Error deleting remind session tasks: %v", err)
+		}
+	}
+	scheduleReopenedVoting(h.client, h.cfg, chatID, adminID, voting, poll, duration)
+	sendText(ctx, b, chatID, fmt.Sprintf("🔄 Голосование \"%s\" открыто заново до %s.", voting.Title, finishedAt.Format("02.01.2006 15:04")))
+}
+
+// scheduleReopenedVoting enqueues the close and the reminder of a voting whose ballot was posted again.
+func scheduleReopenedVoting(client *asynq.Client, cfg *config.VotingConfig, chatID int64, adminID int64, voting *model.Voting, poll *model.Poll, duration time.Duration) {
+	var err error
+	switch voting.Type {
+	case model.VOTING_SELECTION_TYPE:
+		err = tasks.EnqueueCloseSelectionVotingTask(client, duration, &tasks.CloseSelectionVotingPayload{
+			PollID:    poll.PollID,
+			MessageID: poll.MessageID,
+			ChatID:    chatID,
+			VotingID:  voting.ID,
+			UserID:    adminID,
+		})
+	case model.VOTING_RATING_TYPE:
+		var movieID int64
+		if voting.MovieID != nil {
+			movieID = *voting.MovieID
+		}
+		err = tasks.EnqueueCloseRatingVotingTask(client, duration, &tasks.CloseRatingVotingPayload{
+			PollID:    poll.PollID,
+			MessageID: poll.MessageID,
+			ChatID:    chatID,
+			VotingID:  voting.ID,
+			MovieID:   movieID,
+			UserID:    adminID,
+		})
+	}
+	if err != nil {
+		log.Printf("Error scheduling close voting task: %v", err)
+	}
+	err = tasks.EnqueueRemindVotingTask(client, &tasks.EnqueueRemindVotingParams{
+		ChatID:   chatID,
+		VotingID: voting.ID,
+		Duration: duration,
+		Offset:   cfg.ReminderOffset,
+	})
+	if err != nil {
+		log.Printf("Error scheduling remind voting task: %v", err)
+	}
+}
@@ -2,11 +2,11 @@ package telegram
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"log"
 	"time"
 
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/tasks"
 	fsmutils "github.com/Forceres/tg-bot-movieclub-go/internal/utils/fsm"
@@ -21,8 +21,10 @@ const stateRescheduleSession fsm.StateID = "reschedule_session"
 type ResheduleSessionHandler struct {
 	f              *fsm.FSM
 	sessionService service.ISessionService
+	movieService   service.IMovieService
 	inspector      *asynq.Inspector
 	client         *asynq.Client
+	cfg            *config.SessionConfig
 }
 
 type IRescheduleSessionHandler interface {
@@ -30,8 +32,8 @@ type IRescheduleSessionHandler interface {
 	RescheduleSession(f *fsm.FSM, args ...any)
 }
 
-func NewResheduleSessionHandler(f *fsm.FSM, sessionService service.ISessionService, inspector *asynq.Inspector, client *asynq.Client) IRescheduleSessionHandler {
-	return &ResheduleSessionHandler{f: f, sessionService: sessionService, inspector: inspector, client: client}
+func NewResheduleSessionHandler(f *fsm.FSM, sessionService service.ISessionService, movieService service.IMovieService, inspector *asynq.Inspector, client *asynq.Client, cfg *config.SessionConfig) IRescheduleSessionHandler {
+	return &ResheduleSessionHandler{f: f, sessionService: sessionService, movieService: movieService, inspector: inspector, client: client, cfg: cfg}
 }
 
 func (h *ResheduleSessionHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -40,16 +42,14 @@ func (h *ResheduleSessionHandler) Handle(ctx context.Context, b *bot.Bot, update
 	if currentState != stateDefault {
 		return
 	}
-	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
-		Text:   "📅 Обновление даты сессии просмотра...",
+	pickSession(ctx, b, update, h.sessionService, "📅 Какой сеанс перенести?", false, func(ctx context.Context, b *bot.Bot, update *models.Update, session *model.Session) {
+		if h.f.Current(userID) != stateDefault {
+			return
+		}
+		h.f.Set(userID, "session_id", session.ID)
+		h.f.Set(userID, "datepicker", "session")
+		h.f.Transition(userID, stateDate, userID, ctx, b, update)
 	})
-	if err != nil {
-		log.Printf("Error sending message: %v", err)
-		return
-	}
-	h.f.Set(userID, "datepicker", "session")
-	h.f.Transition(userID, stateDate, userID, ctx, b, update)
 }
 
 func (h *ResheduleSessionHandler) RescheduleSession(f *fsm.FSM, args ...any) {
@@ -61,12 +61,17 @@ func (h *ResheduleSessionHandler) RescheduleSession(f *fsm.FSM, args ...any) {
 	ctx := args[1].(context.Context)
 	b := args[2].(*bot.Bot)
 	update := args[3].(*models.Update)
-	session, err := h.sessionService.FindOngoingSession()
+	sessionID, ok := f.Get(userID, "session_id")
+	if !ok {
+		f.Reset(userID)
+		return
+	}
+	session, err := h.sessionService.FindSessionByID(sessionID.(int64))
 	if err != nil {
-		log.Printf("Error finding ongoing session: %v", err)
+		log.Printf("Error finding session: %v", err)
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
-			Text:   "ℹ️ Нет текущей сессии просмотра.",
+			Text:   "ℹ️ Выбранная сессия просмотра не найдена.",
 		})
 		if err != nil {
 			log.Printf("Error sending error message: %v", err)
@@ -92,62 +97,42 @@ func (h *ResheduleSessionHandler) RescheduleSession(f *fsm.FSM, args ...any) {
 		f.Reset(userID)
 		return
 	}
-	taskId := fmt.Sprintf("%s-%d", tasks.FinishSessionTaskType, session.ID)
-	_, err = h.inspector.GetTaskInfo(tasks.QUEUE, taskId)
-	if err != nil {
-		log.Printf("Error getting task info: %v", err)
-	}
-	if err := h.inspector.DeleteTask(tasks.QUEUE, taskId); err != nil {
-		log.Printf("Error deleting task: %v", err)
-	} else {
-		log.Printf("Deleted scheduled finish session task: %s", taskId)
+	if err := tasks.DeleteRemindSessionTasks(h.inspector, session.ID, h.cfg.ReminderOffsets); err != nil {
+		log.Printf("Error deleting remind session tasks: %v", err)
 	}
-	duration := time.Until(time.Unix(finishedAt, 0))
-	err = tasks.EnqueueFinishSessionTask(h.client, &tasks.EnqueueFinishSessionParams{
-		SessionID: session.ID,
-		Duration:  duration,
+	err = tasks.EnqueueRemindSessionTasks(h.client, &tasks.EnqueueRemindSessionParams{
+		ChatID:     update.Message.Chat.ID,
+		SessionID:  session.ID,
+		FinishedAt: finishedAt,
+		Offsets:    h.cfg.ReminderOffsets,
 	})
 	if err != nil {
-		log.Printf("Error scheduling new finish session task: %v", err)
-	} else {
-		log.Printf("Scheduled new finish session task for session: %d", session.ID)
-	}
-	taskIds := make([]string, 0)
-	for _, movie := range session.Movies {
-		taskIds = append(taskIds, fmt.Sprintf("%s-%d-%d", tasks.OpenRatingVotingTaskType, session.ID, movie.ID))
+		log.Printf("Error scheduling remind session tasks: %v", err)
 	}
-	openRatingVotingTasks := make([]*asynq.TaskInfo, 0)
-	for _, tId := range taskIds {
-		taskInfo, err := h.inspector.GetTaskInfo(tasks.QUEUE, taskId)
+	lineup, err := h.movieService.GetLineup(session.ID)
+	if err != nil {
+		log.Printf("Error getting lineup of session %d: %v", session.ID, err)
+	} else {
+		err = tasks.ScheduleFinishSessionTask(h.client, h.inspector, &tasks.ScheduleFinishSessionParams{
+			SessionID: session.ID,
+			StartsAt:  finishedAt,
+			Lineup:    lineup,
+		})
 		if err != nil {
-			log.Printf("Error getting task info: %v", err)
-		}
-		openRatingVotingTasks = append(openRatingVotingTasks, taskInfo)
-		if err := h.inspector.DeleteTask(tasks.QUEUE, tId); err != nil {
-			log.Printf("Error deleting task: %v", err)
+			log.Printf("Error scheduling new finish session task: %v", err)
 		} else {
-			log.Printf("Deleted scheduled open rating voting task: %s", tId)
-		}
-	}
-	for _, t := range openRatingVotingTasks {
-		var p tasks.OpenRatingVotingPayload
-		if err := json.Unmarshal(t.Payload, &p); err != nil {
-			log.Printf("Error unmarshaling task payload: %v", err)
-			continue
+			log.Printf("Scheduled new finish session task for session: %d", session.ID)
 		}
-		duration := time.Until(time.Unix(finishedAt, 0))
-		err := tasks.EnqueueOpenRatingVotingTask(h.client, &tasks.EnqueueOpenRatingVotingParams{
-			ChatID:    p.ChatID,
+		err = tasks.ScheduleOpenRatingVotingTasks(h.client, h.inspector, &tasks.ScheduleOpenRatingVotingsParams{
+			ChatID:    update.Message.Chat.ID,
 			SessionID: session.ID,
-			Movie:     p.Movie,
-			UserID:    p.UserID,
-			TaskID:    t.ID,
-			Duration:  duration,
+			UserID:    userID,
+			Lineup:    lineup,
 		})
 		if err != nil {
-			log.Printf("Error scheduling new open rating voting task: %v", err)
+			log.Printf("Error scheduling new open rating voting tasks: %v", err)
 		} else {
-			log.Printf("Scheduled new open rating voting task for session: %d", session.ID)
+			log.Printf("Scheduled new open rating voting tasks for session: %d", session.ID)
 		}
 	}
 	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
@@ -0,0 +1,174 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/tasks"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/hibiken/asynq"
+)
+
+type RestoreSessionHandler struct {
+	sessionService service.ISessionService
+	votingService  service.IVotingService
+	movieService   service.IMovieService
+	inspector      *asynq.Inspector
+	client         *asynq.Client
+	sessionCfg     *config.SessionConfig
+	votingCfg      *config.VotingConfig
+}
+
+type IRestoreSessionHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewRestoreSessionHandler(sessionService service.ISessionService, votingService service.IVotingService, movieService service.IMovieService, inspector *asynq.Inspector, client *asynq.Client, sessionCfg *config.SessionConfig, votingCfg *config.VotingConfig) *RestoreSessionHandler {
+	return &RestoreSessionHandler{
+		sessionService: sessionService,
+		votingService:  votingService,
+		movieService:   movieService,
+		inspector:      inspector,
+		client:         client,
+		sessionCfg:     sessionCfg,
+		votingCfg:      votingCfg,
+	}
+}
+
+func (h *RestoreSessionHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	since := time.Now().Add(-h.sessionCfg.RestoreWindow).Unix()
+	sessions, err := h.sessionService.FindCancelledSessionsSince(since)
+	if err != nil || len(sessions) == 0 {
+		sendText(ctx, b, update.Message.Chat.ID, fmt.Sprintf("ℹ️ Нет сеансов, отмененных за последние %d ч.", int(h.sessionCfg.RestoreWindow.Hours())))
+		return
+	}
+	adminID := update.Message.From.ID
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   "♻️ Какой отмененный сеанс восстановить?",
+		ReplyMarkup: sessionsKeyboard(b, adminID, sessions, false, func(ctx context.Context, b *bot.Bot, update *models.Update, session *model.Session) {
+			h.restore(ctx, b, updateChatID(update), adminID, session.ID)
+		}),
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+// restore brings the session back with its tasks and reopens the votings cancelled together with it.
+func (h *RestoreSessionHandler) restore(ctx context.Context, b *bot.Bot, chatID int64, adminID int64, sessionID int64) {
+	session, votings, err := h.sessionService.RestoreSession(sessionID, h.sessionCfg.RestoreWindow)
+	if err != nil {
+		if errors.Is(err, service.ErrSessionPassed) {
+			sendText(ctx, b, chatID, "⏰ Время сеанса уже прошло, его нельзя восстановить.")
+			return
+		}
+		if errors.Is(err, service.ErrRestoreWindowPassed) {
+			sendText(ctx, b, chatID, fmt.Sprintf("⏰ Сеанс отменен более %d ч. назад, его нельзя восстановить.", int(h.sessionCfg.RestoreWindow.Hours())))
+			return
+		}
+		log.Printf("Error restoring session %d: %v", sessionID, err)
+		sendText(ctx, b, chatID, "❌ Ошибка при восстановлении сеанса.")
+		return
+	}
+	err = tasks.EnqueueRemindSessionTasks(h.client, &tasks.EnqueueRemindSessionParams{
+		ChatID:     chatID,
+		SessionID:  session.ID,
+		FinishedAt: session.FinishedAt,
+		Offsets:    h.sessionCfg.ReminderOffsets,
+	})
+	if err != nil {
+		log.Printf("Error scheduling remind session tasks: %v", err)
+	}
+	lineup, err := h.movieService.GetLineup(session.ID)
+	if err != nil {
+		log.Printf("Error getting lineup of session %d: %v", session.ID, err)
+	} else {
+		err = tasks.ScheduleFinishSessionTask(h.client, h.inspector, &tasks.ScheduleFinishSessionParams{
+			SessionID: session.ID,
+			StartsAt:  session.FinishedAt,
+			Lineup:    lineup,
+		})
+		if err != nil {
+			log.Printf("Error scheduling finish session task: %v", err)
+		}
+		err = tasks.ScheduleOpenRatingVotingTasks(h.client, h.inspector, &tasks.ScheduleOpenRatingVotingsParams{
+			ChatID:    chatID,
+			SessionID: session.ID,
+			UserID:    adminID,
+			Lineup:    lineup,
+		})
+		if err != nil {
+			log.Printf("Error scheduling open rating voting tasks: %v", err)
+		}
+	}
+
+	window := h.votingCfg.RatingDuration
+	if session.RatingWindow > 0 {
+		window = time.Duration(session.RatingWindow) * time.Minute
+	}
+	var reopened, failed []string
+	for _, voting := range votings {
+		// Group polls and the final of a bracket depend on each other
+		if voting.BracketID != nil {
+			failed = append(failed, voting.Title)
+			continue
+		}
+		var pollOptions []models.InputPollOption
+		duration := window
+		if voting.Type == model.VOTING_RATING_TYPE {
+			pollOptions = tasks.RATING_VOTING_OPTIONS
+		} else {
+			duration = restoredDuration(voting, window)
+		}
+		poll, _, err := h.votingService.ReopenVoting(&service.ReopenVotingParams{
+			Bot:         b,
+			Context:     ctx,
+			ChatID:      chatID,
+			VotingID:    voting.ID,
+			FinishedAt:  time.Now().Add(duration).Unix(),
+			PollOptions: pollOptions,
+			PriorWeight: h.votingCfg.RatingPriorWeight,
+		})
+		if err != nil {
+			log.Printf("Error reopening voting %d: %v", voting.ID, err)
+			failed = append(failed, voting.Title)
+			continue
+		}
+		scheduleReopenedVoting(h.client, h.votingCfg, chatID, adminID, voting, poll, duration)
+		reopened = append(reopened, fmt.Sprintf("%s (%s)", voting.Title, formatWindow(duration)))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("♻️ Сеанс %s восстановлен.", time.Unix(session.FinishedAt, 0).Format("02.01.2006 15:04")))
+	if len(reopened) > 0 {
+		sb.WriteString(fmt.Sprintf("\n🔄 Голосования открыты заново: %s", strings.Join(reopened, ", ")))
+	}
+	if len(failed) > 0 {
+		sb.WriteString(fmt.Sprintf("\n⚠️ Не удалось восстановить голосования: %s", strings.Join(failed, ", ")))
+	}
+	sendText(ctx, b, chatID, sb.String())
+}
+
+// restoredDuration is the time a selection voting had left when it was cancelled with the session,
+// or its whole duration when it was about to close. Votings cancelled before the close time was kept get the fallback.
+func restoredDuration(voting *model.Voting, fallback time.Duration) time.Duration {
+	if voting.PlannedFinishedAt == nil || voting.FinishedAt == nil {
+		return fallback
+	}
+	if left := time.Duration(*voting.PlannedFinishedAt-*voting.FinishedAt) * time.Second; left >= time.Minute {
+		return left.Round(time.Minute)
+	}
+	if whole := time.Duration(*voting.PlannedFinishedAt-voting.CreatedAt.Unix()) * time.Second; whole >= time.Minute {
+		return whole.Round(time.Minute)
+	}
+	return fallback
+}
@@ -0,0 +1,146 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/stats"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type ResultsHandler struct {
+	votingService service.IVotingService
+	voteService   service.IVoteService
+	pollService   service.IPollService
+}
+
+type IResultsHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewResultsHandler(votingService service.IVotingService, voteService service.IVoteService, pollService service.IPollService) *ResultsHandler {
+	return &ResultsHandler{votingService: votingService, voteService: voteService, pollService: pollService}
+}
+
+func (h *ResultsHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	votings, err := h.votingService.FindVotingByStatus(model.VOTING_ACTIVE_STATUS)
+	if err != nil || len(votings) == 0 {
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "🗳️ Активных голосований нет.",
+		})
+		if err != nil {
+			log.Printf("Error sending message: %v", err)
+		}
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("📊 Промежуточные результаты:\n")
+	for _, voting := range votings {
+		sb.WriteString(fmt.Sprintf("\n🗳️ %s", voting.Title))
+		if voting.FinishedAt != nil {
+			sb.WriteString(fmt.Sprintf(" (до %s)", time.Unix(*voting.FinishedAt, 0).Format("02.01.2006 15:04")))
+		}
+		sb.WriteString("\n")
+		tally, err := h.formatTally(voting)
+		if err != nil {
+			log.Printf("Error calculating tally for voting %d: %v", voting.ID, err)
+			sb.WriteString("❌ Не удалось получить результаты\n")
+			continue
+		}
+		sb.WriteString(tally)
+		if voting.BracketID != nil && voting.BracketGroup == 0 {
+			sb.WriteString(h.formatBracket(*voting.BracketID))
+		}
+	}
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   sb.String(),
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+func (h *ResultsHandler) formatTally(voting *model.Voting) (string, error) {
+	// Secret ballots are never shown before the close, whatever the tally setting is
+	if voting.HideTally || voting.Secret {
+		voters, err := h.voteService.CountVoters(voting.ID)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("🙈 Результаты скрыты до завершения, проголосовали: %d\n", voters), nil
+	}
+	if voting.Type == model.VOTING_RATING_TYPE {
+		ratings, err := h.voteService.GetRatings(voting.ID)
+		if err != nil {
+			return "", err
+		}
+		abstained, err := h.voteService.CountAbstained(voting.ID)
+		if err != nil {
+			return "", err
+		}
+		if len(ratings) == 0 {
+			return fmt.Sprintf("Оценок пока нет, не смотрели: %d\n", abstained), nil
+		}
+		return fmt.Sprintf("Среднее: %.2f, медиана: %.1f, голосов: %d, не смотрели: %d\n", stats.Mean(ratings), stats.Median(ratings), len(ratings), abstained), nil
+	}
+
+	poll, err := h.pollService.GetPollByVotingID(voting.ID)
+	if err != nil {
+		return "", err
+	}
+	options, err := h.pollService.GetPollOptionsByPollID(poll.ID)
+	if err != nil {
+		return "", err
+	}
+	counts := make(map[int64]int64)
+	if voting.Method == model.VOTING_METHOD_RANKED {
+		// Ranked ballots are shown by first preferences, the runoff is counted on close
+		result, err := h.voteService.CalculateInstantRunoff(voting.ID)
+		if err != nil {
+			return "", err
+		}
+		if len(result.Rounds) > 0 {
+			for _, tally := range result.Rounds[0].Tallies {
+				counts[tally.MovieID] = int64(tally.Votes)
+			}
+		}
+	} else {
+		counts, err = h.voteService.CountVotesByMovie(voting.ID)
+		if err != nil {
+			return "", err
+		}
+	}
+	var sb strings.Builder
+	if voting.Method == model.VOTING_METHOD_RANKED {
+		sb.WriteString("Первые предпочтения:\n")
+	}
+	for _, option := range options {
+		sb.WriteString(fmt.Sprintf("• %s — %d\n", option.Movie.Title, counts[option.MovieID]))
+	}
+	return sb.String(), nil
+}
+
+// formatBracket shows how the movies of the bracket final got through their groups.
+func (h *ResultsHandler) formatBracket(bracketID int64) string {
+	entries, err := h.votingService.GetBracketEntries(bracketID)
+	if err != nil {
+		log.Printf("Error getting bracket entries: %v", err)
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("🏟️ Путь в финал:\n")
+	for _, entry := range entries {
+		if entry.Advanced {
+			sb.WriteString(fmt.Sprintf("• %s — группа %d, голосов: %d\n", entry.Movie.Title, entry.Group, entry.Votes))
+		}
+	}
+	return sb.String()
+}
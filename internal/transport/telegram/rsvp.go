@@ -0,0 +1,197 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type RsvpHandler struct {
+	sessionService    service.ISessionService
+	attendanceService service.IAttendanceService
+}
+
+type IRsvpHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+	HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewRsvpHandler(sessionService service.ISessionService, attendanceService service.IAttendanceService) *RsvpHandler {
+	return &RsvpHandler{sessionService: sessionService, attendanceService: attendanceService}
+}
+
+// Handle posts the RSVP message of the chosen session or refreshes the pinned one.
+func (h *RsvpHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	pickSession(ctx, b, update, h.sessionService, "📅 Для какого сеанса собрать ответы?", false, func(ctx context.Context, b *bot.Bot, update *models.Update, session *model.Session) {
+		chatID := updateChatID(update)
+		if sendRsvp(ctx, b, h.sessionService, h.attendanceService, chatID, session) {
+			sendText(ctx, b, chatID, "📌 Ответы собираются в закрепленном сообщении, оно обновлено.")
+		}
+	})
+}
+
+func (h *RsvpHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	data := strings.TrimPrefix(update.CallbackQuery.Data, service.RSVP_PREFIX)
+	parts := strings.SplitN(data, "_", 2)
+	if len(parts) != 2 {
+		h.answer(ctx, b, update, "❓ Неизвестный выбор.")
+		return
+	}
+	sessionID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		h.answer(ctx, b, update, "❓ Неизвестный выбор.")
+		return
+	}
+	status := parts[1]
+	err = h.attendanceService.SetAttendance(sessionID, update.CallbackQuery.From.ID, status)
+	if err != nil {
+		if errors.Is(err, service.ErrSessionNotUpcoming) {
+			h.answer(ctx, b, update, "🔒 Сеанс уже прошел или отменен.")
+			return
+		}
+		log.Printf("Error saving attendance: %v", err)
+		h.answer(ctx, b, update, "❌ Ошибка при сохранении ответа.")
+		return
+	}
+	h.answer(ctx, b, update, "✅ Ответ сохранен.")
+
+	session, err := h.sessionService.FindSessionByID(sessionID)
+	if err != nil {
+		log.Printf("Error finding session: %v", err)
+		return
+	}
+	attendances, err := h.attendanceService.GetAttendances(sessionID)
+	if err != nil {
+		log.Printf("Error getting attendances: %v", err)
+		return
+	}
+	message := update.CallbackQuery.Message.Message
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      message.Chat.ID,
+		MessageID:   message.ID,
+		Text:        formatRsvp(session, attendances),
+		ReplyMarkup: service.RsvpKeyboard(sessionID),
+	})
+	if err != nil {
+		log.Printf("Error updating RSVP message: %v", err)
+	}
+}
+
+func (h *RsvpHandler) answer(ctx context.Context, b *bot.Bot, update *models.Update, text string) {
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		Text:            text,
+	})
+	if err != nil {
+		log.Printf("Error answering callback query: %v", err)
+	}
+}
+
+// sendRsvp refreshes the pinned RSVP message of the session and posts and pins one only when there is none yet,
+// so /rsvp doesn't pile up announcements. It reports whether the existing message was refreshed.
+func sendRsvp(ctx context.Context, b *bot.Bot, sessionService service.ISessionService, attendanceService service.IAttendanceService, chatID int64, session *model.Session) bool {
+	if refreshRsvp(ctx, b, attendanceService, chatID, session) {
+		return true
+	}
+	attendances, err := attendanceService.GetAttendances(session.ID)
+	if err != nil {
+		log.Printf("Error getting attendances: %v", err)
+	}
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        formatRsvp(session, attendances),
+		ReplyMarkup: service.RsvpKeyboard(session.ID),
+	})
+	if err != nil {
+		log.Printf("Error sending RSVP message: %v", err)
+		return false
+	}
+	// The RSVP message is the announcement of the session
+	_, err = b.PinChatMessage(ctx, &bot.PinChatMessageParams{
+		ChatID:              chatID,
+		MessageID:           msg.ID,
+		DisableNotification: true,
+	})
+	if err != nil {
+		log.Printf("Error pinning RSVP message: %v", err)
+	}
+	if err := sessionService.SetRsvpMessageID(session.ID, msg.ID); err != nil {
+		log.Printf("Error saving RSVP message of session %d: %v", session.ID, err)
+	}
+	return false
+}
+
+// refreshRsvp edits the posted RSVP message of the session with its current movies, venue and answers.
+// It returns false when the session has no RSVP message or it is gone.
+func refreshRsvp(ctx context.Context, b *bot.Bot, attendanceService service.IAttendanceService, chatID int64, session *model.Session) bool {
+	if session.RsvpMessageID == 0 {
+		return false
+	}
+	attendances, err := attendanceService.GetAttendances(session.ID)
+	if err != nil {
+		log.Printf("Error getting attendances: %v", err)
+		return false
+	}
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   session.RsvpMessageID,
+		Text:        formatRsvp(session, attendances),
+		ReplyMarkup: service.RsvpKeyboard(session.ID),
+	})
+	if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		log.Printf("Error updating RSVP message of session %d: %v", session.ID, err)
+		return false
+	}
+	return true
+}
+
+func formatRsvp(session *model.Session, attendances []*model.Attendance) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🎟️ Сеанс %s\n", time.Unix(session.FinishedAt, 0).Format("02.01.2006 15:04")))
+	for _, movie := range session.Movies {
+		sb.WriteString(fmt.Sprintf("🎬 %s (%d)\n", movie.Title, movie.Year))
+	}
+	if venue := service.FormatVenue(session.Venue); venue != "" {
+		sb.WriteString(fmt.Sprintf("\n%s\n", venue))
+	}
+	if session.Description != "" {
+		sb.WriteString(fmt.Sprintf("\n%s\n", session.Description))
+	}
+	names := make(map[string][]string, 3)
+	for _, attendance := range attendances {
+		names[attendance.Status] = append(names[attendance.Status], displayName(&attendance.User))
+	}
+	sb.WriteString("\nКто придет?\n")
+	for _, answer := range []struct {
+		status string
+		label  string
+	}{
+		{model.ATTENDANCE_YES_STATUS, "✅ Приду"},
+		{model.ATTENDANCE_MAYBE_STATUS, "🤔 Может быть"},
+		{model.ATTENDANCE_NO_STATUS, "❌ Не приду"},
+	} {
+		sb.WriteString(fmt.Sprintf("%s (%d)", answer.label, len(names[answer.status])))
+		if len(names[answer.status]) > 0 {
+			sb.WriteString(": " + strings.Join(names[answer.status], ", "))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func displayName(user *model.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" && user.Username != "" {
+		name = "@" + user.Username
+	}
+	return name
+}
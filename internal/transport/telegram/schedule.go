@@ -2,13 +2,18 @@ package telegram
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/date"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/datepicker"
 	fsmutils "github.com/Forceres/tg-bot-movieclub-go/internal/utils/fsm"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/keyboard"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/go-telegram/fsm"
@@ -20,13 +25,28 @@ const (
 	stateTime         fsm.StateID = "time"
 	stateLocation     fsm.StateID = "location"
 	stateSaveSchedule fsm.StateID = "save_schedule"
+
+	stateScheduleWeekdays fsm.StateID = "schedule_weekdays"
+	stateScheduleInterval fsm.StateID = "schedule_interval"
+	stateSkipDate         fsm.StateID = "skip_date"
+	stateToggleException  fsm.StateID = "toggle_exception"
 )
 
+// SCHEDULE_SAME_WEEKDAY_TEXT keeps only the weekday of the chosen date in the recurrence.
+const SCHEDULE_SAME_WEEKDAY_TEXT = "-"
+
+// SCHEDULE_PREVIEW_COUNT is how many next occurrences #расписание shows.
+const SCHEDULE_PREVIEW_COUNT = 5
+
+// SCHEDULE_MAX_INTERVAL is the longest interval in weeks offered by /schedule.
+const SCHEDULE_MAX_INTERVAL = 4
+
 type ScheduleHandler struct {
-	f                 *fsm.FSM
-	scheduleService   service.IScheduleService
-	datepicker        *datepicker.Datepicker
-	sessionDatepicker *datepicker.Datepicker
+	f                   *fsm.FSM
+	scheduleService     service.IScheduleService
+	datepicker          *datepicker.Datepicker
+	sessionDatepicker   *datepicker.Datepicker
+	exceptionDatepicker *datepicker.Datepicker
 }
 
 type IScheduleHandler interface {
@@ -35,16 +55,22 @@ type IScheduleHandler interface {
 	PrepareDate(f *fsm.FSM, args ...any)
 	PrepareTime(f *fsm.FSM, args ...any)
 	PrepareLocation(f *fsm.FSM, args ...any)
+	PrepareWeekdays(f *fsm.FSM, args ...any)
+	PrepareInterval(f *fsm.FSM, args ...any)
 	SaveSchedule(f *fsm.FSM, args ...any)
+	HandleSkip(ctx context.Context, b *bot.Bot, update *models.Update)
+	PrepareSkipDate(f *fsm.FSM, args ...any)
+	ToggleException(f *fsm.FSM, args ...any)
 }
 
-func NewScheduleHandler(scheduleService service.IScheduleService, f *fsm.FSM, datepicker *datepicker.Datepicker, sessionDatepicker *datepicker.Datepicker) IScheduleHandler {
-	return &ScheduleHandler{scheduleService: scheduleService, f: f, datepicker: datepicker, sessionDatepicker: sessionDatepicker}
+func NewScheduleHandler(scheduleService service.IScheduleService, f *fsm.FSM, datepicker *datepicker.Datepicker, sessionDatepicker *datepicker.Datepicker, exceptionDatepicker *datepicker.Datepicker) IScheduleHandler {
+	return &ScheduleHandler{scheduleService: scheduleService, f: f, datepicker: datepicker, sessionDatepicker: sessionDatepicker, exceptionDatepicker: exceptionDatepicker}
 }
 
+// Handle shows the recurrence of the active schedule, its next occurrences and the skipped dates.
 func (h *ScheduleHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
 	schedule, err := h.scheduleService.GetActiveSchedule()
-	if err != nil || schedule == nil {
+	if err != nil || schedule == nil || schedule.ID == 0 {
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
 			Text:   "ℹ️ Нет активного расписания.",
@@ -54,32 +80,44 @@ func (h *ScheduleHandler) Handle(ctx context.Context, b *bot.Bot, update *models
 		}
 		return
 	}
-	var weekday int = schedule.Weekday
-	if weekday == 7 {
-		weekday = 0
-	}
-	locale, err := time.LoadLocation(schedule.Location)
-	if err != nil {
-		log.Printf("Error loading location: %v", err)
-		locale = time.FixedZone("UTC+3", 3*60*60)
-	}
-	now := time.Now().In(locale)
-	base := time.Date(now.Year(), now.Month(), now.Day(), schedule.Hour, schedule.Minute, 0, 0, locale)
-	daysUntil := (int(weekday) - int(base.Weekday()) + 7) % 7
-	target := base.AddDate(0, 0, daysUntil)
-	out := monday.Format(target, "Monday, 02-January-06 15:04:05 MST", monday.LocaleRuRU)
-	if err != nil {
-		log.Printf("Error parsing time: %v", err)
-	}
 	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: update.Message.Chat.ID,
-		Text:   "📅 Активное расписание: " + out,
+		Text:   h.formatSchedule(schedule),
 	})
 	if err != nil {
 		log.Printf("Error sending message: %v", err)
 	}
 }
 
+func (h *ScheduleHandler) formatSchedule(schedule *model.Schedule) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📅 Активное расписание: %s в %02d:%02d (%s), %s",
+		date.FormatWeekdays(service.ScheduleWeekdays(schedule)), schedule.Hour, schedule.Minute, schedule.Location, formatInterval(schedule.Interval)))
+	occurrences, err := h.scheduleService.GetOccurrences(time.Now().Unix(), SCHEDULE_PREVIEW_COUNT)
+	if err != nil {
+		log.Printf("Error getting schedule occurrences: %v", err)
+	}
+	if len(occurrences) > 0 {
+		location := service.ScheduleLocation(schedule)
+		sb.WriteString("\n\n🗓️ Ближайшие сеансы:")
+		for _, occurrence := range occurrences {
+			sb.WriteString("\n• " + monday.Format(time.Unix(occurrence, 0).In(location), "Monday, 02 January 2006 15:04", monday.LocaleRuRU))
+		}
+	}
+	exceptions, err := h.scheduleService.GetExceptions()
+	if err != nil {
+		log.Printf("Error getting schedule exceptions: %v", err)
+	}
+	if len(exceptions) > 0 {
+		dates := make([]string, 0, len(exceptions))
+		for _, exception := range exceptions {
+			dates = append(dates, formatExceptionDate(exception.Date))
+		}
+		sb.WriteString("\n\n🚫 Пропуски: " + strings.Join(dates, ", "))
+	}
+	return sb.String()
+}
+
 func (h *ScheduleHandler) HandleReschedule(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID := update.Message.From.ID
 	currentState := h.f.Current(userID)
@@ -107,7 +145,7 @@ func (h *ScheduleHandler) PrepareDate(f *fsm.FSM, args ...any) {
 		title = "Изменение расписания..."
 	}
 	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
+		ChatID: updateChatID(update),
 		Text:   title,
 	})
 	if err != nil {
@@ -123,7 +161,7 @@ func (h *ScheduleHandler) PrepareDate(f *fsm.FSM, args ...any) {
 		datepicker = h.datepicker
 	}
 	msg, err = b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID:      update.Message.Chat.ID,
+		ChatID:      updateChatID(update),
 		Text:        "Выбери дату",
 		ReplyMarkup: datepicker.Datepicker,
 	})
@@ -177,6 +215,73 @@ func (h *ScheduleHandler) PrepareLocation(f *fsm.FSM, args ...any) {
 	fsmutils.AppendMessageID(f, userID, msg.ID)
 }
 
+// PrepareWeekdays asks for the weekdays of the recurrence, the chosen date gives the default one.
+func (h *ScheduleHandler) PrepareWeekdays(f *fsm.FSM, args ...any) {
+	userID := args[0].(int64)
+	currentState := f.Current(userID)
+	if currentState == stateDefault {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	update := args[3].(*models.Update)
+	selected, _ := f.Get(userID, "date")
+	weekday := int(selected.(time.Time).Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("📆 Введите дни недели через запятую (например, пн, ср, пт) или «%s», чтобы встречаться только в %s", SCHEDULE_SAME_WEEKDAY_TEXT, date.FormatWeekdays([]int{weekday})),
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+		f.Reset(userID)
+		return
+	}
+	fsmutils.AppendMessageID(f, userID, msg.ID)
+}
+
+// PrepareInterval asks how many weeks pass between the meetings.
+func (h *ScheduleHandler) PrepareInterval(f *fsm.FSM, args ...any) {
+	userID := args[0].(int64)
+	currentState := f.Current(userID)
+	if currentState == stateDefault {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	update := args[3].(*models.Update)
+	kb := keyboard.New(b)
+	for interval := 1; interval <= SCHEDULE_MAX_INTERVAL; interval++ {
+		kb.Row().Button(formatInterval(interval), []byte(strconv.Itoa(interval)), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+			if update.CallbackQuery.From.ID != userID || f.Current(userID) != stateScheduleInterval {
+				return
+			}
+			f.Set(userID, "interval", interval)
+			f.Transition(userID, stateSaveSchedule, userID, ctx, b, update)
+		})
+	}
+	kb.Row().Button("Отменить", []byte("cancel"), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+		if update.CallbackQuery.From.ID != userID {
+			return
+		}
+		fsmutils.DeleteMessages(ctx, b, f, userID, updateChatID(update))
+		f.Reset(userID)
+	})
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        "🔁 Как часто встречаемся?",
+		ReplyMarkup: kb,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+		f.Reset(userID)
+		return
+	}
+	fsmutils.AppendMessageID(f, userID, msg.ID)
+}
+
 func (h *ScheduleHandler) SaveSchedule(f *fsm.FSM, args ...any) {
 	userID := args[0].(int64)
 	currentState := f.Current(userID)
@@ -186,21 +291,39 @@ func (h *ScheduleHandler) SaveSchedule(f *fsm.FSM, args ...any) {
 	ctx := args[1].(context.Context)
 	b := args[2].(*bot.Bot)
 	update := args[3].(*models.Update)
+	chatID := updateChatID(update)
 	hour, _ := f.Get(userID, "hour")
 	minute, _ := f.Get(userID, "minute")
 	location, _ := f.Get(userID, "location")
-	date, _ := f.Get(userID, "date")
+	selected, _ := f.Get(userID, "date")
+	weekdays, _ := f.Get(userID, "weekdays")
+	interval, _ := f.Get(userID, "interval")
+	days := weekdays.([]int)
+	parts := make([]string, 0, len(days))
+	for _, day := range days {
+		parts = append(parts, strconv.Itoa(day))
+	}
+	// The chosen date is the first occurrence, the weeks of the interval count from it
+	locale, err := time.LoadLocation(location.(string))
+	if err != nil {
+		locale = time.UTC
+	}
+	day := selected.(time.Time)
+	startsAt := time.Date(day.Year(), day.Month(), day.Day(), hour.(int), minute.(int), 0, 0, locale)
 	schedule := &model.Schedule{
-		Weekday:  int(date.(time.Time).Weekday()),
+		Weekday:  days[0],
+		Weekdays: strings.Join(parts, ","),
+		Interval: interval.(int),
+		StartsAt: startsAt.Unix(),
 		Hour:     hour.(int),
 		Minute:   minute.(int),
 		IsActive: true,
 		Location: location.(string),
 	}
-	_, err := h.scheduleService.ReplaceSchedule(schedule)
+	schedule, err = h.scheduleService.ReplaceSchedule(schedule)
 	if err != nil {
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
+			ChatID: chatID,
 			Text:   "❌ Ошибка при сохранении расписания.",
 		})
 		if err != nil {
@@ -210,12 +333,105 @@ func (h *ScheduleHandler) SaveSchedule(f *fsm.FSM, args ...any) {
 		return
 	}
 	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
-		Text:   "✅ Расписание успешно обновлено.",
+		ChatID: chatID,
+		Text:   "✅ Расписание успешно обновлено.\n\n" + h.formatSchedule(schedule),
 	})
 	if err != nil {
 		log.Printf("Error sending message: %v", err)
 	}
-	fsmutils.DeleteMessages(ctx, b, f, userID, update.Message.Chat.ID)
+	fsmutils.DeleteMessages(ctx, b, f, userID, chatID)
 	f.Reset(userID)
 }
+
+// HandleSkip lets the admin pick a date to be skipped, picking a skipped date brings it back.
+func (h *ScheduleHandler) HandleSkip(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID := update.Message.From.ID
+	if h.f.Current(userID) != stateDefault {
+		return
+	}
+	h.f.Transition(userID, stateSkipDate, userID, ctx, b, update)
+}
+
+func (h *ScheduleHandler) PrepareSkipDate(f *fsm.FSM, args ...any) {
+	userID := args[0].(int64)
+	currentState := f.Current(userID)
+	if currentState == stateDefault {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	update := args[3].(*models.Update)
+	text := "🚫 Выбери дату, в которую сеанса не будет. Выбор уже пропущенной даты вернет ее в расписание."
+	exceptions, err := h.scheduleService.GetExceptions()
+	if err != nil {
+		log.Printf("Error getting schedule exceptions: %v", err)
+	}
+	if len(exceptions) > 0 {
+		dates := make([]string, 0, len(exceptions))
+		for _, exception := range exceptions {
+			dates = append(dates, formatExceptionDate(exception.Date))
+		}
+		text += "\n\n💡 Сейчас пропускаются: " + strings.Join(dates, ", ")
+	}
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        text,
+		ReplyMarkup: h.exceptionDatepicker.Datepicker,
+	})
+	if err != nil {
+		log.Printf("Error sending datepicker: %v", err)
+		f.Reset(userID)
+		return
+	}
+	fsmutils.AppendMessageID(f, userID, msg.ID)
+}
+
+func (h *ScheduleHandler) ToggleException(f *fsm.FSM, args ...any) {
+	userID := args[0].(int64)
+	currentState := f.Current(userID)
+	if currentState == stateDefault {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	callbackQuery := args[3].(*models.CallbackQuery)
+	chatID := callbackQuery.Message.Message.Chat.ID
+	selected, _ := f.Get(userID, "date")
+	day := selected.(time.Time)
+	skipped, err := h.scheduleService.ToggleException(day)
+	if err != nil {
+		log.Printf("Error toggling schedule exception: %v", err)
+		sendText(ctx, b, chatID, "❌ Ошибка при изменении пропусков расписания.")
+		f.Reset(userID)
+		return
+	}
+	text := fmt.Sprintf("✅ %s снова в расписании.", day.Format("02.01.2006"))
+	if skipped {
+		text = fmt.Sprintf("🚫 %s сеанса не будет.", day.Format("02.01.2006"))
+	}
+	next, err := h.scheduleService.GetNextScheduledTime()
+	if err == nil && next != 0 {
+		schedule, err := h.scheduleService.GetActiveSchedule()
+		if err == nil {
+			text += "\n📅 Следующий сеанс по расписанию: " + monday.Format(time.Unix(next, 0).In(service.ScheduleLocation(schedule)), "Monday, 02 January 2006 15:04", monday.LocaleRuRU)
+		}
+	}
+	sendText(ctx, b, chatID, text)
+	fsmutils.DeleteMessages(ctx, b, f, userID, chatID)
+	f.Reset(userID)
+}
+
+func formatInterval(interval int) string {
+	if interval <= 1 {
+		return "каждую неделю"
+	}
+	return fmt.Sprintf("раз в %d недели", interval)
+}
+
+func formatExceptionDate(day string) string {
+	parsed, err := time.Parse(service.SCHEDULE_EXCEPTION_FORMAT, day)
+	if err != nil {
+		return day
+	}
+	return parsed.Format("02.01.2006")
+}
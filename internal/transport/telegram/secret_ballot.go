@@ -0,0 +1,260 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/tasks"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type SecretBallotHandler struct {
+	pollService   service.IPollService
+	voteService   service.IVoteService
+	votingService service.IVotingService
+}
+
+type ISecretBallotHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+	HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewSecretBallotHandler(pollService service.IPollService, voteService service.IVoteService, votingService service.IVotingService) *SecretBallotHandler {
+	return &SecretBallotHandler{pollService: pollService, voteService: voteService, votingService: votingService}
+}
+
+// Handle opens the ballot in a private chat, the member gets here by the deep link
+// from the group announcement.
+func (h *SecretBallotHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	userID := update.Message.From.ID
+	if update.Message.Chat.Type != models.ChatTypePrivate {
+		return
+	}
+	payload := strings.TrimSpace(strings.TrimPrefix(update.Message.Text, "/start"))
+	votingID, err := strconv.ParseInt(strings.TrimPrefix(payload, service.SECRET_BALLOT_START_PREFIX), 10, 64)
+	if err != nil {
+		h.send(ctx, b, chatID, "❓ Неизвестное голосование.")
+		return
+	}
+	voting, options, ok := h.findBallot(votingID)
+	if !ok {
+		h.send(ctx, b, chatID, "🔒 Голосование уже завершено.")
+		return
+	}
+	var markup *models.InlineKeyboardMarkup
+	text := fmt.Sprintf("🔒 %s\n\nВаш выбор увидит только бот, после завершения будут опубликованы общие итоги.", voting.Title)
+	if voting.Type == model.VOTING_SELECTION_TYPE && voting.Method == model.VOTING_METHOD_RANKED {
+		pollOpts := make([]models.InputPollOption, 0, len(options))
+		for _, option := range options {
+			pollOpts = append(pollOpts, models.InputPollOption{Text: option.Movie.Title})
+		}
+		text += "\nНажимайте на фильмы в порядке предпочтения, начиная с самого желанного."
+		markup = service.RankedBallotKeyboard(voting.ID, pollOpts)
+	} else {
+		votes, err := h.voteService.GetUserVotes(voting.ID, userID)
+		if err != nil {
+			log.Printf("Error getting user votes: %v", err)
+			h.send(ctx, b, chatID, "❌ Ошибка при открытии бюллетеня.")
+			return
+		}
+		if voting.Type == model.VOTING_SELECTION_TYPE {
+			text += "\nМожно выбрать несколько фильмов, повторное нажатие снимает выбор."
+		}
+		markup = secretBallotKeyboard(voting, options, votes)
+	}
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		log.Printf("Error sending secret ballot: %v", err)
+	}
+}
+
+func (h *SecretBallotHandler) HandleCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID := update.CallbackQuery.From.ID
+	data := strings.TrimPrefix(update.CallbackQuery.Data, service.SECRET_BALLOT_PREFIX)
+	parts := strings.SplitN(data, "_", 2)
+	if len(parts) != 2 {
+		h.answer(ctx, b, update, "❓ Неизвестный выбор.")
+		return
+	}
+	votingID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		h.answer(ctx, b, update, "❓ Неизвестный выбор.")
+		return
+	}
+	optionIndex, err := strconv.Atoi(parts[1])
+	if err != nil {
+		h.answer(ctx, b, update, "❓ Неизвестный выбор.")
+		return
+	}
+	voting, options, ok := h.findBallot(votingID)
+	if !ok {
+		h.answer(ctx, b, update, "🔒 Голосование уже завершено.")
+		return
+	}
+	votes, err := h.voteService.GetUserVotes(votingID, userID)
+	if err != nil {
+		log.Printf("Error getting user votes: %v", err)
+		h.answer(ctx, b, update, "❌ Ошибка при сохранении голоса.")
+		return
+	}
+
+	var answer string
+	if voting.Type == model.VOTING_RATING_TYPE {
+		if optionIndex < 0 || optionIndex >= len(tasks.RATING_VOTING_OPTIONS) {
+			h.answer(ctx, b, update, "❓ Неизвестный выбор.")
+			return
+		}
+		vote := &model.Vote{VotingID: votingID, UserID: userID, MovieID: voting.MovieID}
+		if optionIndex == tasks.RATING_ABSTAIN_OPTION_ID {
+			vote.Abstained = true
+		} else {
+			rating := optionIndex + 1
+			vote.Rating = &rating
+		}
+		votes = []*model.Vote{vote}
+		answer = fmt.Sprintf("✅ Ваш ответ: %s", tasks.RATING_VOTING_OPTIONS[optionIndex].Text)
+	} else {
+		if optionIndex < 0 || optionIndex >= len(options) {
+			h.answer(ctx, b, update, "❓ Неизвестный выбор.")
+			return
+		}
+		votes = toggleVote(votes, votingID, userID, options[optionIndex].MovieID)
+		answer = fmt.Sprintf("✅ Выбрано фильмов: %d", len(votes))
+	}
+	// Votes are replaced as a whole, like a re-vote in a regular poll
+	if err := h.voteService.CreateMultiple(votingID, userID, votes); err != nil {
+		log.Printf("Error saving secret votes: %v", err)
+		h.answer(ctx, b, update, "❌ Ошибка при сохранении голоса.")
+		return
+	}
+	if update.CallbackQuery.Message.Message != nil {
+		_, err = b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+			ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
+			MessageID:   update.CallbackQuery.Message.Message.ID,
+			ReplyMarkup: secretBallotKeyboard(voting, options, votes),
+		})
+		if err != nil {
+			log.Printf("Error updating secret ballot: %v", err)
+		}
+	}
+	h.answer(ctx, b, update, answer)
+}
+
+func (h *SecretBallotHandler) findBallot(votingID int64) (*model.Voting, []*model.PollOption, bool) {
+	voting, err := h.votingService.GetVotingByID(votingID)
+	if err != nil || !voting.Secret || voting.Status != model.VOTING_ACTIVE_STATUS {
+		return nil, nil, false
+	}
+	if voting.Type == model.VOTING_RATING_TYPE {
+		return voting, nil, true
+	}
+	poll, err := h.pollService.GetPollByVotingID(votingID)
+	if err != nil {
+		return nil, nil, false
+	}
+	options, err := h.pollService.GetPollOptionsByPollID(poll.ID)
+	if err != nil {
+		log.Printf("Error getting poll options: %v", err)
+		return nil, nil, false
+	}
+	return voting, options, true
+}
+
+func (h *SecretBallotHandler) send(ctx context.Context, b *bot.Bot, chatID int64, text string) {
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+func (h *SecretBallotHandler) answer(ctx context.Context, b *bot.Bot, update *models.Update, text string) {
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		Text:            text,
+	})
+	if err != nil {
+		log.Printf("Error answering callback query: %v", err)
+	}
+}
+
+func toggleVote(votes []*model.Vote, votingID int64, userID int64, movieID int64) []*model.Vote {
+	result := make([]*model.Vote, 0, len(votes)+1)
+	removed := false
+	for _, vote := range votes {
+		if vote.MovieID != nil && *vote.MovieID == movieID {
+			removed = true
+			continue
+		}
+		result = append(result, &model.Vote{VotingID: votingID, UserID: userID, MovieID: vote.MovieID})
+	}
+	if !removed {
+		result = append(result, &model.Vote{VotingID: votingID, UserID: userID, MovieID: &movieID})
+	}
+	return result
+}
+
+// secretBallotKeyboard marks the member's current choice, the ballot is visible only in the private chat.
+func secretBallotKeyboard(voting *model.Voting, options []*model.PollOption, votes []*model.Vote) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	if voting.Type == model.VOTING_RATING_TYPE {
+		selected := -1
+		if len(votes) > 0 {
+			if votes[0].Abstained {
+				selected = tasks.RATING_ABSTAIN_OPTION_ID
+			} else if votes[0].Rating != nil {
+				selected = *votes[0].Rating - 1
+			}
+		}
+		// Five ratings per row, "didn't watch" gets a row of its own
+		var row []models.InlineKeyboardButton
+		for i, option := range tasks.RATING_VOTING_OPTIONS {
+			text := option.Text
+			if i == selected {
+				text = "✅ " + text
+			}
+			row = append(row, models.InlineKeyboardButton{
+				Text:         text,
+				CallbackData: fmt.Sprintf("%s%d_%d", service.SECRET_BALLOT_PREFIX, voting.ID, i),
+			})
+			if len(row) == 5 {
+				rows = append(rows, row)
+				row = nil
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+		return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+	}
+	selected := make(map[int64]bool, len(votes))
+	for _, vote := range votes {
+		if vote.MovieID != nil {
+			selected[*vote.MovieID] = true
+		}
+	}
+	for i, option := range options {
+		text := option.Movie.Title
+		if selected[option.MovieID] {
+			text = "✅ " + text
+		}
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         text,
+			CallbackData: fmt.Sprintf("%s%d_%d", service.SECRET_BALLOT_PREFIX, voting.ID, i),
+		}})
+	}
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
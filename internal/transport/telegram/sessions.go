@@ -0,0 +1,151 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/keyboard"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// NEW_SESSION_DATA is the picker button planning a new session at the next free time of the schedule.
+const NEW_SESSION_DATA = "new"
+
+// OnSessionSelect receives the chosen session, nil means a new session.
+type OnSessionSelect func(ctx context.Context, b *bot.Bot, update *models.Update, session *model.Session)
+
+type SessionsHandler struct {
+	sessionService service.ISessionService
+}
+
+type ISessionsHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewSessionsHandler(sessionService service.ISessionService) *SessionsHandler {
+	return &SessionsHandler{sessionService: sessionService}
+}
+
+func (h *SessionsHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	sessions, err := h.sessionService.FindUpcomingSessions()
+	if err != nil || len(sessions) == 0 {
+		sendText(ctx, b, update.Message.Chat.ID, "📭 Запланированных сеансов нет.")
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("📅 Запланированные сеансы:\n")
+	for _, session := range sessions {
+		sb.WriteString(fmt.Sprintf("\n🗓️ %s", time.Unix(session.FinishedAt, 0).Format("02.01.2006 15:04")))
+		if session.Description != "" {
+			sb.WriteString(fmt.Sprintf(" — %s", session.Description))
+		}
+		sb.WriteString("\n")
+		if len(session.Movies) == 0 {
+			sb.WriteString("• фильмы еще не выбраны\n")
+			continue
+		}
+		for _, movie := range session.Movies {
+			sb.WriteString(fmt.Sprintf("• %s (%d)\n", movie.Title, movie.Year))
+		}
+	}
+	sendText(ctx, b, update.Message.Chat.ID, sb.String())
+}
+
+// sessionsKeyboard shows a button per upcoming session, only the admin who called the command can choose.
+func sessionsKeyboard(b *bot.Bot, adminID int64, sessions []*model.Session, allowNew bool, onSelect OnSessionSelect) *keyboard.Keyboard {
+	kb := sessionButtons(b, adminID, sessions, allowNew, onSelect)
+	return kb.Row().Button("Отменить", []byte("cancel"), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+		if update.CallbackQuery.From.ID == adminID {
+			kb.Close(ctx, b, update)
+		}
+	})
+}
+
+// sessionButtons is the session picker without the cancel button, for flows that reset their state on cancel.
+// The clicks of the others are ignored and leave the keyboard in place, the buttons added by the flows
+// close it themselves.
+func sessionButtons(b *bot.Bot, adminID int64, sessions []*model.Session, allowNew bool, onSelect OnSessionSelect) *keyboard.Keyboard {
+	byID := make(map[int64]*model.Session, len(sessions))
+	kb := keyboard.New(b, keyboard.NoDeleteAfterClick())
+	onClick := func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+		if update.CallbackQuery.From.ID != adminID {
+			return
+		}
+		kb.Close(ctx, b, update)
+		if string(data) == NEW_SESSION_DATA {
+			onSelect(ctx, b, update, nil)
+			return
+		}
+		sessionID, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return
+		}
+		onSelect(ctx, b, update, byID[sessionID])
+	}
+	for _, session := range sessions {
+		byID[session.ID] = session
+		kb.Row().Button(sessionLabel(session), []byte(strconv.FormatInt(session.ID, 10)), onClick)
+	}
+	if allowNew {
+		kb.Row().Button("➕ Новый сеанс", []byte(NEW_SESSION_DATA), onClick)
+	}
+	return kb
+}
+
+func sessionLabel(session *model.Session) string {
+	label := fmt.Sprintf("🗓️ %s", time.Unix(session.FinishedAt, 0).Format("02.01.2006 15:04"))
+	if len(session.Movies) > 0 {
+		titles := make([]string, 0, len(session.Movies))
+		for _, movie := range session.Movies {
+			titles = append(titles, movie.Title)
+		}
+		label += ": " + strings.Join(titles, ", ")
+	}
+	// Telegram cuts long button texts anyway
+	if runes := []rune(label); len(runes) > 60 {
+		label = string(runes[:57]) + "..."
+	}
+	return label
+}
+
+// pickSession asks the admin to choose an upcoming session. When there are no sessions,
+// a new one is chosen right away if allowed.
+func pickSession(ctx context.Context, b *bot.Bot, update *models.Update, sessionService service.ISessionService, text string, allowNew bool, onSelect OnSessionSelect) {
+	sessions, err := sessionService.FindUpcomingSessions()
+	if err != nil {
+		log.Printf("Error finding upcoming sessions: %v", err)
+		sendText(ctx, b, update.Message.Chat.ID, "❌ Ошибка при получении сеансов.")
+		return
+	}
+	if len(sessions) == 0 {
+		if allowNew {
+			onSelect(ctx, b, update, nil)
+			return
+		}
+		sendText(ctx, b, update.Message.Chat.ID, "📭 Запланированных сеансов нет.")
+		return
+	}
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        text,
+		ReplyMarkup: sessionsKeyboard(b, update.Message.From.ID, sessions, allowNew, onSelect),
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+// updateChatID returns the chat of the message or of the pressed button.
+func updateChatID(update *models.Update) int64 {
+	if update.CallbackQuery != nil {
+		return update.CallbackQuery.Message.Message.Chat.ID
+	}
+	return update.Message.Chat.ID
+}
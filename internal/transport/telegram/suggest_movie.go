@@ -2,26 +2,40 @@ package telegram
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"strconv"
+	"strings"
 
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/kinopoisk"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/keyboard"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/media"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 )
 
+// SUGGEST_HASHTAG starts the suggestion messages.
+const SUGGEST_HASHTAG = "#предлагаю"
+
+// SUGGEST_SEARCH_LIMIT is how many matches of a title are offered.
+const SUGGEST_SEARCH_LIMIT = 5
+
+const SUGGEST_TITLE_MAX_LENGTH = 100
+
 type SuggestMovieHandler struct {
-	movieService     service.IMovieService
-	kinopoiskService service.IKinopoiskService
+	movieService      service.IMovieService
+	kinopoiskService  service.IKinopoiskService
+	suggestionService service.ISuggestionService
 }
 
 type ISuggestMovieHandler interface {
 	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
 }
 
-func NewSuggestMovieHandler(movieService service.IMovieService, kinopoiskService service.IKinopoiskService) *SuggestMovieHandler {
-	return &SuggestMovieHandler{movieService: movieService, kinopoiskService: kinopoiskService}
+func NewSuggestMovieHandler(movieService service.IMovieService, kinopoiskService service.IKinopoiskService, suggestionService service.ISuggestionService) *SuggestMovieHandler {
+	return &SuggestMovieHandler{movieService: movieService, kinopoiskService: kinopoiskService, suggestionService: suggestionService}
 }
 
 func (h *SuggestMovieHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -30,6 +44,10 @@ func (h *SuggestMovieHandler) Handle(ctx context.Context, b *bot.Bot, update *mo
 	}
 	ids := kinopoisk.ParseIDsOrRefs(update.Message.Text)
 	if len(ids) == 0 {
+		if title := suggestionTitle(update.Message.Text); title != "" {
+			h.searchByTitle(ctx, b, update, title)
+			return
+		}
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
 			Text:   "🔍 Не найдено ссылок на фильмы Кинопоиска в сообщении.",
@@ -45,8 +63,9 @@ func (h *SuggestMovieHandler) Handle(ctx context.Context, b *bot.Bot, update *mo
 		if err != nil {
 			continue
 		}
-		_, err = h.movieService.GetMovieByID(intId)
-		if err != nil {
+		movie, err := h.movieService.GetMovieByID(intId)
+		// Withdrawn suggestions can be suggested again
+		if err != nil || movie.Status == model.MOVIE_ARCHIVED_STATUS {
 			idsToFind = append(idsToFind, intId)
 		}
 	}
@@ -70,10 +89,18 @@ func (h *SuggestMovieHandler) Handle(ctx context.Context, b *bot.Bot, update *mo
 		}
 		return
 	}
-	moviesDto, err := h.kinopoiskService.SearchMovies(idsToFind, update.Message.From.ID)
+	h.suggest(ctx, b, update.Message.Chat.ID, update.Message.From.ID, idsToFind)
+}
+
+// suggest checks the quotas of the member before Kinopoisk is queried and adds the found movies to the suggestions.
+func (h *SuggestMovieHandler) suggest(ctx context.Context, b *bot.Bot, chatID int64, userID int64, idsToFind []int64) {
+	if !h.checkQuota(ctx, b, chatID, userID, len(idsToFind)) {
+		return
+	}
+	moviesDto, err := h.kinopoiskService.SearchMovies(idsToFind, userID)
 	if err != nil {
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
+			ChatID: chatID,
 			Text:   "❌ Ошибка при поиске фильмов на Кинопоиске.",
 		})
 		if err != nil {
@@ -83,7 +110,7 @@ func (h *SuggestMovieHandler) Handle(ctx context.Context, b *bot.Bot, update *mo
 	}
 	if len(moviesDto) == 0 {
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
+			ChatID: chatID,
 			Text:   "🔍 Не удалось найти фильмы по предоставленным ссылкам.",
 		})
 		if err != nil {
@@ -91,18 +118,129 @@ func (h *SuggestMovieHandler) Handle(ctx context.Context, b *bot.Bot, update *mo
 		}
 		return
 	}
+	var added []int64
 	for _, movieDto := range moviesDto {
-		err := h.movieService.Upsert(&movieDto, update.Message.From.ID)
+		err := h.movieService.Upsert(&movieDto, userID)
 		if err != nil {
 			log.Printf("Error while creating movie: %v", err)
 			continue
 		}
+		added = append(added, movieDto.KinopoiskID)
+	}
+	cards, err := h.movieService.GetMovieCards(added)
+	if err != nil {
+		log.Printf("Error getting movie cards: %v", err)
+	}
+	if err := media.Send(ctx, b, chatID, "✅ Фильм(ы) успешно добавлен(ы) в предложку!", cards); err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+func (h *SuggestMovieHandler) checkQuota(ctx context.Context, b *bot.Bot, chatID int64, userID int64, requested int) bool {
+	quota, err := h.suggestionService.GetQuota(userID)
+	if err != nil {
+		log.Printf("Error getting suggestion quota: %v", err)
+		sendText(ctx, b, chatID, "❌ Ошибка при проверке лимита предложений.")
+		return false
+	}
+	if remaining := quota.Remaining(); remaining != -1 && remaining < requested {
+		sendText(ctx, b, chatID, formatSuggestionQuota(quota, requested))
+		return false
+	}
+	return true
+}
+
+// searchByTitle shows the best Kinopoisk matches of the title, the member picks the one to suggest.
+func (h *SuggestMovieHandler) searchByTitle(ctx context.Context, b *bot.Bot, update *models.Update, title string) {
+	chatID := update.Message.Chat.ID
+	userID := update.Message.From.ID
+	if !h.checkQuota(ctx, b, chatID, userID, 1) {
+		return
 	}
+	matches, err := h.kinopoiskService.SearchByTitle(title, SUGGEST_SEARCH_LIMIT)
+	if err != nil {
+		log.Printf("Error searching movies by title: %v", err)
+		sendText(ctx, b, chatID, "❌ Ошибка при поиске фильмов на Кинопоиске.")
+		return
+	}
+	if len(matches) == 0 {
+		sendText(ctx, b, chatID, fmt.Sprintf("🔍 По запросу «%s» ничего не найдено.", title))
+		return
+	}
+	kb := keyboard.New(b, keyboard.NoDeleteAfterClick())
+	for _, match := range matches {
+		kb.Row().Button(matchLabel(match), []byte(strconv.FormatInt(match.KinopoiskID, 10)), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+			if update.CallbackQuery.From.ID != userID {
+				return
+			}
+			kb.Close(ctx, b, update)
+			movieID, err := strconv.ParseInt(string(data), 10, 64)
+			if err != nil {
+				return
+			}
+			chatID := updateChatID(update)
+			movie, err := h.movieService.GetMovieByID(movieID)
+			if err == nil && movie.Status != model.MOVIE_ARCHIVED_STATUS {
+				sendText(ctx, b, chatID, "ℹ️ Этот фильм уже предложен ранее.")
+				return
+			}
+			h.suggest(ctx, b, chatID, userID, []int64{movieID})
+		})
+	}
+	kb.Row().Button("Отменить", []byte("cancel"), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+		if update.CallbackQuery.From.ID == userID {
+			kb.Close(ctx, b, update)
+		}
+	})
 	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
-		Text:   "✅ Фильм(ы) успешно добавлен(ы) в предложку!",
+		ChatID:      chatID,
+		Text:        fmt.Sprintf("🔍 Найдено по запросу «%s». Какой фильм предложить?", title),
+		ReplyMarkup: kb,
 	})
 	if err != nil {
 		log.Printf("Error sending message: %v", err)
 	}
 }
+
+// suggestionTitle returns the text after the hashtag, it is searched on Kinopoisk when there are no links.
+func suggestionTitle(text string) string {
+	title := strings.TrimSpace(strings.TrimPrefix(text, SUGGEST_HASHTAG))
+	if runes := []rune(title); len(runes) > SUGGEST_TITLE_MAX_LENGTH {
+		title = string(runes[:SUGGEST_TITLE_MAX_LENGTH])
+	}
+	return title
+}
+
+func matchLabel(match service.MovieMatch) string {
+	label := "🎬 " + match.Title
+	if match.Year != "" && match.Year != "null" {
+		label += fmt.Sprintf(" (%s)", match.Year)
+	}
+	if len(match.Directors) > 0 {
+		label += " — " + strings.Join(match.Directors, ", ")
+	}
+	if runes := []rune(label); len(runes) > 60 {
+		label = string(runes[:57]) + "..."
+	}
+	return label
+}
+
+func formatSuggestionQuota(quota *service.SuggestionQuota, requested int) string {
+	var sb strings.Builder
+	remaining := quota.Remaining()
+	if remaining == 0 {
+		sb.WriteString("⛔ Лимит предложений исчерпан.")
+	} else {
+		sb.WriteString(fmt.Sprintf("⛔ Можно предложить еще %d из %d фильмов.", remaining, requested))
+	}
+	if quota.MaxOpen > 0 {
+		sb.WriteString(fmt.Sprintf("\n📋 Ждут в предложке: %d/%d", quota.Open, quota.MaxOpen+quota.Extra))
+	}
+	if quota.MaxPerWeek > 0 {
+		sb.WriteString(fmt.Sprintf("\n📅 Предложено за неделю: %d/%d", quota.Week, quota.MaxPerWeek+quota.Extra))
+	}
+	if quota.Extra > 0 {
+		sb.WriteString(fmt.Sprintf("\n🎁 Включая дополнительные слоты: %d", quota.Extra))
+	}
+	return sb.String()
+}
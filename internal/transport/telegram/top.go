@@ -0,0 +1,46 @@
+package telegram
+
+import (
+	"context"
+	"log"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const TOP_MOVIES_LIMIT = 10
+
+type TopHandler struct {
+	movieService service.IMovieService
+}
+
+type ITopHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewTopHandler(movieService service.IMovieService) *TopHandler {
+	return &TopHandler{movieService: movieService}
+}
+
+func (h *TopHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	movies, err := h.movieService.GetTopRatedMovies(TOP_MOVIES_LIMIT)
+	if err != nil {
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "🏆 Мы еще не оценили ни одного фильма!",
+		})
+		if err != nil {
+			log.Printf("Error sending the message: %v", err)
+		}
+		return
+	}
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      "🏆 Лучшие фильмы клуба:\n" + *movies,
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		log.Printf("Error sending the message: %v", err)
+	}
+}
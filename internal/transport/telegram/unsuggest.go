@@ -0,0 +1,146 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/keyboard"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// UNSUGGEST_MAX_BUTTONS caps the list, Telegram rejects keyboards with too many buttons.
+const UNSUGGEST_MAX_BUTTONS = 50
+
+type UnsuggestHandler struct {
+	movieService service.IMovieService
+	userService  service.IUserService
+}
+
+type IUnsuggestHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+}
+
+func NewUnsuggestHandler(movieService service.IMovieService, userService service.IUserService) *UnsuggestHandler {
+	return &UnsuggestHandler{movieService: movieService, userService: userService}
+}
+
+// Handle lists the suggestions of the member to withdraw one, admins get the suggestions of every member.
+func (h *UnsuggestHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	user, err := h.userService.FindByID(update.Message.From.ID)
+	if err != nil {
+		sendText(ctx, b, chatID, "❌ Сначала зарегистрируйся с помощью /register.")
+		return
+	}
+	isAdmin := user.Role.Name == model.ROLE_ADMIN
+	suggestedBy := user.ID
+	if isAdmin {
+		suggestedBy = 0
+	}
+	movies, err := h.movieService.GetSuggestions(suggestedBy)
+	if err != nil {
+		log.Printf("Error getting suggestions: %v", err)
+		sendText(ctx, b, chatID, "❌ Ошибка при получении предложенных фильмов.")
+		return
+	}
+	if len(movies) == 0 {
+		if isAdmin {
+			sendText(ctx, b, chatID, "📭 Увы фильмов в предложке нет.")
+			return
+		}
+		sendText(ctx, b, chatID, "📭 Нет твоих фильмов в предложке.")
+		return
+	}
+	text := "🗄️ Какой фильм убрать из предложки?"
+	if len(movies) > UNSUGGEST_MAX_BUTTONS {
+		movies = movies[:UNSUGGEST_MAX_BUTTONS]
+		text += fmt.Sprintf("\n\nℹ️ Показаны %d последних предложений.", UNSUGGEST_MAX_BUTTONS)
+	}
+	byID := make(map[int64]*model.Movie, len(movies))
+	kb := keyboard.New(b, keyboard.NoDeleteAfterClick())
+	onClick := func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+		if update.CallbackQuery.From.ID != user.ID {
+			return
+		}
+		kb.Close(ctx, b, update)
+		movieID, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return
+		}
+		h.archive(ctx, b, updateChatID(update), user, byID[movieID])
+	}
+	for _, movie := range movies {
+		byID[movie.ID] = movie
+		label := fmt.Sprintf("🎬 %s (%d)", movie.Title, movie.Year)
+		if isAdmin && movie.Suggester != nil {
+			label += " — " + displayName(movie.Suggester)
+		}
+		if runes := []rune(label); len(runes) > 60 {
+			label = string(runes[:57]) + "..."
+		}
+		kb.Row().Button(label, []byte(strconv.FormatInt(movie.ID, 10)), onClick)
+	}
+	kb.Row().Button("Отменить", []byte("cancel"), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+		if update.CallbackQuery.From.ID == user.ID {
+			kb.Close(ctx, b, update)
+		}
+	})
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: kb,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+// archive withdraws the suggestion and lets the suggester know when an admin did it.
+func (h *UnsuggestHandler) archive(ctx context.Context, b *bot.Bot, chatID int64, user *model.User, movie *model.Movie) {
+	if movie == nil {
+		return
+	}
+	_, err := h.movieService.ArchiveSuggestion(movie.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMovieInActiveVoting):
+			sendText(ctx, b, chatID, fmt.Sprintf("⚠️ «%s» участвует в активном голосовании, его нельзя убрать из предложки.", movie.Title))
+		case errors.Is(err, service.ErrMovieNotSuggested):
+			sendText(ctx, b, chatID, fmt.Sprintf("ℹ️ «%s» уже не в предложке.", movie.Title))
+		default:
+			log.Printf("Error archiving movie %d: %v", movie.ID, err)
+			sendText(ctx, b, chatID, "❌ Ошибка при удалении фильма из предложки.")
+		}
+		return
+	}
+	text := fmt.Sprintf("🗄️ «%s» убран из предложки.", html.EscapeString(movie.Title))
+	if movie.SuggestedBy != nil && *movie.SuggestedBy != user.ID {
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: *movie.SuggestedBy,
+			Text:   fmt.Sprintf("🗄️ %s убрал(а) твое предложение «%s» из предложки.", displayName(user), movie.Title),
+		})
+		if err != nil {
+			log.Printf("Error notifying suggester %d: %v", *movie.SuggestedBy, err)
+			suggester := &model.User{ID: *movie.SuggestedBy, FirstName: "автор"}
+			if movie.Suggester != nil {
+				suggester = movie.Suggester
+			}
+			text += fmt.Sprintf("\n🔔 %s, твое предложение убрано администратором.", service.Mention(suggester))
+		}
+	}
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	fsmutils "github.com/Forceres/tg-bot-movieclub-go/internal/utils/fsm"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/go-telegram/fsm"
+)
+
+const (
+	stateVenueAddress fsm.StateID = "venue_address"
+	stateVenueRoom    fsm.StateID = "venue_room"
+	stateVenueNotes   fsm.StateID = "venue_notes"
+	stateSaveVenue    fsm.StateID = "save_venue"
+)
+
+// VENUE_SKIP_TEXT clears a venue field in the wizard.
+const VENUE_SKIP_TEXT = "-"
+
+// VENUE_SCHEDULE_DATA is the picker button changing the default venue of new sessions.
+const VENUE_SCHEDULE_DATA = "schedule"
+
+type VenueHandler struct {
+	f                 *fsm.FSM
+	sessionService    service.ISessionService
+	scheduleService   service.IScheduleService
+	attendanceService service.IAttendanceService
+}
+
+type IVenueHandler interface {
+	Handle(ctx context.Context, b *bot.Bot, update *models.Update)
+	PrepareAddress(f *fsm.FSM, args ...any)
+	PrepareRoom(f *fsm.FSM, args ...any)
+	PrepareNotes(f *fsm.FSM, args ...any)
+	SaveVenue(f *fsm.FSM, args ...any)
+}
+
+func NewVenueHandler(f *fsm.FSM, sessionService service.ISessionService, scheduleService service.IScheduleService, attendanceService service.IAttendanceService) IVenueHandler {
+	return &VenueHandler{f: f, sessionService: sessionService, scheduleService: scheduleService, attendanceService: attendanceService}
+}
+
+// Handle asks whether the venue of an upcoming session or the default one of the schedule is changed.
+func (h *VenueHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	userID := update.Message.From.ID
+	if h.f.Current(userID) != stateDefault {
+		return
+	}
+	sessions, err := h.sessionService.FindUpcomingSessions()
+	if err != nil {
+		log.Printf("Error finding upcoming sessions: %v", err)
+		sendText(ctx, b, update.Message.Chat.ID, "❌ Ошибка при получении сеансов.")
+		return
+	}
+	kb := sessionButtons(b, userID, sessions, false, func(ctx context.Context, b *bot.Bot, update *models.Update, session *model.Session) {
+		if h.f.Current(userID) != stateDefault {
+			return
+		}
+		h.f.Set(userID, "venue_session_id", session.ID)
+		h.f.Set(userID, "venue", session.Venue)
+		h.f.Transition(userID, stateVenueAddress, userID, ctx, b, update)
+	})
+	kb.Row().Button("🗓️ По умолчанию для новых сеансов", []byte(VENUE_SCHEDULE_DATA), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+		if update.CallbackQuery.From.ID != userID {
+			return
+		}
+		kb.Close(ctx, b, update)
+		if h.f.Current(userID) != stateDefault {
+			return
+		}
+		schedule, err := h.scheduleService.GetActiveSchedule()
+		if err != nil || schedule == nil || schedule.ID == 0 {
+			sendText(ctx, b, updateChatID(update), "ℹ️ Нет активного расписания.")
+			return
+		}
+		h.f.Set(userID, "venue_session_id", int64(0))
+		h.f.Set(userID, "venue", schedule.Venue)
+		h.f.Transition(userID, stateVenueAddress, userID, ctx, b, update)
+	})
+	kb.Row().Button("Отменить", []byte("cancel"), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+		if update.CallbackQuery.From.ID == userID {
+			kb.Close(ctx, b, update)
+		}
+	})
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        "📍 Где будем смотреть? Выберите сеанс или место по умолчанию.",
+		ReplyMarkup: kb,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
+
+func (h *VenueHandler) PrepareAddress(f *fsm.FSM, args ...any) {
+	venue := h.currentVenue(args[0].(int64))
+	h.prompt(f, args, "📍 Введите адрес встречи.", venue.Address)
+}
+
+func (h *VenueHandler) PrepareRoom(f *fsm.FSM, args ...any) {
+	venue := h.currentVenue(args[0].(int64))
+	h.prompt(f, args, "🔗 Отправьте ссылку на онлайн-комнату (http или https).", venue.RoomURL)
+}
+
+func (h *VenueHandler) PrepareNotes(f *fsm.FSM, args ...any) {
+	venue := h.currentVenue(args[0].(int64))
+	h.prompt(f, args, "ℹ️ Добавьте заметки: как пройти, что взять с собой и т.п.", venue.Notes)
+}
+
+func (h *VenueHandler) SaveVenue(f *fsm.FSM, args ...any) {
+	userID := args[0].(int64)
+	if f.Current(userID) != stateSaveVenue {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	update := args[3].(*models.Update)
+	chatID := update.Message.Chat.ID
+	address, _ := f.Get(userID, "venue_address")
+	room, _ := f.Get(userID, "venue_room")
+	notes, _ := f.Get(userID, "venue_notes")
+	venue := model.Venue{Address: address.(string), RoomURL: room.(string), Notes: notes.(string)}
+	sessionID, _ := f.Get(userID, "venue_session_id")
+	var err error
+	if sessionID.(int64) == 0 {
+		err = h.scheduleService.SetVenue(venue)
+	} else {
+		err = h.sessionService.SetVenue(sessionID.(int64), venue)
+	}
+	if err != nil {
+		log.Printf("Error saving venue: %v", err)
+		sendText(ctx, b, chatID, "❌ Ошибка при сохранении места встречи.")
+		f.Reset(userID)
+		return
+	}
+	text := "✅ Место встречи сохранено."
+	if formatted := service.FormatVenue(venue); formatted != "" {
+		text += "\n\n" + formatted
+	} else {
+		text = "✅ Место встречи очищено."
+	}
+	sendText(ctx, b, chatID, text)
+	if sessionID.(int64) != 0 {
+		// The pinned announcement shows the venue too
+		if session, err := h.sessionService.FindSessionByID(sessionID.(int64)); err == nil {
+			refreshRsvp(ctx, b, h.attendanceService, chatID, session)
+		}
+	}
+	fsmutils.DeleteMessages(ctx, b, f, userID, chatID)
+	f.Reset(userID)
+}
+
+func (h *VenueHandler) currentVenue(userID int64) model.Venue {
+	venue, ok := h.f.Get(userID, "venue")
+	if !ok {
+		return model.Venue{}
+	}
+	return venue.(model.Venue)
+}
+
+func (h *VenueHandler) prompt(f *fsm.FSM, args []any, text string, current string) {
+	userID := args[0].(int64)
+	if f.Current(userID) == stateDefault {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	update := args[3].(*models.Update)
+	if current != "" {
+		text += fmt.Sprintf("\n\n💡 Сейчас: %s", current)
+	}
+	text += fmt.Sprintf("\n\nℹ️ Отправьте «%s», чтобы оставить поле пустым, или /cancel для отмены.", VENUE_SKIP_TEXT)
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: updateChatID(update),
+		Text:   text,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+		f.Reset(userID)
+		return
+	}
+	fsmutils.AppendMessageID(f, userID, msg.ID)
+}
@@ -3,14 +3,17 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"html"
 	"log"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/model"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/tasks"
+	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/bracket"
 	fsmutils "github.com/Forceres/tg-bot-movieclub-go/internal/utils/fsm"
 	"github.com/Forceres/tg-bot-movieclub-go/internal/utils/telegram/keyboard"
 	"github.com/go-telegram/bot"
@@ -20,26 +23,15 @@ import (
 	"github.com/hibiken/asynq"
 )
 
-var RATING_VOTING_OPTIONS = []models.InputPollOption{
-	{Text: "1"},
-	{Text: "2"},
-	{Text: "3"},
-	{Text: "4"},
-	{Text: "5"},
-	{Text: "6"},
-	{Text: "7"},
-	{Text: "8"},
-	{Text: "9"},
-	{Text: "10"},
-}
-
 type VotingHandler struct {
-	movieService  service.IMovieService
-	votingService service.IVotingService
-	pollService   service.IPollService
-	voteService   service.IVoteService
-	fsm           *fsm.FSM
-	scheduler     *asynq.Client
+	movieService   service.IMovieService
+	votingService  service.IVotingService
+	pollService    service.IPollService
+	voteService    service.IVoteService
+	sessionService service.ISessionService
+	fsm            *fsm.FSM
+	scheduler      *asynq.Client
+	cfg            *config.VotingConfig
 }
 
 type IVotingHandler interface {
@@ -49,18 +41,32 @@ type IVotingHandler interface {
 const (
 	stateDefault               fsm.StateID = "default"
 	statePrepareVotingType     fsm.StateID = "prepare_voting_type"
+	statePrepareVotingMethod   fsm.StateID = "prepare_voting_method"
 	statePrepareVotingTitle    fsm.StateID = "prepare_voting_title"
 	statePrepareVotingDuration fsm.StateID = "prepare_voting_duration"
+	statePrepareVotingQuorum   fsm.StateID = "prepare_voting_quorum"
+	statePrepareQuorumPolicy   fsm.StateID = "prepare_quorum_policy"
+	statePrepareHideTally      fsm.StateID = "prepare_hide_tally"
+	statePrepareBallotMode     fsm.StateID = "prepare_ballot_mode"
+	statePrepareVotingSession  fsm.StateID = "prepare_voting_session"
 	statePrepareMovies         fsm.StateID = "prepare_movies"
 	stateStartVoting           fsm.StateID = "start_voting"
 )
 
-func NewVotingHandler(movieService service.IMovieService, votingService service.IVotingService, pollService service.IPollService, voteService service.IVoteService, f *fsm.FSM, scheduler *asynq.Client) *VotingHandler {
-	return &VotingHandler{movieService: movieService, votingService: votingService, pollService: pollService, voteService: voteService, fsm: f, scheduler: scheduler}
+func NewVotingHandler(movieService service.IMovieService, votingService service.IVotingService, pollService service.IPollService, voteService service.IVoteService, sessionService service.ISessionService, f *fsm.FSM, scheduler *asynq.Client, cfg *config.VotingConfig) *VotingHandler {
+	return &VotingHandler{movieService: movieService, votingService: votingService, pollService: pollService, voteService: voteService, sessionService: sessionService, fsm: f, scheduler: scheduler, cfg: cfg}
 }
 
 func (h *VotingHandler) Handle(ctx context.Context, b *bot.Bot, update *models.Update) {
 	userID := update.Message.From.ID
+	// FSM storage outlives Reset, so optional answers from a previous run are cleared here
+	h.fsm.Set(userID, "method", model.VOTING_METHOD_PLURALITY)
+	h.fsm.Set(userID, "quorumType", "")
+	h.fsm.Set(userID, "quorumValue", 0)
+	h.fsm.Set(userID, "quorumPolicy", "")
+	h.fsm.Set(userID, "hideTally", false)
+	h.fsm.Set(userID, "secret", false)
+	h.fsm.Set(userID, "sessionID", (*int64)(nil))
 	h.fsm.Transition(userID, statePrepareVotingType, userID, ctx, b, update)
 }
 
@@ -109,7 +115,7 @@ func (h *VotingHandler) onInlineKeyboardSelect(ctx context.Context, b *bot.Bot,
 			fsmutils.AppendMessageID(h.fsm, userID, msg.ID)
 		}
 		h.fsm.Set(userID, "type", selection)
-		h.fsm.Transition(userID, statePrepareVotingTitle, userID, ctx, b, update)
+		h.fsm.Transition(userID, statePrepareVotingMethod, userID, ctx, b, update)
 	case model.VOTING_RATING_TYPE:
 		msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.CallbackQuery.Message.Message.Chat.ID,
@@ -134,6 +140,55 @@ func (h *VotingHandler) onInlineKeyboardSelect(ctx context.Context, b *bot.Bot,
 	}
 }
 
+func (h *VotingHandler) PrepareVotingMethod(f *fsm.FSM, args ...any) {
+	userID := args[0].(int64)
+	currentState := f.Current(userID)
+	if currentState == stateDefault {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	update := args[3].(*models.Update)
+	kb := keyboard.New(b).
+		Row().
+		Button("Обычное", []byte(model.VOTING_METHOD_PLURALITY), h.onMethodSelect).
+		Button("Рейтинговое", []byte(model.VOTING_METHOD_RANKED), h.onMethodSelect).
+		Row().
+		Button("Отменить", []byte("cancel"), h.onCancelSelect)
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
+		Text:        "🗳️ Выбери способ голосования: обычное (побеждает фильм с наибольшим числом голосов) или рейтинговое (участники ранжируют фильмы, победитель определяется мгновенным вторым туром)",
+		ReplyMarkup: kb,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	} else {
+		fsmutils.AppendMessageID(f, userID, msg.ID)
+	}
+}
+
+func (h *VotingHandler) onMethodSelect(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+	userID := update.CallbackQuery.From.ID
+	currentState := h.fsm.Current(userID)
+	if currentState != statePrepareVotingMethod {
+		return
+	}
+	method := string(data)
+	if method != model.VOTING_METHOD_PLURALITY && method != model.VOTING_METHOD_RANKED {
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.CallbackQuery.Message.Message.Chat.ID,
+			Text:   "❓ Неизвестный выбор.",
+		})
+		if err != nil {
+			log.Printf("Error sending message: %v", err)
+		}
+		h.fsm.Reset(userID)
+		return
+	}
+	h.fsm.Set(userID, "method", method)
+	h.fsm.Transition(userID, statePrepareVotingTitle, userID, ctx, b, update)
+}
+
 func (h *VotingHandler) PrepareVotingTitle(f *fsm.FSM, args ...any) {
 	userID := args[0].(int64)
 	currentState := f.Current(userID)
@@ -174,6 +229,197 @@ func (h *VotingHandler) PrepareVotingDuration(f *fsm.FSM, args ...any) {
 	}
 }
 
+func (h *VotingHandler) PrepareVotingQuorum(f *fsm.FSM, args ...any) {
+	userID := args[0].(int64)
+	currentState := f.Current(userID)
+	if currentState == stateDefault {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	update := args[3].(*models.Update)
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   "👥 Введите кворум: минимальное число проголосовавших (например, 5) или долю зарегистрированных участников (например, 30%). 0 — без кворума",
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	} else {
+		fsmutils.AppendMessageID(f, userID, msg.ID)
+	}
+}
+
+func (h *VotingHandler) PrepareQuorumPolicy(f *fsm.FSM, args ...any) {
+	userID := args[0].(int64)
+	currentState := f.Current(userID)
+	if currentState == stateDefault {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	update := args[3].(*models.Update)
+	kb := keyboard.New(b).
+		Row().
+		Button("Продлить", []byte(model.QUORUM_EXTEND_POLICY), h.onQuorumPolicySelect).
+		Button("Отменить голосование", []byte(model.QUORUM_CANCEL_POLICY), h.onQuorumPolicySelect).
+		Button("Принять", []byte(model.QUORUM_ACCEPT_POLICY), h.onQuorumPolicySelect).
+		Row().
+		Button("Отменить", []byte("cancel"), h.onCancelSelect)
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        "⚖️ Что делать, если кворум не будет набран: продлить голосование один раз, отменить его или принять результат с пометкой о низкой достоверности?",
+		ReplyMarkup: kb,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	} else {
+		fsmutils.AppendMessageID(f, userID, msg.ID)
+	}
+}
+
+func (h *VotingHandler) onQuorumPolicySelect(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+	userID := update.CallbackQuery.From.ID
+	currentState := h.fsm.Current(userID)
+	if currentState != statePrepareQuorumPolicy {
+		return
+	}
+	h.fsm.Set(userID, "quorumPolicy", string(data))
+	h.fsm.Transition(userID, statePrepareHideTally, userID, ctx, b, update)
+}
+
+func (h *VotingHandler) PrepareHideTally(f *fsm.FSM, args ...any) {
+	userID := args[0].(int64)
+	currentState := f.Current(userID)
+	if currentState == stateDefault {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	update := args[3].(*models.Update)
+	var chatID int64
+	if update.CallbackQuery != nil {
+		chatID = update.CallbackQuery.Message.Message.Chat.ID
+	} else {
+		chatID = update.Message.Chat.ID
+	}
+	kb := keyboard.New(b).
+		Row().
+		Button("Показывать", []byte("show"), h.onHideTallySelect).
+		Button("Скрыть до завершения", []byte("hide"), h.onHideTallySelect).
+		Row().
+		Button("Отменить", []byte("cancel"), h.onCancelSelect)
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        "📊 Показывать промежуточные результаты в /results или скрыть их до завершения голосования?",
+		ReplyMarkup: kb,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	} else {
+		fsmutils.AppendMessageID(f, userID, msg.ID)
+	}
+}
+
+func (h *VotingHandler) onHideTallySelect(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+	userID := update.CallbackQuery.From.ID
+	currentState := h.fsm.Current(userID)
+	if currentState != statePrepareHideTally {
+		return
+	}
+	h.fsm.Set(userID, "hideTally", string(data) == "hide")
+	h.fsm.Transition(userID, statePrepareBallotMode, userID, ctx, b, update)
+}
+
+func (h *VotingHandler) PrepareBallotMode(f *fsm.FSM, args ...any) {
+	userID := args[0].(int64)
+	currentState := f.Current(userID)
+	if currentState == stateDefault {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	update := args[3].(*models.Update)
+	kb := keyboard.New(b).
+		Row().
+		Button("Открытое", []byte("open"), h.onBallotModeSelect).
+		Button("Тайное", []byte("secret"), h.onBallotModeSelect).
+		Row().
+		Button("Отменить", []byte("cancel"), h.onCancelSelect)
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
+		Text:        "🔒 Открытое голосование (опрос в группе) или тайное (участники голосуют в личных сообщениях с ботом, публикуются только итоги)?",
+		ReplyMarkup: kb,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	} else {
+		fsmutils.AppendMessageID(f, userID, msg.ID)
+	}
+}
+
+func (h *VotingHandler) onBallotModeSelect(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+	userID := update.CallbackQuery.From.ID
+	currentState := h.fsm.Current(userID)
+	if currentState != statePrepareBallotMode {
+		return
+	}
+	h.fsm.Set(userID, "secret", string(data) == "secret")
+	votingType, _ := h.fsm.Get(userID, "type")
+	if votingType == model.VOTING_SELECTION_TYPE {
+		h.fsm.Transition(userID, statePrepareVotingSession, userID, ctx, b, update)
+		return
+	}
+	h.fsm.Transition(userID, stateStartVoting, userID, ctx, b, update)
+}
+
+// PrepareVotingSession asks which session the winner of the selection voting goes to.
+// The step is skipped when nothing is planned yet, the winner then gets a new session.
+func (h *VotingHandler) PrepareVotingSession(f *fsm.FSM, args ...any) {
+	userID := args[0].(int64)
+	currentState := f.Current(userID)
+	if currentState == stateDefault {
+		return
+	}
+	ctx := args[1].(context.Context)
+	b := args[2].(*bot.Bot)
+	update := args[3].(*models.Update)
+	sessions, err := h.sessionService.FindUpcomingSessions()
+	if err != nil {
+		log.Printf("Error finding upcoming sessions: %v", err)
+	}
+	if len(sessions) == 0 {
+		f.Transition(userID, stateStartVoting, userID, ctx, b, update)
+		return
+	}
+	onSelect := func(ctx context.Context, b *bot.Bot, update *models.Update, session *model.Session) {
+		if h.fsm.Current(userID) != statePrepareVotingSession {
+			return
+		}
+		if session != nil {
+			h.fsm.Set(userID, "sessionID", &session.ID)
+		}
+		h.fsm.Transition(userID, stateStartVoting, userID, ctx, b, update)
+	}
+	kb := sessionButtons(b, userID, sessions, true, onSelect)
+	kb.Row().Button("Отменить", []byte("cancel"), func(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
+		if update.CallbackQuery.From.ID != userID {
+			return
+		}
+		kb.Close(ctx, b, update)
+		h.onCancelSelect(ctx, b, update, data)
+	})
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.CallbackQuery.Message.Message.Chat.ID,
+		Text:        "📅 В какой сеанс пойдет победитель голосования?",
+		ReplyMarkup: kb,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	} else {
+		fsmutils.AppendMessageID(f, userID, msg.ID)
+	}
+}
+
 func (h *VotingHandler) onCancelSelect(ctx context.Context, b *bot.Bot, update *models.Update, data []byte) {
 	userID := update.CallbackQuery.From.ID
 	currentState := h.fsm.Current(userID)
@@ -271,9 +517,23 @@ func (h *VotingHandler) StartVoting(f *fsm.FSM, args ...any) {
 	if currentState == stateDefault {
 		return
 	}
+	// The voting is started either by the duration/quorum input or by the quorum policy button
+	var chatID int64
+	if update.CallbackQuery != nil {
+		chatID = update.CallbackQuery.Message.Message.Chat.ID
+	} else {
+		chatID = update.Message.Chat.ID
+	}
 	duration, _ := h.fsm.Get(userID, "duration")
 	votingType, _ := h.fsm.Get(userID, "type")
 	title, _ := h.fsm.Get(userID, "title")
+	method, _ := h.fsm.Get(userID, "method")
+	quorumType, _ := h.fsm.Get(userID, "quorumType")
+	quorumValue, _ := h.fsm.Get(userID, "quorumValue")
+	quorumPolicy, _ := h.fsm.Get(userID, "quorumPolicy")
+	hideTally, _ := h.fsm.Get(userID, "hideTally")
+	secret, _ := h.fsm.Get(userID, "secret")
+	sessionID, _ := h.fsm.Get(userID, "sessionID")
 	finishedAt := time.Now().Add(time.Duration(duration.(int)) * time.Hour).Unix()
 	switch votingType.(string) {
 	case model.VOTING_SELECTION_TYPE:
@@ -295,6 +555,17 @@ func (h *VotingHandler) StartVoting(f *fsm.FSM, args ...any) {
 				log.Printf("Error converting movie ID: %v", err)
 				continue
 			}
+			if method.(string) == model.VOTING_METHOD_RANKED || secret.(bool) {
+				// Ranked and secret ballots are rendered as buttons and plain text, which don't support markdown
+				movie, err := h.movieService.GetMovieByID(movieID)
+				if err != nil {
+					log.Printf("Error getting movie by ID: %v", err)
+					continue
+				}
+				movieIDs = append(movieIDs, movieID)
+				pollOpts = append(pollOpts, models.InputPollOption{Text: fmt.Sprintf("%s (%d)", movie.Title, movie.Year)})
+				continue
+			}
 			movieIDs = append(movieIDs, movieID)
 			var title string = movieData[1]
 			parts := strings.SplitN(movieData[1], ". ", 2)
@@ -303,18 +574,35 @@ func (h *VotingHandler) StartVoting(f *fsm.FSM, args ...any) {
 			}
 			pollOpts = append(pollOpts, models.InputPollOption{Text: title, TextParseMode: models.ParseModeMarkdown})
 		}
+		options := service.VotingOptions{
+			Title:        title.(string),
+			Type:         votingType.(string),
+			Method:       method.(string),
+			CreatedBy:    userID,
+			FinishedAt:   &finishedAt,
+			QuorumType:   quorumType.(string),
+			QuorumValue:  quorumValue.(int),
+			QuorumPolicy: quorumPolicy.(string),
+			HideTally:    hideTally.(bool),
+			Secret:       secret.(bool),
+			SessionID:    sessionID.(*int64),
+		}
+		duration := time.Duration(duration.(int)) * time.Hour
+		if options.Method == model.VOTING_METHOD_PLURALITY && !options.Secret && len(movieIDs) > bracket.MAX_POLL_OPTIONS {
+			// Too many candidates for a single poll, they are split into group polls announced one by one
+			if err := h.startBracket(ctx, b, chatID, options, movieIDs, pollOpts, duration); err != nil {
+				log.Printf("Error starting bracket: %v", err)
+			}
+			break
+		}
+		tasks.AnnounceMovies(ctx, b, h.movieService, chatID, fmt.Sprintf("🗳️ Кандидаты голосования «%s»:", html.EscapeString(options.Title)), movieIDs)
 		multi := new(bool)
 		*multi = true
 		poll, err := h.votingService.StartVoting(&service.StartRatingVotingParams{
-			Bot:     b,
-			Context: ctx,
-			ChatID:  update.Message.Chat.ID,
-			Options: service.VotingOptions{
-				Title:      title.(string),
-				Type:       votingType.(string),
-				CreatedBy:  userID,
-				FinishedAt: &finishedAt,
-			},
+			Bot:         b,
+			Context:     ctx,
+			ChatID:      chatID,
+			Options:     options,
 			Multi:       multi,
 			PollOptions: pollOpts,
 			Question:    title.(string),
@@ -336,17 +624,17 @@ func (h *VotingHandler) StartVoting(f *fsm.FSM, args ...any) {
 				return
 			}
 		}
-		duration := time.Duration(duration.(int)) * time.Hour
 		err = tasks.EnqueueCloseSelectionVotingTask(h.scheduler, duration, &tasks.CloseSelectionVotingPayload{
 			PollID:    poll.PollID,
 			MessageID: poll.MessageID,
-			ChatID:    update.Message.Chat.ID,
+			ChatID:    chatID,
 			VotingID:  poll.VotingID,
 			UserID:    userID,
 		})
 		if err != nil {
 			log.Printf("Error scheduling close rating voting task: %v", err)
 		}
+		h.enqueueReminder(chatID, poll.VotingID, duration)
 	case model.VOTING_RATING_TYPE:
 		movies, _ := f.Get(userID, "movies")
 		selectedMovieIndexes, _ := f.Get(userID, "movieIndexes")
@@ -365,18 +653,24 @@ func (h *VotingHandler) StartVoting(f *fsm.FSM, args ...any) {
 				title = parts[1]
 			}
 			title = fmt.Sprintf("Оцените фильм: %s", title)
+			tasks.AnnounceMovies(ctx, b, h.movieService, chatID, "", []int64{movieID})
 			poll, err := h.votingService.StartVoting(&service.StartRatingVotingParams{
 				Bot:     b,
 				Context: ctx,
-				ChatID:  update.Message.Chat.ID,
+				ChatID:  chatID,
 				Options: service.VotingOptions{
-					Title:      title,
-					Type:       votingType.(string),
-					CreatedBy:  userID,
-					FinishedAt: &finishedAt,
-					MovieID:    &movieID,
+					Title:        title,
+					Type:         votingType.(string),
+					CreatedBy:    userID,
+					FinishedAt:   &finishedAt,
+					MovieID:      &movieID,
+					QuorumType:   quorumType.(string),
+					QuorumValue:  quorumValue.(int),
+					QuorumPolicy: quorumPolicy.(string),
+					HideTally:    hideTally.(bool),
+					Secret:       secret.(bool),
 				},
-				PollOptions: RATING_VOTING_OPTIONS,
+				PollOptions: tasks.RATING_VOTING_OPTIONS,
 				Question:    title,
 			})
 			if err != nil {
@@ -388,7 +682,7 @@ func (h *VotingHandler) StartVoting(f *fsm.FSM, args ...any) {
 			err = tasks.EnqueueCloseRatingVotingTask(h.scheduler, duration, &tasks.CloseRatingVotingPayload{
 				PollID:    poll.PollID,
 				MessageID: poll.MessageID,
-				ChatID:    update.Message.Chat.ID,
+				ChatID:    chatID,
 				VotingID:  poll.VotingID,
 				MovieID:   movieID,
 				UserID:    userID,
@@ -396,8 +690,92 @@ func (h *VotingHandler) StartVoting(f *fsm.FSM, args ...any) {
 			if err != nil {
 				log.Printf("Error scheduling close rating voting task: %v", err)
 			}
+			h.enqueueReminder(chatID, poll.VotingID, duration)
 		}
 	}
-	fsmutils.DeleteMessages(ctx, b, f, userID, update.Message.From.ID)
+	fsmutils.DeleteMessages(ctx, b, f, userID, chatID)
 	h.fsm.Reset(userID)
 }
+
+// startBracket creates the bracket and opens a poll for every group of candidates.
+// The final is started by the close task of the last group.
+func (h *VotingHandler) startBracket(ctx context.Context, b *bot.Bot, chatID int64, options service.VotingOptions, movieIDs []int64, pollOpts []models.InputPollOption, duration time.Duration) error {
+	groups, advanceCount, err := bracket.Split(movieIDs)
+	if err != nil {
+		_, sendErr := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("❌ Слишком много фильмов: не больше %d.", bracket.MAX_POLL_OPTIONS*bracket.MAX_POLL_OPTIONS),
+		})
+		if sendErr != nil {
+			log.Printf("Error sending message: %v", sendErr)
+		}
+		return err
+	}
+	created, err := h.votingService.CreateBracket(options.Title, advanceCount, options.CreatedBy)
+	if err != nil {
+		return err
+	}
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   fmt.Sprintf("🏟️ Фильмов больше, чем помещается в один опрос, поэтому голосование \"%s\" пройдёт в %d группах. Из каждой группы в финал выйдут %d лучших.", options.Title, len(groups), advanceCount),
+	})
+	if err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+	multi := true
+	offset := 0
+	for i, group := range groups {
+		groupOptions := options
+		groupOptions.Title = fmt.Sprintf("%s (группа %d/%d)", options.Title, i+1, len(groups))
+		groupOptions.BracketID = &created.ID
+		groupOptions.BracketGroup = i + 1
+		tasks.AnnounceMovies(ctx, b, h.movieService, chatID, fmt.Sprintf("🗳️ Кандидаты группы %d/%d:", i+1, len(groups)), group)
+		poll, err := h.votingService.StartVoting(&service.StartRatingVotingParams{
+			Bot:         b,
+			Context:     ctx,
+			ChatID:      chatID,
+			Options:     groupOptions,
+			Multi:       &multi,
+			PollOptions: pollOpts[offset : offset+len(group)],
+			Question:    groupOptions.Title,
+		})
+		if err != nil {
+			return err
+		}
+		for optionIndex, id := range group {
+			err := h.pollService.CreatePollOption(&model.PollOption{
+				PollID:      poll.ID,
+				OptionIndex: optionIndex,
+				MovieID:     id,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		offset += len(group)
+		err = tasks.EnqueueCloseSelectionVotingTask(h.scheduler, duration, &tasks.CloseSelectionVotingPayload{
+			PollID:    poll.PollID,
+			MessageID: poll.MessageID,
+			ChatID:    chatID,
+			VotingID:  poll.VotingID,
+			UserID:    options.CreatedBy,
+		})
+		if err != nil {
+			log.Printf("Error scheduling close group voting task: %v", err)
+		}
+		h.enqueueReminder(chatID, poll.VotingID, duration)
+	}
+	return nil
+}
+
+func (h *VotingHandler) enqueueReminder(chatID int64, votingID int64, duration time.Duration) {
+	err := tasks.EnqueueRemindVotingTask(h.scheduler, &tasks.EnqueueRemindVotingParams{
+		ChatID:   chatID,
+		VotingID: votingID,
+		Duration: duration,
+		Offset:   h.cfg.ReminderOffset,
+	})
+	if err != nil {
+		log.Printf("Error scheduling remind voting task: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Forceres/tg-bot-movieclub-go/internal/service"
+	"github.com/go-telegram/bot"
+)
+
+type CalendarHandler struct {
+	calendarService service.ICalendarService
+	b               *bot.Bot
+	groupID         int64
+}
+
+func NewCalendarHandler(calendarService service.ICalendarService, b *bot.Bot, groupID int64) *CalendarHandler {
+	return &CalendarHandler{calendarService: calendarService, b: b, groupID: groupID}
+}
+
+// ServeHTTP serves the iCalendar feed to the links given by /calendar, other paths are not found.
+func (h *CalendarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, service.CALENDAR_PATH)
+	userPart, token, ok := strings.Cut(strings.TrimSuffix(path, ".ics"), "/")
+	if !ok || !strings.HasSuffix(path, ".ics") {
+		http.NotFound(w, r)
+		return
+	}
+	userID, err := strconv.ParseInt(userPart, 10, 64)
+	if err != nil || !h.calendarService.CheckToken(userID, token) {
+		http.NotFound(w, r)
+		return
+	}
+	// The link can't be revoked, so the membership is checked on every request
+	member, err := h.b.GetChatMember(r.Context(), &bot.GetChatMemberParams{
+		ChatID: h.groupID,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Printf("Error getting chat member %d: %v", userID, err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if member.Left != nil || member.Banned != nil {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	feed, err := h.calendarService.Feed()
+	if err != nil {
+		log.Printf("Error building calendar feed: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="movieclub.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Write([]byte(feed))
+}
@@ -0,0 +1,51 @@
+package bracket
+
+import (
+	"errors"
+	"sort"
+)
+
+// MAX_POLL_OPTIONS is the number of options that fit in a single Telegram poll.
+const MAX_POLL_OPTIONS = 10
+
+var ErrTooManyCandidates = errors.New("too many candidates for a bracket")
+
+// Tally is the number of votes for a movie in a group poll.
+type Tally struct {
+	MovieID int64
+	Votes   int64
+}
+
+// Split divides the candidates into the smallest number of groups that fit in a poll,
+// keeping the group sizes as even as possible. Every group sends AdvanceCount movies
+// to the final, so that the final fits in a single poll as well.
+func Split(movieIDs []int64) (groups [][]int64, advanceCount int, err error) {
+	if len(movieIDs) > MAX_POLL_OPTIONS*MAX_POLL_OPTIONS {
+		return nil, 0, ErrTooManyCandidates
+	}
+	count := (len(movieIDs) + MAX_POLL_OPTIONS - 1) / MAX_POLL_OPTIONS
+	if count == 0 {
+		return nil, 0, nil
+	}
+	for i := 0; i < count; i++ {
+		start := i * len(movieIDs) / count
+		end := (i + 1) * len(movieIDs) / count
+		groups = append(groups, movieIDs[start:end])
+	}
+	return groups, max(1, MAX_POLL_OPTIONS/count), nil
+}
+
+// Advance returns the movies with the most votes in the group.
+// Ties at the cut are broken by the order of the options in the poll.
+func Advance(tallies []Tally, count int) []int64 {
+	sorted := make([]Tally, len(tallies))
+	copy(sorted, tallies)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Votes > sorted[j].Votes
+	})
+	advanced := make([]int64, 0, count)
+	for i := 0; i < count && i < len(sorted); i++ {
+		advanced = append(advanced, sorted[i].MovieID)
+	}
+	return advanced
+}
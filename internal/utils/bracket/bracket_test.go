@@ -0,0 +1,108 @@
+package bracket
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func movieIDs(n int) []int64 {
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	return ids
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name         string
+		candidates   int
+		sizes        []int
+		advanceCount int
+		err          error
+	}{
+		{name: "no candidates", candidates: 0, sizes: nil, advanceCount: 0},
+		{name: "single poll", candidates: 10, sizes: []int{10}, advanceCount: 10},
+		{name: "one over a poll", candidates: 11, sizes: []int{5, 6}, advanceCount: 5},
+		{name: "uneven groups", candidates: 25, sizes: []int{8, 8, 9}, advanceCount: 3},
+		{name: "full bracket", candidates: 100, sizes: []int{10, 10, 10, 10, 10, 10, 10, 10, 10, 10}, advanceCount: 1},
+		{name: "too many candidates", candidates: 101, err: ErrTooManyCandidates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := movieIDs(tt.candidates)
+			groups, advanceCount, err := Split(candidates)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if advanceCount != tt.advanceCount {
+				t.Errorf("advanceCount = %d, want %d", advanceCount, tt.advanceCount)
+			}
+			sizes := make([]int, 0, len(groups))
+			var joined []int64
+			for _, group := range groups {
+				sizes = append(sizes, len(group))
+				joined = append(joined, group...)
+			}
+			if len(sizes) == 0 {
+				sizes = nil
+			}
+			if !slices.Equal(sizes, tt.sizes) {
+				t.Errorf("group sizes = %v, want %v", sizes, tt.sizes)
+			}
+			if tt.err == nil && !slices.Equal(joined, candidates) {
+				t.Errorf("groups %v don't keep the candidates in order", groups)
+			}
+			if len(groups)*advanceCount > MAX_POLL_OPTIONS {
+				t.Errorf("final of %d movies doesn't fit in a poll", len(groups)*advanceCount)
+			}
+		})
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	tests := []struct {
+		name    string
+		tallies []Tally
+		count   int
+		want    []int64
+	}{
+		{
+			name:    "most votes",
+			tallies: []Tally{{MovieID: 1, Votes: 3}, {MovieID: 2, Votes: 5}, {MovieID: 3, Votes: 4}, {MovieID: 4, Votes: 1}},
+			count:   2,
+			want:    []int64{2, 3},
+		},
+		{
+			name:    "tie at the cut keeps the poll order",
+			tallies: []Tally{{MovieID: 1, Votes: 2}, {MovieID: 2, Votes: 2}, {MovieID: 3, Votes: 2}},
+			count:   2,
+			want:    []int64{1, 2},
+		},
+		{
+			name:    "fewer movies than advance",
+			tallies: []Tally{{MovieID: 7, Votes: 0}, {MovieID: 8, Votes: 1}},
+			count:   5,
+			want:    []int64{8, 7},
+		},
+		{
+			name:    "empty group",
+			tallies: nil,
+			count:   3,
+			want:    []int64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tallies := slices.Clone(tt.tallies)
+			got := Advance(tallies, tt.count)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Advance() = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(tallies, tt.tallies) {
+				t.Errorf("Advance() reordered the tallies: %v", tallies)
+			}
+		})
+	}
+}
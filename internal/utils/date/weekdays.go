@@ -0,0 +1,71 @@
+package date
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidWeekday = errors.New("invalid weekday")
+
+// WEEKDAY_NAMES are the short Russian names of the weekdays, 1=Monday, ..., 7=Sunday.
+var WEEKDAY_NAMES = []string{"", "пн", "вт", "ср", "чт", "пт", "сб", "вс"}
+
+// weekdayPrefixes maps the first two letters of short and full names to the weekdays.
+var weekdayPrefixes = map[string]int{
+	"пн": 1, "по": 1,
+	"вт": 2,
+	"ср": 3,
+	"чт": 4, "че": 4,
+	"пт": 5, "пя": 5,
+	"сб": 6, "су": 6,
+	"вс": 7, "во": 7,
+}
+
+// ParseWeekdays parses weekdays separated by commas or spaces, given as numbers 1-7 or Russian names like "пн" and "среда".
+func ParseWeekdays(text string) ([]int, error) {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return r == ',' || r == ' ' || r == ';'
+	})
+	var weekdays []int
+	for _, field := range fields {
+		weekday := parseWeekday(field)
+		if weekday == 0 {
+			return nil, ErrInvalidWeekday
+		}
+		if !slices.Contains(weekdays, weekday) {
+			weekdays = append(weekdays, weekday)
+		}
+	}
+	if len(weekdays) == 0 {
+		return nil, ErrInvalidWeekday
+	}
+	slices.Sort(weekdays)
+	return weekdays, nil
+}
+
+func parseWeekday(field string) int {
+	if n, err := strconv.Atoi(field); err == nil {
+		if n >= 1 && n <= 7 {
+			return n
+		}
+		return 0
+	}
+	runes := []rune(field)
+	if len(runes) < 2 {
+		return 0
+	}
+	return weekdayPrefixes[string(runes[:2])]
+}
+
+// FormatWeekdays joins the short names of the weekdays.
+func FormatWeekdays(weekdays []int) string {
+	names := make([]string, 0, len(weekdays))
+	for _, weekday := range weekdays {
+		if weekday >= 1 && weekday <= 7 {
+			names = append(names, WEEKDAY_NAMES[weekday])
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,57 @@
+package date
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		text string
+		want []int
+		err  error
+	}{
+		{text: "пн, ср", want: []int{1, 3}},
+		{text: "5 1 1", want: []int{1, 5}},
+		{text: "Пятница;суббота", want: []int{5, 6}},
+		{text: "воскресенье", want: []int{7}},
+		{text: "четверг, вт", want: []int{2, 4}},
+		{text: "вт,,чт", want: []int{2, 4}},
+		{text: "7", want: []int{7}},
+		{text: "", err: ErrInvalidWeekday},
+		{text: " , ", err: ErrInvalidWeekday},
+		{text: "0", err: ErrInvalidWeekday},
+		{text: "8", err: ErrInvalidWeekday},
+		{text: "пн, х", err: ErrInvalidWeekday},
+		{text: "monday", err: ErrInvalidWeekday},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseWeekdays(tt.text)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseWeekdays(%q) err = %v, want %v", tt.text, err, tt.err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseWeekdays(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatWeekdays(t *testing.T) {
+	tests := []struct {
+		weekdays []int
+		want     string
+	}{
+		{weekdays: []int{1, 3, 5}, want: "пн, ср, пт"},
+		{weekdays: []int{7}, want: "вс"},
+		{weekdays: []int{0, 2, 8}, want: "вт"},
+		{weekdays: nil, want: ""},
+	}
+	for _, tt := range tests {
+		if got := FormatWeekdays(tt.weekdays); got != tt.want {
+			t.Errorf("FormatWeekdays(%v) = %q, want %q", tt.weekdays, got, tt.want)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 const stateDefault fsm.StateID = "default"
 const stateTime fsm.StateID = "time"
+const stateToggleException fsm.StateID = "toggle_exception"
 
 type Datepicker struct {
 	f          *fsm.FSM
@@ -56,6 +57,24 @@ func (d *Datepicker) OnDatepickerSelect(ctx context.Context, b *bot.Bot, callbac
 	}
 }
 
+// OnExceptionSelect passes the date to be skipped or brought back in the schedule.
+func (d *Datepicker) OnExceptionSelect(ctx context.Context, b *bot.Bot, callbackQuery *models.CallbackQuery, date time.Time) {
+	userID := callbackQuery.From.ID
+	currentState := d.f.Current(userID)
+	if currentState == stateDefault {
+		return
+	}
+	d.f.Set(userID, "date", date)
+	d.f.Transition(userID, stateToggleException, userID, ctx, b, callbackQuery)
+	_, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    callbackQuery.Message.Message.Chat.ID,
+		MessageID: callbackQuery.Message.Message.ID,
+	})
+	if err != nil {
+		log.Printf("failed to delete message onCancel: %e", err)
+	}
+}
+
 func ScheduleDatepicker(b *bot.Bot, d *Datepicker) {
 	now := time.Now()
 	year := now.Year()
@@ -99,3 +118,21 @@ func SessionDatepicker(b *bot.Bot, d *Datepicker) {
 		opts...,
 	)
 }
+
+func ExceptionDatepicker(b *bot.Bot, d *Datepicker) {
+	now := time.Now()
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	opts := []datepicker.Option{
+		datepicker.From(date),
+		datepicker.OnCancel(d.OnDatepickerCancel),
+		datepicker.Language("ru"),
+		datepicker.NoDeleteAfterCancel(),
+		datepicker.NoDeleteAfterSelect(),
+		datepicker.WithPrefix("exception-datepicker"),
+	}
+	d.Datepicker = datepicker.New(
+		b,
+		d.OnExceptionSelect,
+		opts...,
+	)
+}
@@ -6,12 +6,14 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 
 	"github.com/Forceres/tg-bot-movieclub-go/internal/config"
 )
 
 const MOVIES = "/films"
 const STAFF = "/staff"
+const SEARCH_BY_KEYWORD = "/films/search-by-keyword"
 
 type Country struct {
 	Country string `json:"country"`
@@ -79,6 +81,26 @@ type KinopoiskStaff struct {
 	ProfessionKey  string  `json:"professionKey"`
 }
 
+type KinopoiskSearchFilm struct {
+	FilmID      int64     `json:"filmId"`
+	NameRu      string    `json:"nameRu"`
+	NameEn      string    `json:"nameEn"`
+	Type        string    `json:"type"`
+	Year        string    `json:"year"`
+	Description string    `json:"description"`
+	FilmLength  string    `json:"filmLength"`
+	Countries   []Country `json:"countries"`
+	Genres      []Genre   `json:"genres"`
+	Rating      string    `json:"rating"`
+	PosterURL   string    `json:"posterUrl"`
+}
+
+type KinopoiskSearchResponse struct {
+	Keyword    string                `json:"keyword"`
+	PagesCount int                   `json:"pagesCount"`
+	Films      []KinopoiskSearchFilm `json:"films"`
+}
+
 type KinopoiskMovieWithStaff struct {
 	Movie *KinopoiskMovie
 	Staff *[]KinopoiskStaff
@@ -104,6 +126,7 @@ type IKinopoiskAPI interface {
 	SearchMovie(id int64) (*KinopoiskMovie, error)
 	SearchMovies(ids []int64) (*[]KinopoiskMovieWithStaff, error)
 	SearchStaff(movieId int64) (*[]KinopoiskStaff, error)
+	SearchByKeyword(keyword string) (*[]KinopoiskSearchFilm, error)
 	APIGetCall(url string) ([]byte, error)
 }
 
@@ -182,3 +205,21 @@ func (k *KinopoiskAPI) SearchStaff(movieId int64) (*[]KinopoiskStaff, error) {
 	}
 	return &staff, nil
 }
+
+// SearchByKeyword returns the first page of the films matching the keyword, the best matches first.
+func (k *KinopoiskAPI) SearchByKeyword(keyword string) (*[]KinopoiskSearchFilm, error) {
+	query := url.QueryEscape(keyword)
+	var url string = fmt.Sprintf(k.APIUrl+"v2.1"+"%s?keyword=%s&page=1", SEARCH_BY_KEYWORD, query)
+	body, err := k.APIGetCall(url)
+	if err != nil {
+		log.Printf("Error searching movies: %v", err)
+		return nil, err
+	}
+	var response KinopoiskSearchResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		log.Printf("Error unmarshalling response body: %v", err)
+		return nil, err
+	}
+	return &response.Films, nil
+}
@@ -0,0 +1,91 @@
+package runoff
+
+import "sort"
+
+// Tally is the number of ballots counted for a movie in a single round.
+type Tally struct {
+	MovieID int64
+	Votes   int
+}
+
+type Round struct {
+	Tallies    []Tally
+	Eliminated []int64
+}
+
+type Result struct {
+	Rounds  []Round
+	Winners []int64 // more than one winner means an unbreakable tie
+}
+
+// InstantRunoff counts ranked ballots (most preferred movie first).
+// Each round every ballot goes to its highest ranked movie still in the race;
+// a movie with more than half of the counted ballots wins, otherwise the movies
+// with the fewest ballots are eliminated and the next round starts.
+func InstantRunoff(ballots [][]int64) *Result {
+	result := &Result{}
+	var remaining []int64
+	seen := make(map[int64]bool)
+	for _, ballot := range ballots {
+		for _, movieID := range ballot {
+			if !seen[movieID] {
+				seen[movieID] = true
+				remaining = append(remaining, movieID)
+			}
+		}
+	}
+	if len(remaining) == 0 {
+		return result
+	}
+
+	for {
+		counts := make(map[int64]int, len(remaining))
+		inRace := make(map[int64]bool, len(remaining))
+		for _, movieID := range remaining {
+			inRace[movieID] = true
+		}
+		total := 0
+		for _, ballot := range ballots {
+			for _, movieID := range ballot {
+				if inRace[movieID] {
+					counts[movieID]++
+					total++
+					break
+				}
+			}
+		}
+
+		round := Round{Tallies: make([]Tally, 0, len(remaining))}
+		for _, movieID := range remaining {
+			round.Tallies = append(round.Tallies, Tally{MovieID: movieID, Votes: counts[movieID]})
+		}
+		sort.SliceStable(round.Tallies, func(i, j int) bool {
+			return round.Tallies[i].Votes > round.Tallies[j].Votes
+		})
+
+		leader := round.Tallies[0]
+		if leader.Votes*2 > total || len(remaining) == 1 {
+			result.Rounds = append(result.Rounds, round)
+			result.Winners = []int64{leader.MovieID}
+			return result
+		}
+
+		minVotes := round.Tallies[len(round.Tallies)-1].Votes
+		if minVotes == leader.Votes {
+			// Everybody left is tied, nobody can be eliminated.
+			result.Rounds = append(result.Rounds, round)
+			result.Winners = remaining
+			return result
+		}
+		var survivors []int64
+		for _, movieID := range remaining {
+			if counts[movieID] == minVotes {
+				round.Eliminated = append(round.Eliminated, movieID)
+			} else {
+				survivors = append(survivors, movieID)
+			}
+		}
+		result.Rounds = append(result.Rounds, round)
+		remaining = survivors
+	}
+}
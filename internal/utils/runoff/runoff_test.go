@@ -0,0 +1,87 @@
+package runoff
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestInstantRunoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		ballots    [][]int64
+		winners    []int64
+		rounds     int
+		eliminated [][]int64 // by round
+	}{
+		{
+			name:    "no ballots",
+			ballots: nil,
+			winners: nil,
+			rounds:  0,
+		},
+		{
+			name:    "single candidate",
+			ballots: [][]int64{{5}},
+			winners: []int64{5},
+			rounds:  1,
+		},
+		{
+			name:    "majority in the first round",
+			ballots: [][]int64{{1, 2}, {1, 3}, {2, 1}},
+			winners: []int64{1},
+			rounds:  1,
+		},
+		{
+			name:       "last place ballots are transferred",
+			ballots:    [][]int64{{1, 2, 3}, {1, 3}, {2, 1}, {2, 3}, {3, 1}},
+			winners:    []int64{1},
+			rounds:     2,
+			eliminated: [][]int64{{3}, nil},
+		},
+		{
+			name:    "everybody tied",
+			ballots: [][]int64{{1}, {2}, {3}},
+			winners: []int64{1, 2, 3},
+			rounds:  1,
+		},
+		{
+			name:       "exhausted ballots leave a tie",
+			ballots:    [][]int64{{1}, {1}, {2}, {2}, {3}},
+			winners:    []int64{1, 2},
+			rounds:     2,
+			eliminated: [][]int64{{3}, nil},
+		},
+		{
+			name:       "movies tied for the last place are eliminated together",
+			ballots:    [][]int64{{1}, {1}, {1}, {2}, {2}, {3, 2}, {4, 2}},
+			winners:    []int64{2},
+			rounds:     2,
+			eliminated: [][]int64{{3, 4}, nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := InstantRunoff(tt.ballots)
+			if !slices.Equal(result.Winners, tt.winners) {
+				t.Errorf("winners = %v, want %v", result.Winners, tt.winners)
+			}
+			if len(result.Rounds) != tt.rounds {
+				t.Fatalf("rounds = %d, want %d", len(result.Rounds), tt.rounds)
+			}
+			for i, eliminated := range tt.eliminated {
+				if !slices.Equal(result.Rounds[i].Eliminated, eliminated) {
+					t.Errorf("round %d eliminated = %v, want %v", i+1, result.Rounds[i].Eliminated, eliminated)
+				}
+			}
+		})
+	}
+}
+
+func TestInstantRunoffTalliesSorted(t *testing.T) {
+	result := InstantRunoff([][]int64{{1}, {2}, {2}, {3}, {3}, {3}})
+	tallies := result.Rounds[0].Tallies
+	want := []Tally{{MovieID: 3, Votes: 3}, {MovieID: 2, Votes: 2}, {MovieID: 1, Votes: 1}}
+	if !slices.Equal(tallies, want) {
+		t.Errorf("tallies = %v, want %v", tallies, want)
+	}
+}
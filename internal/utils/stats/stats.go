@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+func Mean(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, value := range values {
+		sum += value
+	}
+	return float64(sum) / float64(len(values))
+}
+
+func Median(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return float64(sorted[middle-1]+sorted[middle]) / 2
+	}
+	return float64(sorted[middle])
+}
+
+// StdDev returns the population standard deviation.
+func StdDev(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	mean := Mean(values)
+	var sum float64
+	for _, value := range values {
+		diff := float64(value) - mean
+		sum += diff * diff
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+// Histogram counts the values from 1 to size, values out of the range are skipped.
+func Histogram(values []int, size int) []int {
+	histogram := make([]int, size)
+	for _, value := range values {
+		if value >= 1 && value <= size {
+			histogram[value-1]++
+		}
+	}
+	return histogram
+}
+
+// Expand turns a histogram back into the list of values.
+func Expand(histogram []int) []int {
+	var values []int
+	for i, count := range histogram {
+		for j := 0; j < count; j++ {
+			values = append(values, i+1)
+		}
+	}
+	return values
+}
+
+// BayesianAverage pulls the mean of a few votes toward the prior mean,
+// weight is the number of virtual votes given to the prior.
+func BayesianAverage(mean float64, count int, priorMean float64, weight float64) float64 {
+	total := float64(count) + weight
+	if total == 0 {
+		return 0
+	}
+	return (mean*float64(count) + priorMean*weight) / total
+}
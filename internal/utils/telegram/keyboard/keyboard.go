@@ -5,6 +5,7 @@ package keyboard
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 
 	"github.com/go-telegram/bot"
@@ -61,3 +62,17 @@ func (kb *Keyboard) MarshalJSON() ([]byte, error) {
 func defaultOnError(err error) {
 	log.Printf("[TG-UI-INLINE-KEYBOARD] [ERROR] %s", err)
 }
+
+// Close unregisters the handler of the widget and deletes its message.
+// Keyboards created with NoDeleteAfterClick call it once a click is accepted.
+func (kb *Keyboard) Close(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.UnregisterHandler(kb.callbackHandlerID)
+
+	_, errDelete := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    update.CallbackQuery.Message.Message.Chat.ID,
+		MessageID: update.CallbackQuery.Message.Message.ID,
+	})
+	if errDelete != nil {
+		kb.onError(fmt.Errorf("error delete message in callback, %w", errDelete))
+	}
+}
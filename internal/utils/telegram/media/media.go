@@ -0,0 +1,115 @@
+package media
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// CAPTION_MAX_LENGTH is the Telegram limit of a photo caption, longer cards are sent as text.
+const CAPTION_MAX_LENGTH = 1024
+
+// MEDIA_GROUP_MAX_SIZE is the Telegram limit of photos in one album.
+const MEDIA_GROUP_MAX_SIZE = 10
+
+// Card is an HTML caption with the poster it is shown under.
+type Card struct {
+	PhotoURL string
+	Caption  string
+}
+
+func (c Card) hasPhoto() bool {
+	return c.PhotoURL != "" && len([]rune(c.Caption)) <= CAPTION_MAX_LENGTH
+}
+
+// Send sends the header and the cards in their order: cards with a poster as photos or albums,
+// the rest as text. Without any poster everything is joined into one text message.
+// A poster Telegram fails to fetch falls back to text too.
+func Send(ctx context.Context, b *bot.Bot, chatID int64, header string, cards []Card) error {
+	withPhoto := false
+	for _, card := range cards {
+		if card.hasPhoto() {
+			withPhoto = true
+			break
+		}
+	}
+	if !withPhoto {
+		texts := make([]string, 0, len(cards)+1)
+		if header != "" {
+			texts = append(texts, header)
+		}
+		for _, card := range cards {
+			texts = append(texts, card.Caption)
+		}
+		return sendText(ctx, b, chatID, strings.Join(texts, "\n"))
+	}
+	if header != "" {
+		if err := sendText(ctx, b, chatID, header); err != nil {
+			return err
+		}
+	}
+	for len(cards) > 0 {
+		n := 0
+		if cards[0].hasPhoto() {
+			for n < len(cards) && n < MEDIA_GROUP_MAX_SIZE && cards[n].hasPhoto() {
+				n++
+			}
+			err := sendPhotos(ctx, b, chatID, cards[:n])
+			if err == nil {
+				cards = cards[n:]
+				continue
+			}
+			log.Printf("Error sending posters, falling back to text: %v", err)
+		} else {
+			for n < len(cards) && !cards[n].hasPhoto() {
+				n++
+			}
+		}
+		texts := make([]string, n)
+		for i, card := range cards[:n] {
+			texts[i] = card.Caption
+		}
+		if err := sendText(ctx, b, chatID, strings.Join(texts, "\n")); err != nil {
+			return err
+		}
+		cards = cards[n:]
+	}
+	return nil
+}
+
+func sendPhotos(ctx context.Context, b *bot.Bot, chatID int64, cards []Card) error {
+	if len(cards) == 1 {
+		_, err := b.SendPhoto(ctx, &bot.SendPhotoParams{
+			ChatID:    chatID,
+			Photo:     &models.InputFileString{Data: cards[0].PhotoURL},
+			Caption:   cards[0].Caption,
+			ParseMode: models.ParseModeHTML,
+		})
+		return err
+	}
+	album := make([]models.InputMedia, len(cards))
+	for i, card := range cards {
+		album[i] = &models.InputMediaPhoto{
+			Media:     card.PhotoURL,
+			Caption:   card.Caption,
+			ParseMode: models.ParseModeHTML,
+		}
+	}
+	_, err := b.SendMediaGroup(ctx, &bot.SendMediaGroupParams{
+		ChatID: chatID,
+		Media:  album,
+	})
+	return err
+}
+
+func sendText(ctx context.Context, b *bot.Bot, chatID int64, text string) error {
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	return err
+}
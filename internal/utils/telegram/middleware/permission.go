@@ -23,6 +23,9 @@ func CheckIfInGroup(ctx context.Context, b *bot.Bot, update *models.Update, grou
 	} else if update.PollAnswer != nil {
 		userID = update.PollAnswer.User.ID
 		chatID = 0
+	} else if update.InlineQuery != nil {
+		userID = update.InlineQuery.From.ID
+		chatID = 0
 	} else {
 		log.Println("Update type is not supported for group check")
 		return false
@@ -85,6 +88,10 @@ func CheckIfInGroup(ctx context.Context, b *bot.Bot, update *models.Update, grou
 			userName = update.PollAnswer.User.Username
 			firstName = update.PollAnswer.User.FirstName
 			lastName = update.PollAnswer.User.LastName
+		} else if update.InlineQuery != nil && update.InlineQuery.From != nil {
+			userName = update.InlineQuery.From.Username
+			firstName = update.InlineQuery.From.FirstName
+			lastName = update.InlineQuery.From.LastName
 		}
 		role := model.ROLE_USER
 		chatAdmins, adminErr := b.GetChatAdministrators(ctx, &bot.GetChatAdministratorsParams{